@@ -0,0 +1,234 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authGrantClientCredentials = "client_credentials"
+	authGrantPassword          = "password"
+	authGrantRefreshToken      = "refresh_token"
+)
+
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+const grpcAuthorizationKey = "authorization"
+
+// authenticator obtains the access token using OAuth2 grants and caches it for the lifetime of the runner.
+type authenticator struct {
+	c *authConfig
+	// client - HTTP client to request the token endpoint.
+	client   *http.Client
+	tokenURL string
+	token    *oauth2.Token
+	mu       sync.Mutex
+}
+
+func newAuthenticator(c *authConfig) (*authenticator, error) {
+	switch c.Grant {
+	case authGrantClientCredentials:
+		if c.ClientID == "" {
+			return nil, fmt.Errorf("invalid auth: %s grant requires clientID", c.Grant)
+		}
+	case authGrantPassword:
+		if c.Username == "" {
+			return nil, fmt.Errorf("invalid auth: %s grant requires username", c.Grant)
+		}
+	case authGrantRefreshToken:
+		if c.RefreshToken == "" {
+			return nil, fmt.Errorf("invalid auth: %s grant requires refreshToken", c.Grant)
+		}
+	default:
+		return nil, fmt.Errorf("invalid auth: unsupported grant: %q", c.Grant)
+	}
+	if c.TokenURL == "" && c.Issuer == "" {
+		return nil, errors.New("invalid auth: tokenURL or issuer is required")
+	}
+	return &authenticator{
+		c:        c,
+		tokenURL: c.TokenURL,
+	}, nil
+}
+
+// Token returns the cached access token, or obtains a new one if the token is not cached or expired.
+func (a *authenticator) Token(ctx context.Context) (*oauth2.Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token.Valid() {
+		return a.token, nil
+	}
+	if a.client != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, a.client)
+	}
+	if a.tokenURL == "" {
+		u, err := a.discoverTokenURL(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.tokenURL = u
+	}
+	var (
+		t   *oauth2.Token
+		err error
+	)
+	if a.token != nil && a.token.RefreshToken != "" {
+		// Refresh the expired token, and fall back to the grant if it fails.
+		t, err = a.config().TokenSource(ctx, &oauth2.Token{RefreshToken: a.token.RefreshToken}).Token()
+	}
+	if t == nil || err != nil {
+		t, err = a.grant(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain the access token: %w", err)
+		}
+	}
+	a.token = t
+	return t, nil
+}
+
+// Invalidate discards the cached access token ( e.g. when the token is rejected by the server ).
+func (a *authenticator) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == nil {
+		return
+	}
+	// Keep the refresh token to refresh the access token.
+	a.token = &oauth2.Token{RefreshToken: a.token.RefreshToken}
+}
+
+func (a *authenticator) grant(ctx context.Context) (*oauth2.Token, error) {
+	switch a.c.Grant {
+	case authGrantClientCredentials:
+		c := &clientcredentials.Config{
+			ClientID:       a.c.ClientID,
+			ClientSecret:   a.c.ClientSecret,
+			TokenURL:       a.tokenURL,
+			Scopes:         a.c.Scopes,
+			EndpointParams: url.Values{},
+		}
+		for k, v := range a.c.Params {
+			c.EndpointParams.Set(k, v)
+		}
+		return c.Token(ctx)
+	case authGrantPassword:
+		return a.config().PasswordCredentialsToken(ctx, a.c.Username, a.c.Password)
+	case authGrantRefreshToken:
+		return a.config().TokenSource(ctx, &oauth2.Token{RefreshToken: a.c.RefreshToken}).Token()
+	default:
+		return nil, fmt.Errorf("unsupported grant: %q", a.c.Grant)
+	}
+}
+
+func (a *authenticator) config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     a.c.ClientID,
+		ClientSecret: a.c.ClientSecret,
+		Scopes:       a.c.Scopes,
+		Endpoint: oauth2.Endpoint{
+			TokenURL: a.tokenURL,
+		},
+	}
+}
+
+// discoverTokenURL fetches the token endpoint from the OpenID Provider Configuration of the issuer.
+func (a *authenticator) discoverTokenURL(ctx context.Context) (string, error) {
+	u := strings.TrimSuffix(a.c.Issuer, "/") + oidcDiscoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	client := http.DefaultClient
+	if a.client != nil {
+		client = a.client
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover the token endpoint: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to discover the token endpoint: %s returned %d", u, res.StatusCode)
+	}
+	var conf struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&conf); err != nil {
+		return "", fmt.Errorf("failed to discover the token endpoint: %w", err)
+	}
+	if conf.TokenEndpoint == "" {
+		return "", fmt.Errorf("failed to discover the token endpoint: token_endpoint is not found in %s", u)
+	}
+	return conf.TokenEndpoint, nil
+}
+
+// setAuthHeader sets the access token to the Authorization header if it is not specified.
+func (a *authenticator) setAuthHeader(ctx context.Context, req *http.Request) (bool, error) {
+	if req.Header.Get("Authorization") != "" {
+		return false, nil
+	}
+	t, err := a.Token(ctx)
+	if err != nil {
+		return false, err
+	}
+	t.SetAuthHeader(req)
+	return true, nil
+}
+
+// setAuthMetadata sets the access token to the authorization metadata if it is not specified.
+func (a *authenticator) setAuthMetadata(ctx context.Context) (context.Context, bool, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(grpcAuthorizationKey)) > 0 {
+		return ctx, false, nil
+	}
+	t, err := a.Token(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, grpcAuthorizationKey, fmt.Sprintf("%s %s", t.Type(), t.AccessToken)), true, nil
+}
+
+// unaryClientInterceptor sets the access token, and retries once with a new token if the server returns Unauthenticated.
+func (a *authenticator) unaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		actx, injected, err := a.setAuthMetadata(ctx)
+		if err != nil {
+			return err
+		}
+		err = invoker(actx, method, req, reply, cc, opts...)
+		if !injected || status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+		a.Invalidate()
+		actx, _, err = a.setAuthMetadata(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(actx, method, req, reply, cc, opts...)
+	}
+}
+
+// streamClientInterceptor sets the access token.
+// Unlike unaryClientInterceptor, it does not retry with a new token, because Unauthenticated is returned after the messages of the stream may have been sent.
+func (a *authenticator) streamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		actx, _, err := a.setAuthMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(actx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,241 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPRunnerAuth(t *testing.T) {
+	ts := testutil.OAuth2Server(t)
+	t.Setenv("TEST_OAUTH2_ENDPOINT", ts.URL)
+	o, err := New(Book("testdata/book/http_auth.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"client_credentials"}; !cmp.Equal(ts.Grants(), want) {
+		t.Errorf("got %v\nwant %v", ts.Grants(), want)
+	}
+}
+
+func TestAuthenticatorGrants(t *testing.T) {
+	tests := []struct {
+		name string
+		c    func(u string) *authConfig
+		want []string
+	}{
+		{
+			"client_credentials",
+			func(u string) *authConfig {
+				return &authConfig{Grant: authGrantClientCredentials, TokenURL: u + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret}
+			},
+			[]string{"client_credentials"},
+		},
+		{
+			"password",
+			func(u string) *authConfig {
+				return &authConfig{Grant: authGrantPassword, TokenURL: u + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret, Username: testutil.OAuth2Username, Password: testutil.OAuth2Password}
+			},
+			[]string{"password"},
+		},
+		{
+			"refresh_token",
+			func(u string) *authConfig {
+				return &authConfig{Grant: authGrantRefreshToken, TokenURL: u + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret, RefreshToken: testutil.OAuth2RefreshToken}
+			},
+			[]string{"refresh_token"},
+		},
+		{
+			"discover token endpoint using issuer",
+			func(u string) *authConfig {
+				return &authConfig{Grant: authGrantClientCredentials, Issuer: u, ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret}
+			},
+			[]string{"client_credentials"},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := testutil.OAuth2Server(t)
+			a, err := newAuthenticator(tt.c(ts.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t1, err := a.Token(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t2, err := a.Token(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if t1.AccessToken != t2.AccessToken {
+				t.Errorf("the token should be cached: %s, %s", t1.AccessToken, t2.AccessToken)
+			}
+			if diff := cmp.Diff(ts.Grants(), tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorRefreshOnExpiry(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.OAuth2Server(t)
+	// Tokens that expire within 10 seconds are treated as expired by golang.org/x/oauth2.
+	ts.SetExpiresIn(1)
+	a, err := newAuthenticator(&authConfig{Grant: authGrantPassword, TokenURL: ts.URL + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret, Username: testutil.OAuth2Username, Password: testutil.OAuth2Password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := a.Token(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"password", "refresh_token"}; !cmp.Equal(ts.Grants(), want) {
+		t.Errorf("got %v\nwant %v", ts.Grants(), want)
+	}
+}
+
+func TestHTTPRunnerAuthRetryOnUnauthorized(t *testing.T) {
+	tests := []struct {
+		name      string
+		newRunner func(ts *testutil.OAuth2Stub) (*httpRunner, error)
+	}{
+		{
+			"client",
+			func(ts *testutil.OAuth2Stub) (*httpRunner, error) {
+				return newHTTPRunner("req", ts.URL)
+			},
+		},
+		{
+			"handler",
+			func(ts *testutil.OAuth2Stub) (*httpRunner, error) {
+				return newHTTPRunnerWithHandler("req", ts.Config.Handler)
+			},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := testutil.OAuth2Server(t)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := tt.newRunner(ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.auth, err = newAuthenticator(&authConfig{Grant: authGrantClientCredentials, TokenURL: ts.URL + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range 2 {
+				if i == 1 {
+					ts.Revoke()
+				}
+				req := &httpRequest{
+					path:    "/me",
+					method:  http.MethodGet,
+					headers: http.Header{},
+				}
+				s := newStep(i, "stepKey", o, nil)
+				if err := r.run(ctx, req, s); err != nil {
+					t.Fatal(err)
+				}
+				res, ok := o.store.latest()["res"].(map[string]any)
+				if !ok {
+					t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+				}
+				if got := res["status"]; got != http.StatusOK {
+					t.Errorf("got %v\nwant %v", got, http.StatusOK)
+				}
+			}
+			if want := []string{"client_credentials", "refresh_token"}; !cmp.Equal(ts.Grants(), want) {
+				t.Errorf("got %v\nwant %v", ts.Grants(), want)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerAuthNotCaptured(t *testing.T) {
+	ts := testutil.OAuth2Server(t)
+	t.Setenv("TEST_OAUTH2_ENDPOINT", ts.URL)
+	buf := new(bytes.Buffer)
+	o, err := New(Book("testdata/book/http_auth.yml"), Debug(true), Stderr(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Authorization: Bearer access-token-1") {
+		t.Errorf("the access token is captured: %s", buf.String())
+	}
+	// The Authorization header specified in the step is captured.
+	if !strings.Contains(buf.String(), "Bearer invalid") {
+		t.Errorf("the Authorization header of the step is not captured: %s", buf.String())
+	}
+}
+
+func TestAuthenticatorGRPCInterceptor(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.OAuth2Server(t)
+	a, err := newAuthenticator(&authConfig{Grant: authGrantClientCredentials, TokenURL: ts.URL + "/token", ClientID: testutil.OAuth2ClientID, ClientSecret: testutil.OAuth2ClientSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = append(got, md.Get("authorization")...)
+		if len(got) == 1 {
+			return status.Error(codes.Unauthenticated, "token revoked")
+		}
+		return nil
+	}
+	if err := a.unaryClientInterceptor()(ctx, "/grpctest.GrpcTestService/Hello", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Bearer access-token-1", "Bearer access-token-2"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+
+	t.Run("Do not override authorization metadata", func(t *testing.T) {
+		got = nil
+		ctx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer explicit")
+		_ = a.unaryClientInterceptor()(ctx, "/grpctest.GrpcTestService/Hello", nil, nil, nil, invoker)
+		if want := []string{"Bearer explicit"}; !cmp.Equal(got, want) {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	})
+}
+
+func TestNewAuthenticatorInvalid(t *testing.T) {
+	tests := []*authConfig{
+		{Grant: "implicit", TokenURL: "http://localhost/token"},
+		{Grant: authGrantClientCredentials, TokenURL: "http://localhost/token"},
+		{Grant: authGrantPassword, TokenURL: "http://localhost/token", ClientID: "runn"},
+		{Grant: authGrantRefreshToken, TokenURL: "http://localhost/token", ClientID: "runn"},
+		{Grant: authGrantClientCredentials, ClientID: "runn"},
+	}
+	for _, tt := range tests {
+		if _, err := newAuthenticator(tt); err == nil {
+			t.Errorf("want error: %#v", tt)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -11,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/chromedp/chromedp"
 	"github.com/goccy/go-json"
 	"github.com/k1LoW/duration"
 	"github.com/k1LoW/runn/tmpmod/github.com/goccy/go-yaml"
@@ -28,9 +30,11 @@ type book struct {
 	rawSteps             []map[string]any
 	hostRules            hostRules
 	hostRulesFromOpts    hostRules
+	proxy                *url.URL
 	debug                bool
 	ifCond               string
 	skipTest             bool
+	updateSnapshots      bool
 	funcs                map[string]any
 	stepKeys             []string
 	path                 string // runbook file path
@@ -39,6 +43,9 @@ type book struct {
 	grpcRunners          map[string]*grpcRunner
 	cdpRunners           map[string]*cdpRunner
 	sshRunners           map[string]*sshRunner
+	websocketRunners     map[string]*websocketRunner
+	mockRunners          map[string]*mockRunner
+	queueRunners         map[string]*queueRunner
 	includeRunners       map[string]*includeRunner
 	profile              bool
 	intervalStr          string
@@ -50,6 +57,9 @@ type book struct {
 	included             bool
 	force                bool
 	trace                bool
+	otel                 *otelTracer
+	replayDir            string
+	responseCoverage     *responseCoverageCapturer
 	attach               bool
 	waitTimeout          time.Duration // waitTimout is the time to wait for sub-processes to complete after the Run or RunN context is canceled
 	failFast             bool
@@ -57,11 +67,13 @@ type book struct {
 	openAPI3DocLocations []string
 	grpcNoTLS            bool
 	grpcProtos           []string
+	grpcProtosets        []string
 	grpcImportPaths      []string
 	grpcBufDirs          []string
 	grpcBufLocks         []string
 	grpcBufConfigs       []string
 	grpcBufModules       []string
+	grpcProtoCacheDir    string
 	runIDs               []string
 	runMatch             *regexp.Regexp
 	runLabels            []string
@@ -214,6 +226,18 @@ func (bk *book) parseRunner(k string, v any) error {
 				return err
 			}
 			bk.sshRunners[k] = sc
+		case strings.HasPrefix(vv, "ws://") || strings.HasPrefix(vv, "wss://"):
+			wc, err := newWebSocketRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.websocketRunners[k] = wc
+		case strings.HasPrefix(vv, "kafka://") || strings.HasPrefix(vv, "nats://"):
+			qc, err := newQueueRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.queueRunners[k] = qc
 		default:
 			dc, err := newDBRunner(k, vv)
 			if err != nil {
@@ -242,6 +266,22 @@ func (bk *book) parseRunner(k string, v any) error {
 			}
 		}
 
+		// CDP Runner
+		if !detect {
+			detect, err = bk.parseCDPRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
+		// WebSocket Runner
+		if !detect {
+			detect, err = bk.parseWebSocketRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// DB Runner
 		if !detect {
 			detect, err = bk.parseDBRunnerWithDetailed(k, tmp)
@@ -258,6 +298,14 @@ func (bk *book) parseRunner(k string, v any) error {
 			}
 		}
 
+		// Mock Runner
+		if !detect {
+			detect, err = bk.parseMockRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Include Runner
 		if !detect {
 			detect, err = bk.parseIncludeRunnerWithDetailed(k, tmp)
@@ -299,6 +347,9 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 	if c.OpenAPI3DocLocation != "" && !strings.HasPrefix(c.OpenAPI3DocLocation, "https://") && !strings.HasPrefix(c.OpenAPI3DocLocation, "http://") && !strings.HasPrefix(c.OpenAPI3DocLocation, "/") {
 		c.OpenAPI3DocLocation = fp(c.OpenAPI3DocLocation, root)
 	}
+	if c.GraphQLSchemaLocation != "" && !strings.HasPrefix(c.GraphQLSchemaLocation, "https://") && !strings.HasPrefix(c.GraphQLSchemaLocation, "http://") && !strings.HasPrefix(c.GraphQLSchemaLocation, "/") {
+		c.GraphQLSchemaLocation = fp(c.GraphQLSchemaLocation, root)
+	}
 	if c.CACert != "" {
 		b, err := readFile(fp(c.CACert, root))
 		if err != nil {
@@ -330,6 +381,30 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 	r.useCookie = c.UseCookie
 	r.trace = c.Trace.Enable
 	r.traceHeaderName = c.Trace.HeaderName
+	if c.Auth != nil {
+		a, err := newAuthenticator(c.Auth)
+		if err != nil {
+			return false, err
+		}
+		a.client = r.client
+		r.auth = a
+	}
+	r.signer, err = c.httpSigner()
+	if err != nil {
+		return false, err
+	}
+	if c.Retry != nil {
+		r.retry, err = newRetrier(c.Retry)
+		if err != nil {
+			return false, err
+		}
+	}
+	if c.Proxy != "" {
+		r.proxy, err = parseProxyURL(c.Proxy)
+		if err != nil {
+			return false, err
+		}
+	}
 	hv, err := newHttpValidator(c)
 	if err != nil {
 		return false, err
@@ -354,6 +429,10 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 	if err != nil {
 		return false, err
 	}
+	r.protocol, err = parseGRPCProtocol(c.Protocol)
+	if err != nil {
+		return false, err
+	}
 	r.tls = c.TLS
 	if len(c.cacert) != 0 {
 		r.cacert = c.cacert
@@ -389,6 +468,9 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 	for _, p := range c.Protos {
 		r.protos = append(r.protos, fp(p, root))
 	}
+	for _, p := range c.Protosets {
+		r.protosets = append(r.protosets, fp(p, root))
+	}
 	for _, p := range c.BufDirs {
 		r.bufDirs = append(r.bufDirs, fp(p, root))
 	}
@@ -399,13 +481,123 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 		r.bufConfigs = append(r.bufConfigs, fp(p, root))
 	}
 	r.bufModules = c.BufModules
+	if c.Proxy != "" {
+		r.proxy, err = parseProxyURL(c.Proxy)
+		if err != nil {
+			return false, err
+		}
+	}
 	r.trace = c.Trace.Enable
 	r.traceHeaderName = c.Trace.HeaderName
+	if c.Auth != nil {
+		a, err := newAuthenticator(c.Auth)
+		if err != nil {
+			return false, err
+		}
+		r.auth = a
+	}
+	if c.Retry != nil {
+		r.retry, err = newRetrier(c.Retry)
+		if err != nil {
+			return false, err
+		}
+	}
 
 	bk.grpcRunners[name] = r
 	return true, nil
 }
 
+func (bk *book) parseCDPRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &cdpRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if !strings.HasPrefix(c.Remote, "cdp://") && !strings.HasPrefix(c.Remote, "chrome://") {
+		return false, nil
+	}
+	remote := strings.TrimPrefix(strings.TrimPrefix(c.Remote, "cdp://"), "chrome://")
+	r, err := newCDPRunner(name, remote)
+	if err != nil {
+		return false, err
+	}
+	for n, v := range c.Flags {
+		r.opts = append(r.opts, chromedp.Flag(n, v))
+	}
+	if c.Proxy != "" {
+		r.proxy, err = parseProxyURL(c.Proxy)
+		if err != nil {
+			return false, err
+		}
+	}
+	bk.cdpRunners[name] = r
+	return true, nil
+}
+
+func (bk *book) parseWebSocketRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &websocketRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if !strings.HasPrefix(c.URL, "ws://") && !strings.HasPrefix(c.URL, "wss://") {
+		return false, nil
+	}
+	r, err := newWebSocketRunner(name, c.URL)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range c.Headers {
+		r.headers.Set(k, v)
+	}
+	r.skipVerify = c.SkipVerify
+	if c.Timeout != "" {
+		r.timeout, err = duration.Parse(c.Timeout)
+		if err != nil {
+			return false, fmt.Errorf("timeout in WebSocketRunnerConfig is invalid: %w", err)
+		}
+	}
+	bk.websocketRunners[name] = r
+	return true, nil
+}
+
+func (bk *book) parseMockRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &mockRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if c.Mock == nil {
+		return false, nil
+	}
+	r, err := newMockRunner(name, c.Mock)
+	if err != nil {
+		return false, err
+	}
+	if c.Mock.GRPC != nil {
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return false, err
+		}
+		gr := r.grpcResolver
+		for _, p := range c.Mock.GRPC.ImportPaths {
+			gr.importPaths = append(gr.importPaths, fp(p, root))
+		}
+		for _, p := range c.Mock.GRPC.Protos {
+			gr.protos = append(gr.protos, fp(p, root))
+		}
+		for _, p := range c.Mock.GRPC.BufDirs {
+			gr.bufDirs = append(gr.bufDirs, fp(p, root))
+		}
+		for _, p := range c.Mock.GRPC.BufLocks {
+			gr.bufLocks = append(gr.bufLocks, fp(p, root))
+		}
+		for _, p := range c.Mock.GRPC.BufConfigs {
+			gr.bufConfigs = append(gr.bufConfigs, fp(p, root))
+		}
+		gr.bufModules = c.Mock.GRPC.BufModules
+	}
+	bk.mockRunners[name] = r
+	return true, nil
+}
+
 func (bk *book) parseDBRunnerWithDetailed(name string, b []byte) (bool, error) {
 	c := &dbRunnerConfig{}
 	if err := yaml.Unmarshal(b, c); err != nil {
@@ -581,6 +773,15 @@ func (bk *book) merge(loaded *book) error {
 	for k, r := range loaded.sshRunners {
 		bk.sshRunners[k] = r
 	}
+	for k, r := range loaded.websocketRunners {
+		bk.websocketRunners[k] = r
+	}
+	for k, r := range loaded.mockRunners {
+		bk.mockRunners[k] = r
+	}
+	for k, r := range loaded.queueRunners {
+		bk.queueRunners[k] = r
+	}
 	for k, r := range loaded.includeRunners {
 		bk.includeRunners[k] = r
 	}
@@ -609,11 +810,13 @@ func (bk *book) merge(loaded *book) error {
 	bk.openAPI3DocLocations = loaded.openAPI3DocLocations
 	bk.grpcNoTLS = loaded.grpcNoTLS
 	bk.grpcProtos = loaded.grpcProtos
+	bk.grpcProtosets = loaded.grpcProtosets
 	bk.grpcImportPaths = loaded.grpcImportPaths
 	bk.grpcBufDirs = loaded.grpcBufDirs
 	bk.grpcBufLocks = loaded.grpcBufLocks
 	bk.grpcBufConfigs = loaded.grpcBufConfigs
 	bk.grpcBufModules = loaded.grpcBufModules
+	bk.grpcProtoCacheDir = loaded.grpcProtoCacheDir
 	if loaded.intervalStr != "" {
 		bk.interval = loaded.interval
 	}
@@ -653,20 +856,23 @@ func fp(p, root string) string {
 
 func newBook() *book {
 	return &book{
-		runners:        map[string]any{},
-		vars:           map[string]any{},
-		rawSteps:       []map[string]any{},
-		funcs:          map[string]any{},
-		httpRunners:    map[string]*httpRunner{},
-		dbRunners:      map[string]*dbRunner{},
-		grpcRunners:    map[string]*grpcRunner{},
-		cdpRunners:     map[string]*cdpRunner{},
-		sshRunners:     map[string]*sshRunner{},
-		includeRunners: map[string]*includeRunner{},
-		interval:       0 * time.Second,
-		runnerErrs:     map[string]error{},
-		stdout:         os.Stdout,
-		stderr:         os.Stderr,
+		runners:          map[string]any{},
+		vars:             map[string]any{},
+		rawSteps:         []map[string]any{},
+		funcs:            map[string]any{},
+		httpRunners:      map[string]*httpRunner{},
+		dbRunners:        map[string]*dbRunner{},
+		grpcRunners:      map[string]*grpcRunner{},
+		cdpRunners:       map[string]*cdpRunner{},
+		sshRunners:       map[string]*sshRunner{},
+		websocketRunners: map[string]*websocketRunner{},
+		mockRunners:      map[string]*mockRunner{},
+		queueRunners:     map[string]*queueRunner{},
+		includeRunners:   map[string]*includeRunner{},
+		interval:         0 * time.Second,
+		runnerErrs:       map[string]error{},
+		stdout:           os.Stdout,
+		stderr:           os.Stderr,
 	}
 }
 
@@ -719,7 +925,7 @@ func parseBook(in io.Reader) (*book, error) {
 }
 
 func validateRunnerKey(k string) error {
-	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey || k == runnerRunnerKey {
+	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey || k == jsonSchemaRunnerKey || k == runnerRunnerKey || k == parallelRunnerKey {
 		return fmt.Errorf("runner name %q is reserved for built-in runner", k)
 	}
 	if k == ifSectionKey || k == descSectionKey || k == loopSectionKey {
@@ -739,7 +945,7 @@ func validateStepKeys(s map[string]any) error {
 		if k == ifSectionKey || k == descSectionKey || k == loopSectionKey {
 			continue
 		}
-		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey {
+		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey || k == jsonSchemaRunnerKey {
 			subRunner += 1
 			continue
 		}
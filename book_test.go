@@ -147,7 +147,7 @@ func TestParseRunnerForHttpRunner(t *testing.T) {
 	}{
 		{
 			"https://example.com/",
-			httpRunner{
+			&httpRunner{
 				name:            "req",
 				endpoint:        secureUrl,
 				client:          client,
@@ -157,7 +157,7 @@ func TestParseRunnerForHttpRunner(t *testing.T) {
 		},
 		{
 			"http://example.com/",
-			httpRunner{
+			&httpRunner{
 				name:            "req",
 				endpoint:        url,
 				client:          client,
@@ -168,6 +168,7 @@ func TestParseRunnerForHttpRunner(t *testing.T) {
 	}
 	opts := []cmp.Option{
 		cmp.AllowUnexported(httpRunner{}),
+		cmpopts.IgnoreFields(httpRunner{}, "mu"),
 		cmpopts.IgnoreFields(http.Client{}, "Transport"),
 	}
 
@@ -178,7 +179,7 @@ func TestParseRunnerForHttpRunner(t *testing.T) {
 		}
 
 		got := bk.httpRunners["req"]
-		if diff := cmp.Diff(*got, tt.want, opts...); diff != "" {
+		if diff := cmp.Diff(got, tt.want, opts...); diff != "" {
 			t.Error(diff)
 		}
 	}
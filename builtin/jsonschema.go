@@ -0,0 +1,175 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/k1LoW/runn/tmpmod/github.com/goccy/go-yaml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const jsonSchemaInlineURL = "inline.json"
+
+// JSONSchemaErrors is the errors of validating the value against the JSON Schema.
+type JSONSchemaErrors []string
+
+// JSONSchema validates the value against the JSON Schema and returns the validation errors.
+// The schema is the path to the schema file ( JSON or YAML ) or the schema object.
+func JSONSchema(schema, v any) (JSONSchemaErrors, error) {
+	return defaultJSONSchemaValidator.validate(schema, v)
+}
+
+var defaultJSONSchemaValidator = newJSONSchemaValidator("", os.ReadFile)
+
+// NewJSONSchema returns the function that is the same as JSONSchema but loads the schema files relative to root using read.
+func NewJSONSchema(root string, read func(string) ([]byte, error)) func(schema, v any) (JSONSchemaErrors, error) {
+	return newJSONSchemaValidator(root, read).validate
+}
+
+type jsonSchemaValidator struct {
+	root string
+	read func(string) ([]byte, error)
+	// cache - Compiled schemas of the files
+	cache map[string]*jsonschema.Schema
+	mu    sync.Mutex
+}
+
+func newJSONSchemaValidator(root string, read func(string) ([]byte, error)) *jsonSchemaValidator {
+	return &jsonSchemaValidator{
+		root:  root,
+		read:  read,
+		cache: map[string]*jsonschema.Schema{},
+	}
+}
+
+func (jv *jsonSchemaValidator) validate(schema, v any) (JSONSchemaErrors, error) {
+	var (
+		s   *jsonschema.Schema
+		err error
+	)
+	switch ss := schema.(type) {
+	case string:
+		s, err = jv.compileFile(ss)
+	default:
+		s, err = jv.compileObject(ss)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	nv, err := normalizeJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+	errs := JSONSchemaErrors{}
+	if err := s.Validate(nv); err != nil {
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, err
+		}
+		errs = append(errs, leafJSONSchemaErrors(ve)...)
+	}
+	return errs, nil
+}
+
+func (jv *jsonSchemaValidator) compileFile(p string) (*jsonschema.Schema, error) {
+	p = strings.TrimPrefix(p, "json://")
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(jv.root, p)
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	jv.mu.Lock()
+	defer jv.mu.Unlock()
+	if s, ok := jv.cache[abs]; ok {
+		return s, nil
+	}
+	c := jv.newCompiler()
+	u := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	s, err := c.Compile(u)
+	if err != nil {
+		return nil, err
+	}
+	jv.cache[abs] = s
+	return s, nil
+}
+
+func (jv *jsonSchemaValidator) compileObject(schema any) (*jsonschema.Schema, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	c := jv.newCompiler()
+	if err := c.AddResource(jsonSchemaInlineURL, bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return c.Compile(jsonSchemaInlineURL)
+}
+
+// newCompiler returns the compiler that loads the schema files ( including the files referenced by $ref ) using read.
+func (jv *jsonSchemaValidator) newCompiler() *jsonschema.Compiler {
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("unsupported schema location: %s", s)
+		}
+		p := filepath.FromSlash(u.Path)
+		b, err := jv.read(p)
+		if err != nil {
+			return nil, err
+		}
+		if ext := filepath.Ext(p); ext == ".yml" || ext == ".yaml" {
+			b, err = yaml.YAMLToJSON(b)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	return c
+}
+
+// normalizeJSONValue converts the value into the value decoded from JSON.
+func normalizeJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var nv any
+	if err := dec.Decode(&nv); err != nil {
+		return nil, err
+	}
+	return nv, nil
+}
+
+// leafJSONSchemaErrors returns the errors of the leaves of the validation error ( e.g. "/name: expected string, but got number" ).
+func leafJSONSchemaErrors(ve *jsonschema.ValidationError) []string {
+	if len(ve.Causes) == 0 {
+		loc := ve.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		return []string{fmt.Sprintf("%s: %s", loc, ve.Message)}
+	}
+	var errs []string
+	for _, c := range ve.Causes {
+		errs = append(errs, leafJSONSchemaErrors(c)...)
+	}
+	sort.Strings(errs)
+	return errs
+}
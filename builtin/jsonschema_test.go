@@ -0,0 +1,83 @@
+package builtin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSchema(t *testing.T) {
+	inline := map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+		},
+	}
+	tests := []struct {
+		schema any
+		v      any
+		want   JSONSchemaErrors
+	}{
+		{
+			inline,
+			map[string]any{"name": "alice"},
+			JSONSchemaErrors{},
+		},
+		{
+			inline,
+			map[string]any{"name": 1},
+			JSONSchemaErrors{"/name: expected string, but got number"},
+		},
+		{
+			inline,
+			map[string]any{},
+			JSONSchemaErrors{"/: missing properties: 'name'"},
+		},
+		{
+			"user.json",
+			map[string]any{"id": 1, "name": "alice", "address": map[string]any{"city": "Tokyo", "zip": "100-0001"}},
+			JSONSchemaErrors{},
+		},
+		{
+			"json://user.json",
+			map[string]any{"id": 0, "name": "alice"},
+			JSONSchemaErrors{"/id: must be >= 1 but found 0"},
+		},
+		{
+			"user.json",
+			map[string]any{"id": 1, "name": true, "address": map[string]any{"zip": "1000001"}},
+			JSONSchemaErrors{
+				"/address/zip: does not match pattern '^[0-9]{3}-[0-9]{4}$'",
+				"/address: missing properties: 'city'",
+				"/name: expected string, but got boolean",
+			},
+		},
+	}
+	f := NewJSONSchema("../testdata/jsonschema", os.ReadFile)
+	for _, tt := range tests {
+		got, err := f(tt.schema, tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestJSONSchemaInvalidSchema(t *testing.T) {
+	tests := []struct {
+		schema any
+	}{
+		{"not_exist.json"},
+		{map[string]any{"type": 1}},
+	}
+	f := NewJSONSchema("../testdata/jsonschema", os.ReadFile)
+	for _, tt := range tests {
+		if _, err := f(tt.schema, map[string]any{}); err == nil {
+			t.Errorf("want error: %v", tt.schema)
+		}
+	}
+}
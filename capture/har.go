@@ -0,0 +1,392 @@
+package capture
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn"
+	"github.com/k1LoW/runn/version"
+	"google.golang.org/grpc/status"
+)
+
+var _ runn.Capturer = (*cHAR)(nil)
+
+type cHAR struct {
+	dir           string
+	currentTrails runn.Trails
+	errs          error
+	hars          sync.Map
+}
+
+// HAR ( HTTP Archive ) 1.2
+// ref: http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log *harLog `json:"log"`
+
+	currentStart time.Time
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator *harCreator `json:"creator"`
+	Pages   []*harPage  `json:"pages,omitempty"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harPage struct {
+	StartedDateTime time.Time       `json:"startedDateTime"`
+	ID              string          `json:"id"`
+	Title           string          `json:"title"`
+	PageTimings     *harPageTimings `json:"pageTimings"`
+}
+
+type harPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type harEntry struct {
+	Pageref         string       `json:"pageref,omitempty"`
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Time            float64      `json:"time"`
+	Request         *harRequest  `json:"request"`
+	Response        *harResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *harTimings  `json:"timings"`
+	Comment         string       `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*harCookie    `json:"cookies"`
+	Headers     []*harNameValue `json:"headers"`
+	QueryString []*harNameValue `json:"queryString"`
+	PostData    *harPostData    `json:"postData,omitempty"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int             `json:"status"`
+	StatusText  string          `json:"statusText"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*harCookie    `json:"cookies"`
+	Headers     []*harNameValue `json:"headers"`
+	Content     *harContent     `json:"content"`
+	RedirectURL string          `json:"redirectURL"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR returns the capturer that writes the HTTP requests and responses of each runbook as a HAR ( HTTP Archive ) file.
+func HAR(dir string) *cHAR {
+	return &cHAR{
+		dir:  dir,
+		hars: sync.Map{},
+	}
+}
+
+func (c *cHAR) CaptureStart(trs runn.Trails, bookPath, desc string) {
+	title := desc
+	if title == "" {
+		title = bookPath
+	}
+	c.hars.Store(trs[0], &har{
+		Log: &harLog{
+			Version: "1.2",
+			Creator: &harCreator{
+				Name:    version.Name,
+				Version: version.Version,
+			},
+			Pages: []*harPage{
+				{
+					StartedDateTime: time.Now(),
+					ID:              bookPath,
+					Title:           title,
+					PageTimings:     &harPageTimings{OnContentLoad: -1, OnLoad: -1},
+				},
+			},
+			Entries: []*harEntry{},
+		},
+	})
+}
+
+func (c *cHAR) CaptureResult(trs runn.Trails, result *runn.RunResult) {
+	if !result.Skipped {
+		c.writeHAR(trs, result.Path)
+	}
+}
+
+func (c *cHAR) CaptureEnd(trs runn.Trails, bookPath, desc string) {}
+
+func (c *cHAR) CaptureResultByStep(trs runn.Trails, result *runn.RunResult) {}
+
+func (c *cHAR) CaptureHTTPRequest(name string, req *http.Request) {
+	h := c.currentHAR()
+	if h == nil {
+		return
+	}
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, req.Body, err = drainBody(req.Body)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to drainBody: %w", err))
+		return
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to io.ReadAll: %w", err))
+		return
+	}
+	u := *req.URL
+	if u.Host == "" {
+		// The request to the http.Handler
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+	}
+	hr := &harRequest{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []*harCookie{},
+		Headers:     harHeaders(req.Header),
+		QueryString: harQueryString(u.Query()),
+		HeadersSize: -1,
+		BodySize:    len(b),
+	}
+	for _, ck := range req.Cookies() {
+		hr.Cookies = append(hr.Cookies, &harCookie{Name: ck.Name, Value: ck.Value})
+	}
+	if len(b) > 0 {
+		hr.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(b),
+		}
+	}
+	h.currentStart = time.Now()
+	h.Log.Entries = append(h.Log.Entries, &harEntry{
+		Pageref:         h.Log.Pages[0].ID,
+		StartedDateTime: h.currentStart,
+		Request:         hr,
+		// The response of the failed request remains empty ( status 0 ) as in browsers
+		Response: &harResponse{
+			Cookies:     []*harCookie{},
+			Headers:     []*harNameValue{},
+			Content:     &harContent{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: &harTimings{},
+		Comment: fmt.Sprintf("runner: %s", name),
+	})
+}
+
+func (c *cHAR) CaptureHTTPResponse(name string, res *http.Response) {
+	h := c.currentHAR()
+	if h == nil || len(h.Log.Entries) == 0 {
+		return
+	}
+	e := h.Log.Entries[len(h.Log.Entries)-1]
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, res.Body, err = drainBody(res.Body)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to drainBody: %w", err))
+		return
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to io.ReadAll: %w", err))
+		return
+	}
+	content := &harContent{
+		Size:     len(b),
+		MimeType: res.Header.Get("Content-Type"),
+	}
+	if utf8.Valid(b) {
+		content.Text = string(b)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(b)
+		content.Encoding = "base64"
+	}
+	hr := &harResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprintf("%d", res.StatusCode))),
+		HTTPVersion: res.Proto,
+		Cookies:     []*harCookie{},
+		Headers:     harHeaders(res.Header),
+		Content:     content,
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(b),
+	}
+	if hr.StatusText == "" {
+		hr.StatusText = http.StatusText(res.StatusCode)
+	}
+	for _, ck := range res.Cookies() {
+		hr.Cookies = append(hr.Cookies, &harCookie{Name: ck.Name, Value: ck.Value})
+	}
+	e.Response = hr
+	// runn does not measure the send and receive phases, so the whole time is regarded as the wait phase
+	elapsed := float64(time.Since(h.currentStart).Microseconds()) / 1000
+	e.Time = elapsed
+	e.Timings.Wait = elapsed
+}
+
+func (c *cHAR) CaptureGRPCStart(name string, typ runn.GRPCType, service, method string) {}
+func (c *cHAR) CaptureGRPCRequestHeaders(h map[string][]string)                         {}
+func (c *cHAR) CaptureGRPCRequestMessage(m map[string]any)                              {}
+func (c *cHAR) CaptureGRPCResponseStatus(s *status.Status)                              {}
+func (c *cHAR) CaptureGRPCResponseHeaders(h map[string][]string)                        {}
+func (c *cHAR) CaptureGRPCResponseMessage(m map[string]any)                             {}
+func (c *cHAR) CaptureGRPCResponseTrailers(t map[string][]string)                       {}
+func (c *cHAR) CaptureGRPCClientClose()                                                 {}
+func (c *cHAR) CaptureGRPCEnd(name string, typ runn.GRPCType, service, method string)   {}
+func (c *cHAR) CaptureCDPStart(name string)                                             {}
+func (c *cHAR) CaptureCDPAction(a runn.CDPAction)                                       {}
+func (c *cHAR) CaptureCDPResponse(a runn.CDPAction, res map[string]any)                 {}
+func (c *cHAR) CaptureCDPEnd(name string)                                               {}
+func (c *cHAR) CaptureWebSocketStart(name, url string)                                  {}
+func (c *cHAR) CaptureWebSocketSendMessage(m any)                                       {}
+func (c *cHAR) CaptureWebSocketReceiveMessage(m any)                                    {}
+func (c *cHAR) CaptureWebSocketClose()                                                  {}
+func (c *cHAR) CaptureWebSocketEnd(name, url string)                                    {}
+func (c *cHAR) CaptureQueueProduce(name string, m *runn.QueueMessage)                   {}
+func (c *cHAR) CaptureQueueConsume(name string, m *runn.QueueMessage)                   {}
+func (c *cHAR) CaptureSSHCommand(command string)                                        {}
+func (c *cHAR) CaptureSSHStdout(stdout string)                                          {}
+func (c *cHAR) CaptureSSHStderr(stderr string)                                          {}
+func (c *cHAR) CaptureDBStatement(name string, stmt string)                             {}
+func (c *cHAR) CaptureDBResponse(name string, res *runn.DBResponse)                     {}
+func (c *cHAR) CaptureExecCommand(command, shell string, background bool)               {}
+func (c *cHAR) CaptureExecStdin(stdin string)                                           {}
+func (c *cHAR) CaptureExecStdout(stdout string)                                         {}
+func (c *cHAR) CaptureExecStderr(stderr string)                                         {}
+
+func (c *cHAR) SetCurrentTrails(trs runn.Trails) {
+	c.currentTrails = trs
+}
+
+func (c *cHAR) Errs() error {
+	return c.errs
+}
+
+func (c *cHAR) currentHAR() *har {
+	v, ok := c.hars.Load(c.currentTrails[0])
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to c.hars.Load: %s", c.currentTrails[0]))
+		return nil
+	}
+	h, ok := v.(*har)
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to cast: %#v", v))
+		return nil
+	}
+	return h
+}
+
+func (c *cHAR) writeHAR(trs runn.Trails, bookPath string) {
+	v, ok := c.hars.Load(trs[0])
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to c.hars.Load: %s", trs[0]))
+		return
+	}
+	h, ok := v.(*har)
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to cast: %#v", v))
+		return
+	}
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to json.Marshal: %w", err))
+		return
+	}
+	p := filepath.Join(c.dir, fmt.Sprintf("%s.har", capturedFilename(bookPath)))
+	if err := os.WriteFile(p, b, os.ModePerm); err != nil {
+		c.errs = errors.Join(c.errs, err)
+		return
+	}
+}
+
+func harHeaders(h http.Header) []*harNameValue {
+	var keys []string
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	nvs := []*harNameValue{}
+	for _, k := range keys {
+		for _, v := range h[k] {
+			nvs = append(nvs, &harNameValue{Name: k, Value: v})
+		}
+	}
+	return nvs
+}
+
+func harQueryString(q url.Values) []*harNameValue {
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	nvs := []*harNameValue{}
+	for _, k := range keys {
+		for _, v := range q[k] {
+			nvs = append(nvs, &harNameValue{Name: k, Value: v})
+		}
+	}
+	return nvs
+}
@@ -0,0 +1,73 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn"
+	"github.com/k1LoW/runn/testutil"
+	"github.com/tenntenn/golden"
+)
+
+func TestHAR(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{filepath.Join(testutil.Testdata(), "book", "http.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "http_multipart.yml")},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.book), func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			dir := t.TempDir()
+			hs := testutil.HTTPServer(t)
+			opts := []runn.Option{
+				runn.Book(tt.book),
+				runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
+				runn.Capture(HAR(dir)),
+				runn.Scopes(runn.ScopeAllowReadParent),
+			}
+			o, err := runn.New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%s.har", capturedFilename(tt.book))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := &har{}
+			if err := json.Unmarshal(b, h); err != nil {
+				t.Fatal(err)
+			}
+			if h.Log.Version != "1.2" {
+				t.Errorf("got %v\nwant %v", h.Log.Version, "1.2")
+			}
+			// Summarize entries excluding times
+			got := new(bytes.Buffer)
+			for _, e := range h.Log.Entries {
+				u := strings.Replace(e.Request.URL, hs.URL, "http://runn.test", 1)
+				_, _ = fmt.Fprintf(got, "%s %s %d %s (request body: %d bytes, response body: %d bytes)\n", e.Request.Method, u, e.Response.Status, e.Response.StatusText, e.Request.BodySize, e.Response.BodySize)
+			}
+			f := fmt.Sprintf("%s.har", filepath.Base(tt.book))
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, testutil.Testdata(), f, got)
+				return
+			}
+			if diff := golden.Diff(t, testutil.Testdata(), f, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -345,6 +345,34 @@ func (c *cRunbook) CaptureCDPEnd(name string) {
 	// FIXME: not implemented
 }
 
+func (c *cRunbook) CaptureWebSocketStart(name, url string) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureWebSocketSendMessage(m any) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureWebSocketReceiveMessage(m any) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureWebSocketClose() {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureWebSocketEnd(name, url string) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureQueueProduce(name string, m *runn.QueueMessage) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureQueueConsume(name string, m *runn.QueueMessage) {
+	// FIXME: not implemented
+}
+
 func (c *cRunbook) CaptureSSHCommand(command string) {
 	// FIXME: not implemented
 }
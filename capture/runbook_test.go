@@ -3,8 +3,12 @@ package capture
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"testing"
 
@@ -121,3 +125,43 @@ func TestRunnable(t *testing.T) {
 		})
 	}
 }
+
+func TestRunbookWithParallel(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dir := t.TempDir()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	t.Cleanup(ts.Close)
+	book := filepath.Join(testutil.Testdata(), "book", "parallel.yml")
+	// The branches run concurrently with the debugger and the runbook capturer ( run with -race )
+	opts := []runn.Option{
+		runn.Book(book),
+		runn.HTTPRunner("req", ts.URL, ts.Client()),
+		runn.Debug(true),
+		runn.Stdout(io.Discard),
+		runn.Stderr(io.Discard),
+		runn.Capture(Runbook(dir)),
+		runn.Scopes(runn.ScopeAllowReadParent),
+	}
+	o, err := runn.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, capturedFilename(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	// The captured steps of the branches are in the order of the branches
+	projects := strings.Index(got, "/projects")
+	users := strings.Index(got, "/users?name=alice")
+	if projects < 0 || users < 0 || projects > users {
+		t.Errorf("invalid captured runbook:\n%s", got)
+	}
+}
@@ -32,6 +32,15 @@ type Capturer interface {
 	CaptureCDPResponse(a CDPAction, res map[string]any)
 	CaptureCDPEnd(name string)
 
+	CaptureWebSocketStart(name, url string)
+	CaptureWebSocketSendMessage(m any)
+	CaptureWebSocketReceiveMessage(m any)
+	CaptureWebSocketClose()
+	CaptureWebSocketEnd(name, url string)
+
+	CaptureQueueProduce(name string, m *QueueMessage)
+	CaptureQueueConsume(name string, m *QueueMessage)
+
 	CaptureSSHCommand(command string)
 	CaptureSSHStdout(stdout string)
 	CaptureSSHStderr(stderr string)
@@ -48,8 +57,47 @@ type Capturer interface {
 	Errs() error
 }
 
+// operatorCapturer - Capturer that holds the in-flight state per operator so that operators running concurrently do not mix it.
+type operatorCapturer interface {
+	Capturer
+	// forOperator returns the capturer for the operator that shares the captured results.
+	forOperator(o *operator) Capturer
+}
+
+// attemptCapturer - Capturer that discards the exchange of the attempt replaced by the retry.
+type attemptCapturer interface {
+	Capturer
+	// discardAttempt discards the latest exchange of the runner captured before the retry.
+	discardAttempt(name string)
+}
+
 type capturers []Capturer
 
+// forOperator returns the capturers for the operator.
+func (cs capturers) forOperator(o *operator) capturers { //nostyle:recvtype
+	if cs == nil {
+		return nil
+	}
+	ocs := make(capturers, 0, len(cs))
+	for _, c := range cs {
+		if oc, ok := c.(operatorCapturer); ok {
+			ocs = append(ocs, oc.forOperator(o))
+			continue
+		}
+		ocs = append(ocs, c)
+	}
+	return ocs
+}
+
+// discardAttempt tells the attemptCapturers that the latest exchange of the runner is replaced by the retry.
+func (cs capturers) discardAttempt(name string) { //nostyle:recvtype
+	for _, c := range cs {
+		if ac, ok := c.(attemptCapturer); ok {
+			ac.discardAttempt(name)
+		}
+	}
+}
+
 func (cs capturers) captureStart(trs Trails, bookPath, desc string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureStart(trs, bookPath, desc)
@@ -163,6 +211,48 @@ func (cs capturers) captureCDPEnd(name string) { //nostyle:recvtype
 	}
 }
 
+func (cs capturers) captureWebSocketStart(name, url string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketStart(name, url)
+	}
+}
+
+func (cs capturers) captureWebSocketSendMessage(m any) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketSendMessage(m)
+	}
+}
+
+func (cs capturers) captureWebSocketReceiveMessage(m any) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketReceiveMessage(m)
+	}
+}
+
+func (cs capturers) captureWebSocketClose() { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketClose()
+	}
+}
+
+func (cs capturers) captureWebSocketEnd(name, url string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketEnd(name, url)
+	}
+}
+
+func (cs capturers) captureQueueProduce(name string, m *QueueMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureQueueProduce(name, m)
+	}
+}
+
+func (cs capturers) captureQueueConsume(name string, m *QueueMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureQueueConsume(name, m)
+	}
+}
+
 func (cs capturers) captureSSHCommand(command string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureSSHCommand(command)
@@ -0,0 +1,235 @@
+package runn
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/status"
+)
+
+var _ Capturer = (*captureBuffer)(nil)
+
+// captureBuffer - Capturer that buffers the captured events to replay them to the capturers later.
+// Capturers are not safe for concurrent use, so parallel branches capture into their own buffers.
+type captureBuffer struct {
+	events []func(c Capturer)
+}
+
+// forBranch returns the capturers for the branch running concurrently with other branches.
+// The operatorCapturers capture directly, and the others capture through the returned buffer.
+func (cs capturers) forBranch(o *operator) (capturers, *captureBuffer) { //nostyle:recvtype
+	buf := &captureBuffer{}
+	if cs == nil {
+		return nil, buf
+	}
+	ocs := make(capturers, 0, len(cs))
+	shared := false
+	for _, c := range cs {
+		if oc, ok := c.(operatorCapturer); ok {
+			ocs = append(ocs, oc.forOperator(o))
+			continue
+		}
+		shared = true
+	}
+	if shared {
+		ocs = append(ocs, buf)
+	}
+	return ocs, buf
+}
+
+// replay replays the buffered events to the capturers except for the operatorCapturers.
+func (b *captureBuffer) replay(cs capturers) {
+	var targets capturers
+	for _, c := range cs {
+		if _, ok := c.(operatorCapturer); ok {
+			continue
+		}
+		targets = append(targets, c)
+	}
+	for _, e := range b.events {
+		for _, c := range targets {
+			e(c)
+		}
+	}
+	b.events = nil
+}
+
+func (b *captureBuffer) add(e func(c Capturer)) {
+	b.events = append(b.events, e)
+}
+
+func (b *captureBuffer) CaptureStart(trs Trails, bookPath, desc string) {
+	b.add(func(c Capturer) { c.CaptureStart(trs, bookPath, desc) })
+}
+
+func (b *captureBuffer) CaptureResult(trs Trails, result *RunResult) {
+	b.add(func(c Capturer) { c.CaptureResult(trs, result) })
+}
+
+func (b *captureBuffer) CaptureEnd(trs Trails, bookPath, desc string) {
+	b.add(func(c Capturer) { c.CaptureEnd(trs, bookPath, desc) })
+}
+
+func (b *captureBuffer) CaptureResultByStep(trs Trails, result *RunResult) {
+	b.add(func(c Capturer) { c.CaptureResultByStep(trs, result) })
+}
+
+// CaptureHTTPRequest buffers the copy of the request because the body is consumed before replaying.
+func (b *captureBuffer) CaptureHTTPRequest(name string, req *http.Request) {
+	body, ok := readAndRestoreBody(&req.Body)
+	r := req.Clone(req.Context())
+	b.add(func(c Capturer) {
+		if ok {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		c.CaptureHTTPRequest(name, r)
+	})
+}
+
+// CaptureHTTPResponse buffers the copy of the response because the body is consumed before replaying.
+func (b *captureBuffer) CaptureHTTPResponse(name string, res *http.Response) {
+	body, ok := readAndRestoreBody(&res.Body)
+	r := *res
+	b.add(func(c Capturer) {
+		if ok {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		c.CaptureHTTPResponse(name, &r)
+	})
+}
+
+func (b *captureBuffer) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	b.add(func(c Capturer) { c.CaptureGRPCStart(name, typ, service, method) })
+}
+
+func (b *captureBuffer) CaptureGRPCRequestHeaders(h map[string][]string) {
+	b.add(func(c Capturer) { c.CaptureGRPCRequestHeaders(h) })
+}
+
+func (b *captureBuffer) CaptureGRPCRequestMessage(m map[string]any) {
+	b.add(func(c Capturer) { c.CaptureGRPCRequestMessage(m) })
+}
+
+func (b *captureBuffer) CaptureGRPCResponseStatus(s *status.Status) {
+	b.add(func(c Capturer) { c.CaptureGRPCResponseStatus(s) })
+}
+
+func (b *captureBuffer) CaptureGRPCResponseHeaders(h map[string][]string) {
+	b.add(func(c Capturer) { c.CaptureGRPCResponseHeaders(h) })
+}
+
+func (b *captureBuffer) CaptureGRPCResponseMessage(m map[string]any) {
+	b.add(func(c Capturer) { c.CaptureGRPCResponseMessage(m) })
+}
+
+func (b *captureBuffer) CaptureGRPCResponseTrailers(t map[string][]string) {
+	b.add(func(c Capturer) { c.CaptureGRPCResponseTrailers(t) })
+}
+
+func (b *captureBuffer) CaptureGRPCClientClose() {
+	b.add(func(c Capturer) { c.CaptureGRPCClientClose() })
+}
+
+func (b *captureBuffer) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	b.add(func(c Capturer) { c.CaptureGRPCEnd(name, typ, service, method) })
+}
+
+func (b *captureBuffer) CaptureCDPStart(name string) {
+	b.add(func(c Capturer) { c.CaptureCDPStart(name) })
+}
+
+func (b *captureBuffer) CaptureCDPAction(a CDPAction) {
+	b.add(func(c Capturer) { c.CaptureCDPAction(a) })
+}
+
+func (b *captureBuffer) CaptureCDPResponse(a CDPAction, res map[string]any) {
+	b.add(func(c Capturer) { c.CaptureCDPResponse(a, res) })
+}
+
+func (b *captureBuffer) CaptureCDPEnd(name string) {
+	b.add(func(c Capturer) { c.CaptureCDPEnd(name) })
+}
+
+func (b *captureBuffer) CaptureWebSocketStart(name, url string) {
+	b.add(func(c Capturer) { c.CaptureWebSocketStart(name, url) })
+}
+
+func (b *captureBuffer) CaptureWebSocketSendMessage(m any) {
+	b.add(func(c Capturer) { c.CaptureWebSocketSendMessage(m) })
+}
+
+func (b *captureBuffer) CaptureWebSocketReceiveMessage(m any) {
+	b.add(func(c Capturer) { c.CaptureWebSocketReceiveMessage(m) })
+}
+
+func (b *captureBuffer) CaptureWebSocketClose() {
+	b.add(func(c Capturer) { c.CaptureWebSocketClose() })
+}
+
+func (b *captureBuffer) CaptureWebSocketEnd(name, url string) {
+	b.add(func(c Capturer) { c.CaptureWebSocketEnd(name, url) })
+}
+
+func (b *captureBuffer) CaptureQueueProduce(name string, m *QueueMessage) {
+	b.add(func(c Capturer) { c.CaptureQueueProduce(name, m) })
+}
+
+func (b *captureBuffer) CaptureQueueConsume(name string, m *QueueMessage) {
+	b.add(func(c Capturer) { c.CaptureQueueConsume(name, m) })
+}
+
+func (b *captureBuffer) CaptureSSHCommand(command string) {
+	b.add(func(c Capturer) { c.CaptureSSHCommand(command) })
+}
+
+func (b *captureBuffer) CaptureSSHStdout(stdout string) {
+	b.add(func(c Capturer) { c.CaptureSSHStdout(stdout) })
+}
+
+func (b *captureBuffer) CaptureSSHStderr(stderr string) {
+	b.add(func(c Capturer) { c.CaptureSSHStderr(stderr) })
+}
+
+func (b *captureBuffer) CaptureDBStatement(name string, stmt string) {
+	b.add(func(c Capturer) { c.CaptureDBStatement(name, stmt) })
+}
+
+func (b *captureBuffer) CaptureDBResponse(name string, res *DBResponse) {
+	b.add(func(c Capturer) { c.CaptureDBResponse(name, res) })
+}
+
+func (b *captureBuffer) CaptureExecCommand(command, shell string, background bool) {
+	b.add(func(c Capturer) { c.CaptureExecCommand(command, shell, background) })
+}
+
+func (b *captureBuffer) CaptureExecStdin(stdin string) {
+	b.add(func(c Capturer) { c.CaptureExecStdin(stdin) })
+}
+
+func (b *captureBuffer) CaptureExecStdout(stdout string) {
+	b.add(func(c Capturer) { c.CaptureExecStdout(stdout) })
+}
+
+func (b *captureBuffer) CaptureExecStderr(stderr string) {
+	b.add(func(c Capturer) { c.CaptureExecStderr(stderr) })
+}
+
+func (b *captureBuffer) SetCurrentTrails(trs Trails) {
+	b.add(func(c Capturer) { c.SetCurrentTrails(trs) })
+}
+
+func (b *captureBuffer) Errs() error {
+	return nil
+}
+
+// readAndRestoreBody reads the body and restores it so that it can be read again.
+func readAndRestoreBody(body *io.ReadCloser) ([]byte, bool) {
+	if *body == nil || *body == http.NoBody {
+		return nil, false
+	}
+	b, _ := io.ReadAll(*body)
+	_ = (*body).Close()
+	*body = io.NopCloser(bytes.NewReader(b))
+	return b, true
+}
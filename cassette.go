@@ -0,0 +1,577 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	cassetteExt               = ".cassette.json"
+	cassetteMultipartBoundary = "runn-cassette-boundary"
+)
+
+var errCassetteMismatch = errors.New("no recorded interaction matches the request")
+
+// cassette - Recorded HTTP and gRPC exchanges of a runbook.
+type cassette struct {
+	Path         string                 `json:"path"`
+	Interactions []*cassetteInteraction `json:"interactions"`
+}
+
+type cassetteInteraction struct {
+	Runner string        `json:"runner"`
+	HTTP   *cassetteHTTP `json:"http,omitempty"`
+	GRPC   *cassetteGRPC `json:"grpc,omitempty"`
+
+	seq int
+}
+
+type cassetteHTTP struct {
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	BodyHash   string      `json:"body_hash"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+type cassetteGRPC struct {
+	Type          GRPCType         `json:"type"`
+	Method        string           `json:"method"`
+	MessagesHash  string           `json:"messages_hash"`
+	Status        *int             `json:"status,omitempty"`
+	StatusMessage string           `json:"status_message,omitempty"`
+	Headers       metadata.MD      `json:"headers,omitempty"`
+	Trailers      metadata.MD      `json:"trailers,omitempty"`
+	Messages      []map[string]any `json:"messages,omitempty"`
+
+	requestMessages []map[string]any
+}
+
+var (
+	_ operatorCapturer = (*cassetteRecorder)(nil)
+	_ attemptCapturer  = (*cassetteRecorder)(nil)
+)
+
+// cassetteRecorder - Capturer that records HTTP and gRPC exchanges into a cassette file per runbook.
+// Each operator has its own recorder for the in-flight exchanges, and the recorders share the cassettes.
+type cassetteRecorder struct {
+	*cassetteShelf
+	currentTrails Trails
+	pendingHTTP   map[*http.Request]*cassetteInteraction
+	lastHTTP      map[string]*cassetteInteraction
+	seq           int
+	currentGRPC   *cassetteInteraction
+}
+
+// cassetteShelf - Cassettes shared by the recorders of operators.
+type cassetteShelf struct {
+	dir       string
+	cassettes map[string]*cassette
+	errs      error
+	mu        sync.Mutex
+}
+
+// cassetteReplayer - Answer HTTP and gRPC requests from the cassette instead of the network.
+type cassetteReplayer struct {
+	path     string
+	cassette *cassette
+	used     []bool
+	loaded   bool
+	err      error
+	mu       sync.Mutex
+}
+
+func newCassetteRecorder(dir string) *cassetteRecorder {
+	return &cassetteRecorder{
+		cassetteShelf: &cassetteShelf{
+			dir:       dir,
+			cassettes: map[string]*cassette{},
+		},
+		pendingHTTP: map[*http.Request]*cassetteInteraction{},
+		lastHTTP:    map[string]*cassetteInteraction{},
+	}
+}
+
+func (c *cassetteRecorder) forOperator(_ *operator) Capturer {
+	return &cassetteRecorder{
+		cassetteShelf: c.cassetteShelf,
+		pendingHTTP:   map[*http.Request]*cassetteInteraction{},
+		lastHTTP:      map[string]*cassetteInteraction{},
+	}
+}
+
+func (c *cassetteRecorder) CaptureStart(trs Trails, bookPath, desc string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cassettes[trs[0].RunbookID] = &cassette{
+		Path:         bookPath,
+		Interactions: []*cassetteInteraction{},
+	}
+}
+
+func (c *cassetteRecorder) CaptureResult(trs Trails, result *RunResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cs, ok := c.cassettes[trs[0].RunbookID]
+	if !ok {
+		return
+	}
+	delete(c.cassettes, trs[0].RunbookID)
+	if result.Skipped {
+		return
+	}
+	if err := cs.write(filepath.Join(c.dir, cassetteFilename(cs.Path))); err != nil {
+		c.errs = errors.Join(c.errs, err)
+	}
+}
+
+func (c *cassetteRecorder) CaptureEnd(trs Trails, bookPath, desc string) {}
+
+func (c *cassetteRecorder) CaptureResultByStep(trs Trails, result *RunResult) {}
+
+func (c *cassetteRecorder) CaptureHTTPRequest(name string, req *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Only the response to this request can be discarded by the retry
+	delete(c.lastHTTP, name)
+	h, err := hashHTTPRequestBody(req)
+	if err != nil {
+		c.errs = errors.Join(c.errs, err)
+		return
+	}
+	c.seq++
+	c.pendingHTTP[req] = &cassetteInteraction{
+		Runner: name,
+		HTTP: &cassetteHTTP{
+			Method:   req.Method,
+			Path:     req.URL.RequestURI(),
+			BodyHash: h,
+		},
+		seq: c.seq,
+	}
+}
+
+func (c *cassetteRecorder) CaptureHTTPResponse(name string, res *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		i  *cassetteInteraction
+		ok bool
+	)
+	// Follow redirects back to the request that the runner sent.
+	for req := res.Request; req != nil; {
+		i, ok = c.pendingHTTP[req]
+		if ok {
+			delete(c.pendingHTTP, req)
+			break
+		}
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	if !ok {
+		// The request may be cloned by the transport, so fall back to the latest request of the runner.
+		var latest *http.Request
+		for req, pi := range c.pendingHTTP {
+			if pi.Runner == name && (latest == nil || pi.seq > c.pendingHTTP[latest].seq) {
+				latest = req
+			}
+		}
+		if latest == nil {
+			c.errs = errors.Join(c.errs, fmt.Errorf("failed to find the request of the response: %s", name))
+			return
+		}
+		i = c.pendingHTTP[latest]
+		delete(c.pendingHTTP, latest)
+	}
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, res.Body, err = drainBody(res.Body)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to drainBody: %w", err))
+		return
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to io.ReadAll: %w", err))
+		return
+	}
+	i.HTTP.Status = res.StatusCode
+	i.HTTP.Header = res.Header.Clone()
+	if utf8.Valid(b) {
+		i.HTTP.Body = string(b)
+	} else {
+		i.HTTP.BodyBase64 = base64.StdEncoding.EncodeToString(b)
+	}
+	c.appendInteraction(i)
+	c.lastHTTP[name] = i
+}
+
+func (c *cassetteRecorder) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currentGRPC = &cassetteInteraction{
+		Runner: name,
+		GRPC: &cassetteGRPC{
+			Type:   typ,
+			Method: grpcFullMethod(service, method),
+		},
+	}
+}
+
+func (c *cassetteRecorder) CaptureGRPCRequestHeaders(h map[string][]string) {}
+
+func (c *cassetteRecorder) CaptureGRPCRequestMessage(m map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC == nil {
+		return
+	}
+	c.currentGRPC.GRPC.requestMessages = append(c.currentGRPC.GRPC.requestMessages, m)
+}
+
+func (c *cassetteRecorder) CaptureGRPCResponseStatus(s *status.Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC == nil {
+		return
+	}
+	code := int(s.Code())
+	c.currentGRPC.GRPC.Status = &code
+	c.currentGRPC.GRPC.StatusMessage = s.Message()
+}
+
+func (c *cassetteRecorder) CaptureGRPCResponseHeaders(h map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC == nil {
+		return
+	}
+	c.currentGRPC.GRPC.Headers = metadata.MD(h).Copy()
+}
+
+func (c *cassetteRecorder) CaptureGRPCResponseMessage(m map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC == nil {
+		return
+	}
+	c.currentGRPC.GRPC.Messages = append(c.currentGRPC.GRPC.Messages, m)
+}
+
+func (c *cassetteRecorder) CaptureGRPCResponseTrailers(t map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC == nil {
+		return
+	}
+	c.currentGRPC.GRPC.Trailers = metadata.MD(t).Copy()
+}
+
+func (c *cassetteRecorder) CaptureGRPCClientClose() {}
+
+func (c *cassetteRecorder) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.currentGRPC
+	c.currentGRPC = nil
+	if i == nil {
+		return
+	}
+	h, err := hashGRPCMessages(i.GRPC.requestMessages)
+	if err != nil {
+		c.errs = errors.Join(c.errs, err)
+		return
+	}
+	i.GRPC.MessagesHash = h
+	c.appendInteraction(i)
+}
+
+func (c *cassetteRecorder) CaptureCDPStart(name string)                        {}
+func (c *cassetteRecorder) CaptureCDPAction(a CDPAction)                       {}
+func (c *cassetteRecorder) CaptureCDPResponse(a CDPAction, res map[string]any) {}
+func (c *cassetteRecorder) CaptureCDPEnd(name string)                          {}
+
+func (c *cassetteRecorder) CaptureWebSocketStart(name, url string)           {}
+func (c *cassetteRecorder) CaptureWebSocketSendMessage(m any)                {}
+func (c *cassetteRecorder) CaptureWebSocketReceiveMessage(m any)             {}
+func (c *cassetteRecorder) CaptureWebSocketClose()                           {}
+func (c *cassetteRecorder) CaptureWebSocketEnd(name, url string)             {}
+func (c *cassetteRecorder) CaptureQueueProduce(name string, m *QueueMessage) {}
+func (c *cassetteRecorder) CaptureQueueConsume(name string, m *QueueMessage) {}
+
+func (c *cassetteRecorder) CaptureSSHCommand(command string) {}
+func (c *cassetteRecorder) CaptureSSHStdout(stdout string)   {}
+func (c *cassetteRecorder) CaptureSSHStderr(stderr string)   {}
+
+func (c *cassetteRecorder) CaptureDBStatement(name string, stmt string)               {}
+func (c *cassetteRecorder) CaptureDBResponse(name string, res *DBResponse)            {}
+func (c *cassetteRecorder) CaptureExecCommand(command, shell string, background bool) {}
+func (c *cassetteRecorder) CaptureExecStdin(stdin string)                             {}
+func (c *cassetteRecorder) CaptureExecStdout(stdout string)                           {}
+func (c *cassetteRecorder) CaptureExecStderr(stderr string)                           {}
+
+func (c *cassetteRecorder) SetCurrentTrails(trs Trails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currentTrails = trs
+}
+
+func (c *cassetteRecorder) Errs() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errs
+}
+
+// discardAttempt discards the exchange of the attempt replaced by the retry.
+// The replayer does not retry, so only the final exchange must be in the cassette.
+func (c *cassetteRecorder) discardAttempt(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentGRPC != nil && c.currentGRPC.Runner == name {
+		// The retry captures the request and the response again
+		c.currentGRPC.GRPC = &cassetteGRPC{
+			Type:   c.currentGRPC.GRPC.Type,
+			Method: c.currentGRPC.GRPC.Method,
+		}
+		return
+	}
+	i, ok := c.lastHTTP[name]
+	if !ok {
+		return
+	}
+	delete(c.lastHTTP, name)
+	if len(c.currentTrails) == 0 {
+		return
+	}
+	cs, ok := c.cassettes[c.currentTrails[0].RunbookID]
+	if !ok {
+		return
+	}
+	for j, ci := range cs.Interactions {
+		if ci == i {
+			cs.Interactions = append(cs.Interactions[:j], cs.Interactions[j+1:]...)
+			return
+		}
+	}
+}
+
+func (c *cassetteRecorder) appendInteraction(i *cassetteInteraction) {
+	if len(c.currentTrails) == 0 {
+		return
+	}
+	cs, ok := c.cassettes[c.currentTrails[0].RunbookID]
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to find the cassette of the runbook: %s", c.currentTrails[0].RunbookID))
+		return
+	}
+	cs.Interactions = append(cs.Interactions, i)
+}
+
+func (cs *cassette) write(p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create the cassette directory: %w", err)
+	}
+	b, err := json.MarshalIndent(cs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the cassette: %w", err)
+	}
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write the cassette: %w", err)
+	}
+	return nil
+}
+
+func newCassetteReplayer(dir, bookPath string) *cassetteReplayer {
+	return &cassetteReplayer{
+		path: filepath.Join(dir, cassetteFilename(bookPath)),
+	}
+}
+
+// load reads the cassette file on first use, so that a missing cassette fails the step, not the loading of runbooks.
+func (r *cassetteReplayer) load() error {
+	if r.loaded {
+		return r.err
+	}
+	r.loaded = true
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		r.err = fmt.Errorf("failed to read the cassette: %w", err)
+		return r.err
+	}
+	cs := &cassette{}
+	if err := json.Unmarshal(b, cs); err != nil {
+		r.err = fmt.Errorf("failed to parse the cassette %s: %w", r.path, err)
+		return r.err
+	}
+	r.cassette = cs
+	r.used = make([]bool, len(cs.Interactions))
+	return nil
+}
+
+// replayHTTP returns the recorded response of the request.
+// Each recorded interaction is replayed only once, in the recorded order.
+func (r *cassetteReplayer) replayHTTP(name string, req *http.Request) (*http.Response, error) {
+	h, err := hashHTTPRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	p := req.URL.RequestURI()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	for idx, i := range r.cassette.Interactions {
+		if r.used[idx] || i.Runner != name || i.HTTP == nil {
+			continue
+		}
+		if i.HTTP.Method != req.Method || i.HTTP.Path != p || i.HTTP.BodyHash != h {
+			continue
+		}
+		r.used[idx] = true
+		b := []byte(i.HTTP.Body)
+		if i.HTTP.BodyBase64 != "" {
+			b, err = base64.StdEncoding.DecodeString(i.HTTP.BodyBase64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the recorded body: %w", err)
+			}
+		}
+		header := i.HTTP.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.HTTP.Status, http.StatusText(i.HTTP.Status)),
+			StatusCode:    i.HTTP.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(b)),
+			ContentLength: int64(len(b)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w in the cassette %s: runner=%s, method=%s, path=%s, body_hash=%s", errCassetteMismatch, r.path, name, req.Method, p, h)
+}
+
+// replayGRPC returns the recorded exchange of the gRPC method called with the messages.
+func (r *cassetteReplayer) replayGRPC(name, service, method string, messages []map[string]any) (*cassetteGRPC, error) {
+	h, err := hashGRPCMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	m := grpcFullMethod(service, method)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	for idx, i := range r.cassette.Interactions {
+		if r.used[idx] || i.Runner != name || i.GRPC == nil {
+			continue
+		}
+		if i.GRPC.Method != m || i.GRPC.MessagesHash != h {
+			continue
+		}
+		r.used[idx] = true
+		return i.GRPC, nil
+	}
+	return nil, fmt.Errorf("%w in the cassette %s: runner=%s, method=%s, messages_hash=%s", errCassetteMismatch, r.path, name, m, h)
+}
+
+// toStoreValue converts the recorded exchange to the value recorded by the gRPC runner.
+func (g *cassetteGRPC) toStoreValue() map[string]any {
+	headers := g.Headers
+	if headers == nil {
+		headers = metadata.MD{}
+	}
+	trailers := g.Trailers
+	if trailers == nil {
+		trailers = metadata.MD{}
+	}
+	d := map[string]any{
+		string(grpcStoreHeaderKey):  headers,
+		string(grpcStoreTrailerKey): trailers,
+		string(grpcStoreMessageKey): nil,
+	}
+	ok := g.Status == nil || *g.Status == int(codes.OK)
+	if g.Status != nil {
+		if g.Type == GRPCUnary {
+			d[grpcStoreStatusKey] = *g.Status
+		} else {
+			d[grpcStoreStatusKey] = int64(*g.Status)
+		}
+	}
+	switch {
+	case !ok:
+		d[grpcStoreMessageKey] = g.StatusMessage
+	case len(g.Messages) > 0:
+		d[grpcStoreMessageKey] = g.Messages[len(g.Messages)-1]
+	}
+	if g.Type != GRPCUnary || ok {
+		d[grpcStoreMessagesKey] = g.Messages
+	}
+	return d
+}
+
+func cassetteFilename(bookPath string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(filepath.ToSlash(bookPath), "/", "-"), "..", "") + cassetteExt
+}
+
+func grpcFullMethod(service, method string) string {
+	return strings.Join([]string{service, method}, "/")
+}
+
+func hashHTTPRequestBody(req *http.Request) (string, error) {
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, req.Body, err = drainBody(req.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to drainBody: %w", err)
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		return "", fmt.Errorf("failed to io.ReadAll: %w", err)
+	}
+	// The boundary of multipart bodies is random unless it is specified, so replace it with a fixed one before hashing.
+	if mt, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && strings.HasPrefix(mt, "multipart/") && params["boundary"] != "" {
+		b = bytes.ReplaceAll(b, []byte(params["boundary"]), []byte(cassetteMultipartBoundary))
+	}
+	return hashBytes(b), nil
+}
+
+func hashGRPCMessages(messages []map[string]any) (string, error) {
+	if messages == nil {
+		messages = []map[string]any{}
+	}
+	// json.Marshal sorts the keys of maps, so the hash does not depend on the order of fields.
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal gRPC messages: %w", err)
+	}
+	return hashBytes(b), nil
+}
@@ -0,0 +1,344 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	book := "testdata/book/cassette.yml"
+
+	t.Run("Record", func(t *testing.T) {
+		ts := testutil.HTTPServer(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+		o, err := New(Book(book), Record(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, cassetteFilename(book)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := &cassette{}
+		if err := json.Unmarshal(b, cs); err != nil {
+			t.Fatal(err)
+		}
+		if want := 3; len(cs.Interactions) != want {
+			t.Fatalf("got %v\nwant %v", len(cs.Interactions), want)
+		}
+		got := cs.Interactions[2].HTTP
+		if got.Path != "/redirect" {
+			t.Errorf("got %v\nwant %v", got.Path, "/redirect")
+		}
+		if got.Status != 404 {
+			t.Errorf("got %v\nwant %v", got.Status, 404)
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		// Nothing listens on the endpoint, so the responses must come from the cassette.
+		t.Setenv("TEST_HTTP_ENDPOINT", "http://127.0.0.1:1")
+		o, err := New(Book(book), Replay(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Replay with mismatched request", func(t *testing.T) {
+		t.Setenv("TEST_HTTP_ENDPOINT", "http://127.0.0.1:1")
+		o, err := New(Book(book), Replay(dir), Var("username", "bob"), FailFast(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = o.Run(ctx)
+		if !errors.Is(err, errCassetteMismatch) {
+			t.Errorf("got %v\nwant %v", err, errCassetteMismatch)
+		}
+		if o.Result().StepResults[0].Err == nil {
+			t.Error("the step should fail")
+		}
+	})
+
+	t.Run("Replay without cassette", func(t *testing.T) {
+		t.Setenv("TEST_HTTP_ENDPOINT", "http://127.0.0.1:1")
+		o, err := New(Book(book), Replay(t.TempDir()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err == nil {
+			t.Error("want error")
+		}
+	})
+}
+
+func TestCassetteRecordConcurrently(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.HTTPServer(t)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	b, err := os.ReadFile("testdata/book/cassette.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookDir := t.TempDir()
+	var books []string
+	for i := 0; i < 8; i++ {
+		p := filepath.Join(bookDir, fmt.Sprintf("cassette_%d.yml", i))
+		if err := os.WriteFile(p, b, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		books = append(books, p)
+	}
+	dir := t.TempDir()
+	ops, err := Load(filepath.Join(bookDir, "*.yml"), Record(dir), RunConcurrent(true, len(books)), Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ops.RunN(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range books {
+		b, err := os.ReadFile(filepath.Join(dir, cassetteFilename(p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := &cassette{}
+		if err := json.Unmarshal(b, cs); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, i := range cs.Interactions {
+			got = append(got, i.HTTP.Path)
+		}
+		want := []string{"/users", "/users/1", "/redirect"}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("%s: %s", p, diff)
+		}
+	}
+}
+
+func TestCassetteRecordAndReplayRetries(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(ts.Close)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, &retryHealthServer{failures: 2})
+	reflection.Register(srv)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(srv.Stop)
+
+	book := filepath.Join(t.TempDir(), "cassette_retry.yml")
+	if err := os.WriteFile(book, []byte(`desc: Record and replay retried exchanges
+runners:
+  req:
+    endpoint: ${TEST_HTTP_ENDPOINT}
+    retry:
+      interval: 1ms
+  greq:
+    addr: ${TEST_GRPC_ADDR}
+    tls: false
+    retry:
+      interval: 1ms
+steps:
+  http:
+    req:
+      /health:
+        get:
+          body: null
+    test: current.res.status == 200 && current.res.body.status == 'ok'
+  grpc:
+    greq:
+      grpc.health.v1.Health/Check:
+        message: {}
+    test: current.res.status == 0 && current.res.message.status == 1
+`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	t.Run("Record", func(t *testing.T) {
+		t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+		t.Setenv("TEST_GRPC_ADDR", l.Addr().String())
+		o, err := New(Book(book), Record(dir), Scopes(ScopeAllowReadParent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, cassetteFilename(book)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := &cassette{}
+		if err := json.Unmarshal(b, cs); err != nil {
+			t.Fatal(err)
+		}
+		// Only the final exchanges are recorded
+		if want := 2; len(cs.Interactions) != want {
+			t.Fatalf("got %v\nwant %v", len(cs.Interactions), want)
+		}
+		if got := cs.Interactions[0].HTTP.Status; got != http.StatusOK {
+			t.Errorf("got %v\nwant %v", got, http.StatusOK)
+		}
+		if got := *cs.Interactions[1].GRPC.Status; got != int(codes.OK) {
+			t.Errorf("got %v\nwant %v", got, int(codes.OK))
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		// Nothing listens on the endpoints, so the responses must come from the cassette.
+		t.Setenv("TEST_HTTP_ENDPOINT", "http://127.0.0.1:1")
+		t.Setenv("TEST_GRPC_ADDR", "127.0.0.1:1")
+		o, err := New(Book(book), Replay(dir), Scopes(ScopeAllowReadParent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestHashHTTPRequestBodyWithMultipartBoundary(t *testing.T) {
+	var hashes []string
+	for _, boundary := range []string{"boundary-a", "boundary-b"} {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		if err := mw.SetBoundary(boundary); err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.WriteField("username", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "http://example.com/upload", buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		h, err := hashHTTPRequestBody(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, h)
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("the hash depends on the boundary: %v", hashes)
+	}
+}
+
+func TestCassetteReplayGRPC(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	book := "testdata/book/cassette_grpc.yml"
+	ok := 0
+	unaryHash, err := hashGRPCMessages([]map[string]any{{"name": "alice", "num": 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverHash, err := hashGRPCMessages([]map[string]any{{"name": "bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := &cassette{
+		Path: book,
+		Interactions: []*cassetteInteraction{
+			{
+				Runner: "greq",
+				GRPC: &cassetteGRPC{
+					Type:         GRPCUnary,
+					Method:       "grpctest.GrpcTestService/Hello",
+					MessagesHash: unaryHash,
+					Status:       &ok,
+					Headers:      metadata.Pairs("hello", "header"),
+					Messages:     []map[string]any{{"message": "hello", "num": float64(3)}},
+				},
+			},
+			{
+				Runner: "greq",
+				GRPC: &cassetteGRPC{
+					Type:         GRPCServerStreaming,
+					Method:       "grpctest.GrpcTestService/ListHello",
+					MessagesHash: serverHash,
+					Status:       &ok,
+					Messages: []map[string]any{
+						{"message": "hello", "num": float64(1)},
+						{"message": "hello", "num": float64(2)},
+					},
+				},
+			},
+		},
+	}
+	if err := cs.write(filepath.Join(dir, cassetteFilename(book))); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing listens on the address, so the responses must come from the cassette.
+	t.Setenv("TEST_GRPC_ADDR", "127.0.0.1:1")
+	o, err := New(Book(book), Replay(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCassetteFilename(t *testing.T) {
+	tests := []struct {
+		bookPath string
+		want     string
+	}{
+		{"testdata/book/http.yml", "testdata-book-http.yml.cassette.json"},
+		{"../book/http.yml", "-book-http.yml.cassette.json"},
+		{"http.yml", "http.yml.cassette.json"},
+	}
+	for _, tt := range tests {
+		if got := cassetteFilename(tt.bookPath); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
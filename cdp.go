@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -29,6 +30,7 @@ type cdpRunner struct {
 	store         map[string]any
 	opts          []chromedp.ExecAllocatorOption
 	timeoutByStep time.Duration
+	proxy         *url.URL
 	mu            sync.Mutex
 	// operatorID - The id of the operator for which the runner is defined.
 	operatorID string
@@ -86,6 +88,14 @@ func (rnr *cdpRunner) Renew() error {
 	return nil
 }
 
+// handleProxyAuth answers the authentication challenges of the proxy in the tab of ctx.
+func (rnr *cdpRunner) handleProxyAuth(ctx context.Context) error {
+	if rnr.proxy == nil || rnr.proxy.User == nil {
+		return nil
+	}
+	return chromedp.Run(ctx, proxyAuthAction(ctx, rnr.proxy))
+}
+
 func (rnr *cdpRunner) Run(ctx context.Context, s *step) error {
 	o := s.parent
 	cas, err := parseCDPActions(s.cdpActions, o.expandBeforeRecord)
@@ -118,6 +128,9 @@ func (rnr *cdpRunner) run(ctx context.Context, cas CDPActions, s *step) error {
 		}); err != nil {
 			return err
 		}
+		if err := rnr.handleProxyAuth(rnr.ctx); err != nil {
+			return err
+		}
 	}
 	o.capturers.captureCDPStart(rnr.name)
 	defer o.capturers.captureCDPEnd(rnr.name)
@@ -154,6 +167,9 @@ func (rnr *cdpRunner) run(ctx context.Context, cas CDPActions, s *step) error {
 			}
 			latestCtx, _ := chromedp.NewContext(rnr.ctx, chromedp.WithTargetID(infos[0].TargetID))
 			rnr.ctx = latestCtx
+			if err := rnr.handleProxyAuth(rnr.ctx); err != nil {
+				return err
+			}
 			continue
 		}
 		as, err := rnr.evalAction(ca, s)
@@ -64,7 +64,12 @@ var coverageCmd = &cobra.Command{
 			return err
 		}
 		pathp := strings.Join(args, string(filepath.ListSeparator))
-		opts = append(opts, runn.LoadOnly())
+		if flgs.CoverageResponses {
+			// Response statuses are collected by running runbooks
+			opts = append(opts, runn.ResponseCoverage(true))
+		} else {
+			opts = append(opts, runn.LoadOnly())
+		}
 
 		// setup cache dir
 		if err := runn.SetCacheDir(flgs.CacheDir); err != nil {
@@ -81,6 +86,14 @@ var coverageCmd = &cobra.Command{
 			return err
 		}
 
+		// runErr - Failures of runbooks do not stop collecting coverage, and are reported after the coverage is shown
+		var runErr error
+		if flgs.CoverageResponses {
+			if err := o.RunN(ctx); err != nil {
+				runErr = fmt.Errorf("failed to run runbooks: %w", err)
+			}
+		}
+
 		cov, err := o.CollectCoverage(ctx)
 		if err != nil {
 			return err
@@ -92,7 +105,7 @@ var coverageCmd = &cobra.Command{
 				return err
 			}
 			_, _ = fmt.Println(string(b))
-			return nil
+			return errors.Join(runErr, checkCoverageThreshold(cov))
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
@@ -100,12 +113,20 @@ var coverageCmd = &cobra.Command{
 		if flgs.Long {
 			ct = "Coverage/Count"
 		}
-		table.SetHeader([]string{"Spec", ct})
+		header := []string{"Spec", ct}
+		alignment := []int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT}
+		if flgs.CoverageResponses {
+			header = append(header, "Responses")
+			alignment = append(alignment, tablewriter.ALIGN_RIGHT)
+		}
+		table.SetHeader(header)
 		table.SetAutoWrapText(false)
 		table.SetAutoFormatHeaders(false)
 		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-		table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold})
-		table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT})
+		table.SetHeaderColor(lo.Map(header, func(_ string, _ int) tablewriter.Colors {
+			return tablewriter.Colors{tablewriter.Bold}
+		})...)
+		table.SetColumnAlignment(alignment)
 		table.SetCenterSeparator("")
 		table.SetColumnSeparator("")
 		table.SetRowSeparator("-")
@@ -126,8 +147,13 @@ var coverageCmd = &cobra.Command{
 			}
 			total += t
 			covered += c
-			coverages = append(coverages, []string{fmt.Sprintf("  %s", spec.Key), fmt.Sprintf("%.1f%%", float64(c)/float64(t)*100)})
-			colors = append(colors, []tablewriter.Colors{{}, {}})
+			row := []string{fmt.Sprintf("  %s", spec.Key), fmt.Sprintf("%.1f%%", float64(c)/float64(t)*100)}
+			if flgs.CoverageResponses {
+				rt, rc := responseCoverage(spec)
+				row = append(row, percentage(rc, rt))
+			}
+			coverages = append(coverages, row)
+			colors = append(colors, make([]tablewriter.Colors, len(row)))
 			if flgs.Long {
 				keys := lo.Keys(spec.Coverages)
 				sort.SliceStable(keys, func(i, j int) bool {
@@ -146,13 +172,23 @@ var coverageCmd = &cobra.Command{
 				})
 				for _, k := range keys {
 					v := spec.Coverages[k]
+					var (
+						row   []string
+						color []tablewriter.Colors
+					)
 					if v == 0 {
-						coverages = append(coverages, []string{fmt.Sprintf("    %s", k), ""})
-						colors = append(colors, []tablewriter.Colors{{tablewriter.FgRedColor}, {}})
-						continue
+						row = []string{fmt.Sprintf("    %s", k), ""}
+						color = []tablewriter.Colors{{tablewriter.FgRedColor}, {}}
+					} else {
+						row = []string{fmt.Sprintf("    %s", k), fmt.Sprintf("%d", v)}
+						color = []tablewriter.Colors{{tablewriter.FgGreenColor}, {tablewriter.FgHiGreenColor}}
+					}
+					if flgs.CoverageResponses {
+						row = append(row, responseStatuses(spec.Responses[k]))
+						color = append(color, tablewriter.Colors{})
 					}
-					coverages = append(coverages, []string{fmt.Sprintf("    %s", k), fmt.Sprintf("%d", v)})
-					colors = append(colors, []tablewriter.Colors{{tablewriter.FgGreenColor}, {tablewriter.FgHiGreenColor}})
+					coverages = append(coverages, row)
+					colors = append(colors, color)
 				}
 			}
 		}
@@ -162,18 +198,103 @@ var coverageCmd = &cobra.Command{
 		if len(coverages) == 0 {
 			return errors.New("could not find any specs")
 		}
-		table.Rich([]string{"Total", fmt.Sprintf("%.1f%%", float64(covered)/float64(total)*100)}, []tablewriter.Colors{{}, {}})
+		totalRow := []string{"Total", fmt.Sprintf("%.1f%%", float64(covered)/float64(total)*100)}
+		if flgs.CoverageResponses {
+			rt, rc := totalResponseCoverage(cov)
+			totalRow = append(totalRow, percentage(rc, rt))
+		}
+		table.Rich(totalRow, make([]tablewriter.Colors, len(totalRow)))
 		for i, v := range coverages {
 			table.Rich(v, colors[i])
 		}
 		table.Render()
-		return nil
+		return errors.Join(runErr, checkCoverageThreshold(cov))
 	},
 }
 
+// checkCoverageThreshold returns an error if the total coverage ( and the total response coverage ) is below the threshold.
+func checkCoverageThreshold(cov *runn.Coverage) error {
+	if flgs.CoverageThreshold <= 0 {
+		return nil
+	}
+	var total, covered int
+	for _, spec := range cov.Specs {
+		for _, v := range spec.Coverages {
+			total++
+			if v > 0 {
+				covered++
+			}
+		}
+	}
+	if total == 0 {
+		return errors.New("could not find any specs")
+	}
+	if c := float64(covered) / float64(total) * 100; c < flgs.CoverageThreshold {
+		return fmt.Errorf("coverage %.1f%% is below the threshold %.1f%%", c, flgs.CoverageThreshold)
+	}
+	if !flgs.CoverageResponses {
+		return nil
+	}
+	rt, rc := totalResponseCoverage(cov)
+	if rt == 0 {
+		return nil
+	}
+	if c := float64(rc) / float64(rt) * 100; c < flgs.CoverageThreshold {
+		return fmt.Errorf("response coverage %.1f%% is below the threshold %.1f%%", c, flgs.CoverageThreshold)
+	}
+	return nil
+}
+
+// responseCoverage returns the number of the documented response statuses and the observed ones of the spec.
+// The status codes of gRPC are not documented, so they are not counted.
+func responseCoverage(spec *runn.SpecCoverage) (int, int) {
+	var t, c int
+	for k, statuses := range spec.Responses {
+		if !strings.Contains(k, " ") {
+			// Method of protocol buffers
+			continue
+		}
+		for _, v := range statuses {
+			t++
+			if v > 0 {
+				c++
+			}
+		}
+	}
+	return t, c
+}
+
+func totalResponseCoverage(cov *runn.Coverage) (int, int) {
+	var total, covered int
+	for _, spec := range cov.Specs {
+		t, c := responseCoverage(spec)
+		total += t
+		covered += c
+	}
+	return total, covered
+}
+
+func percentage(c, t int) string {
+	if t == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(c)/float64(t)*100)
+}
+
+// responseStatuses returns the response statuses with the counts ( e.g. "200:3 404:0" ).
+func responseStatuses(statuses map[string]int) string {
+	keys := lo.Keys(statuses)
+	sort.Strings(keys)
+	return strings.Join(lo.Map(keys, func(k string, _ int) string {
+		return fmt.Sprintf("%s:%d", k, statuses[k])
+	}), " ")
+}
+
 func init() {
 	rootCmd.AddCommand(coverageCmd)
 	coverageCmd.Flags().BoolVarP(&flgs.Long, "long", "l", false, flgs.Usage("Long"))
+	coverageCmd.Flags().BoolVarP(&flgs.CoverageResponses, "responses", "", false, flgs.Usage("CoverageResponses"))
+	coverageCmd.Flags().Float64VarP(&flgs.CoverageThreshold, "threshold", "", 0, flgs.Usage("CoverageThreshold"))
 	coverageCmd.Flags().BoolVarP(&flgs.Debug, "debug", "", false, flgs.Usage("Debug"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
@@ -186,11 +307,13 @@ func init() {
 	coverageCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
 	coverageCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
+	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCBufDirs, "grpc-buf-dir", "", []string{}, flgs.Usage("GRPCBufDirs"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCBufLocks, "grpc-buf-lock", "", []string{}, flgs.Usage("GRPCBufLocks"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCBufConfigs, "grpc-buf-config", "", []string{}, flgs.Usage("GRPCBufConfigs"))
 	coverageCmd.Flags().StringSliceVarP(&flgs.GRPCBufModules, "grpc-buf-module", "", []string{}, flgs.Usage("GRPCBufModules"))
+	coverageCmd.Flags().StringVarP(&flgs.GRPCProtoCacheDir, "grpc-proto-cache-dir", "", "", flgs.Usage("GRPCProtoCacheDir"))
 	coverageCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
 	coverageCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
 	coverageCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
@@ -0,0 +1,159 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// maxFuzzRequestLength - Max length of the request to show in the table.
+const maxFuzzRequestLength = 64
+
+// fuzzCmd represents the fuzz command.
+var fuzzCmd = &cobra.Command{
+	Use:   "fuzz [PATH_PATTERN ...]",
+	Short: "send mutated requests derived from OpenAPI spec and report unexpected responses",
+	Long: `send mutated requests derived from OpenAPI spec and report unexpected responses.
+
+The requests violating the schemas ( missing required fields, wrong types, out-of-enum values, too long strings, etc. )
+and the boundary requests are sent through the HTTP runners that have OpenAPI v3 documents.
+Responses that are server errors or do not match the documented responses are reported.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		opts, err := flgs.ToOpts()
+		if err != nil {
+			return err
+		}
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		opts = append(opts, runn.LoadOnly())
+
+		// setup cache dir
+		if err := runn.SetCacheDir(flgs.CacheDir); err != nil {
+			return err
+		}
+		defer func() {
+			if !flgs.RetainCacheDir {
+				_ = runn.RemoveCacheDir()
+			}
+		}()
+
+		o, err := runn.Load(pathp, opts...)
+		if err != nil {
+			return err
+		}
+
+		seed := flgs.FuzzSeed
+		if seed == 0 {
+			seed = rand.Uint64() //nolint:gosec
+		}
+		r, err := o.Fuzz(ctx, seed, flgs.FuzzMaxMutations)
+		if err != nil {
+			return err
+		}
+
+		if flgs.Format == "json" {
+			b, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Println(string(b))
+		} else {
+			cmd.Printf("Seed: %d\n", r.Seed)
+			cmd.Printf("%d requests, %d findings\n", r.Total, len(r.Findings))
+			if len(r.Findings) > 0 {
+				cmd.Println()
+				table := tablewriter.NewWriter(os.Stdout)
+				header := []string{"Operation", "Mutation", "Status", "Reason"}
+				if flgs.Long {
+					header = append(header, "Request")
+				}
+				table.SetHeader(header)
+				table.SetAutoWrapText(false)
+				table.SetAutoFormatHeaders(false)
+				table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+				table.SetCenterSeparator("")
+				table.SetColumnSeparator("")
+				table.SetRowSeparator("-")
+				table.SetHeaderLine(true)
+				table.SetBorder(false)
+				for _, f := range r.Findings {
+					status := ""
+					if f.Status > 0 {
+						status = fmt.Sprintf("%d", f.Status)
+					}
+					reason, _, _ := strings.Cut(f.Reason, "\n")
+					row := []string{f.Operation, f.Mutation, status, reason}
+					if flgs.Long {
+						req := f.Request
+						if len(req) > maxFuzzRequestLength {
+							req = req[:maxFuzzRequestLength] + "..."
+						}
+						row = append(row, req)
+					}
+					table.Rich(row, []tablewriter.Colors{{}, {}, {tablewriter.FgRedColor}, {tablewriter.FgRedColor}})
+				}
+				table.Render()
+			}
+		}
+		if len(r.Findings) > 0 {
+			return fmt.Errorf("%d findings ( seed: %d )", len(r.Findings), r.Seed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fuzzCmd)
+	fuzzCmd.Flags().Uint64VarP(&flgs.FuzzSeed, "seed", "", 0, flgs.Usage("FuzzSeed"))
+	fuzzCmd.Flags().IntVarP(&flgs.FuzzMaxMutations, "max-mutations", "", 0, flgs.Usage("FuzzMaxMutations"))
+	fuzzCmd.Flags().BoolVarP(&flgs.Long, "long", "l", false, flgs.Usage("Long"))
+	fuzzCmd.Flags().BoolVarP(&flgs.Debug, "debug", "", false, flgs.Usage("Debug"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.Underlays, "underlay", "", []string{}, flgs.Usage("Underlays"))
+	fuzzCmd.Flags().StringVarP(&flgs.RunMatch, "run", "", "", flgs.Usage("RunMatch"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.RunIDs, "id", "", []string{}, flgs.Usage("RunIDs"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.RunLabels, "label", "", []string{}, flgs.Usage("RunLabels"))
+	fuzzCmd.Flags().BoolVarP(&flgs.SkipIncluded, "skip-included", "", false, flgs.Usage("SkipIncluded"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
+	fuzzCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
+	fuzzCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+	fuzzCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
+	fuzzCmd.Flags().StringSliceVarP(&flgs.HostRules, "host-rules", "", []string{}, flgs.Usage("HostRules"))
+	fuzzCmd.Flags().StringVarP(&flgs.Proxy, "proxy", "", "", flgs.Usage("Proxy"))
+	fuzzCmd.Flags().StringVarP(&flgs.EnvFile, "env-file", "", "", flgs.Usage("EnvFile"))
+	if err := fuzzCmd.MarkFlagFilename("env-file"); err != nil {
+		panic(err)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/k1LoW/runn"
 	"github.com/k1LoW/runn/capture"
@@ -49,12 +50,20 @@ var newCmd = &cobra.Command{
 			err error
 			al  [][]string
 		)
-		if len(args) == 0 {
+		if flgs.FromPostman != "" {
+			if len(args) > 0 || flgs.FromOpenAPI != "" || flgs.FromHAR != "" || flgs.AndRun {
+				return errors.New("--postman cannot be used with other sources or --and-run")
+			}
+			return newFromPostman(flgs.FromPostman, flgs.Out)
+		}
+		switch {
+		case len(args) == 0 && (flgs.FromOpenAPI != "" || flgs.FromHAR != ""):
+		case len(args) == 0:
 			if isatty.IsTerminal(os.Stdin.Fd()) {
 				return errors.New("interactive mode is planned, but not yet implemented")
 			}
 			al = argsListFromStdin(os.Stdin)
-		} else {
+		default:
 			al = [][]string{args}
 		}
 		ctx := context.Background()
@@ -75,6 +84,28 @@ var newCmd = &cobra.Command{
 				}
 			}
 		}
+		if flgs.FromOpenAPI != "" {
+			ref, err := openAPI3Ref(flgs.FromOpenAPI, flgs.Out, flgs.AndRun)
+			if err != nil {
+				return err
+			}
+			if err := rb.AppendStepsFromOpenAPI3(flgs.FromOpenAPI, ref); err != nil {
+				return err
+			}
+		}
+		if flgs.FromHAR != "" {
+			f, err := os.Open(filepath.Clean(flgs.FromHAR))
+			if err != nil {
+				return err
+			}
+			if err := rb.AppendStepsFromHAR(f, flgs.HARFilter...); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
 		for _, args := range al {
 			if err := rb.AppendStep(args...); err != nil {
 				return err
@@ -122,11 +153,85 @@ func init() {
 	newCmd.Flags().StringVarP(&flgs.Desc, "desc", "", "", flgs.Usage("Desc"))
 	newCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
 	newCmd.Flags().BoolVarP(&flgs.AndRun, "and-run", "", false, flgs.Usage("AndRun"))
+	newCmd.Flags().StringVarP(&flgs.FromOpenAPI, "from-openapi", "", "", flgs.Usage("FromOpenAPI"))
+	newCmd.Flags().StringVarP(&flgs.FromHAR, "har", "", "", flgs.Usage("FromHAR"))
+	newCmd.Flags().StringSliceVarP(&flgs.HARFilter, "filter", "", []string{}, flgs.Usage("HARFilter"))
+	newCmd.Flags().StringVarP(&flgs.FromPostman, "postman", "", "", flgs.Usage("FromPostman"))
 	newCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
+	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
 }
 
+// newFromPostman writes the runbooks converted from the Postman collection to the directory ( or stdout ).
+func newFromPostman(p, dir string) error {
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return err
+	}
+	rbs, unsupported, err := runn.NewRunbooksFromPostman(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Anything that could not be translated is reported
+	for _, u := range unsupported {
+		_, _ = fmt.Fprintf(os.Stderr, "unsupported: %s\n", u)
+	}
+	if dir == "" {
+		for i, rb := range rbs {
+			if i > 0 {
+				_, _ = fmt.Fprintln(os.Stdout, "---")
+			}
+			if err := yaml.NewEncoder(os.Stdout).Encode(rb.Runbook); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Clean(dir), os.ModePerm); err != nil {
+		return err
+	}
+	for _, rb := range rbs {
+		b, err := yaml.Marshal(rb.Runbook)
+		if err != nil {
+			return err
+		}
+		fp := filepath.Join(dir, fmt.Sprintf("%s.yml", rb.Name))
+		if err := os.WriteFile(fp, b, os.ModePerm); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "created: %s\n", fp)
+	}
+	return nil
+}
+
+// openAPI3Ref returns the location of the OpenAPI v3 document relative to the runbook to be written.
+// The runbook run by --and-run is written to a temporary directory, so the absolute path is returned.
+func openAPI3Ref(l, out string, andRun bool) (string, error) {
+	if strings.Contains(l, "://") || filepath.IsAbs(l) {
+		return l, nil
+	}
+	al, err := filepath.Abs(l)
+	if err != nil {
+		return "", err
+	}
+	if andRun {
+		return al, nil
+	}
+	if out == "" {
+		return l, nil
+	}
+	ao, err := filepath.Abs(out)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(filepath.Dir(ao), al)
+}
+
 func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) error {
 	const newf = "new.yml"
 	td, err := os.MkdirTemp("", "runn")
@@ -154,6 +259,7 @@ func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) err
 		runn.Capture(capture.Runbook(td, capture.RunbookLoadDesc(true))),
 		runn.GRPCNoTLS(flgs.GRPCNoTLS),
 		runn.GRPCProtos(flgs.GRPCProtos),
+		runn.GRPCProtosets(flgs.GRPCProtosets),
 		runn.GRPCImportPaths(flgs.GRPCImportPaths),
 		runn.Scopes(runn.ScopeAllowReadParent),
 	}
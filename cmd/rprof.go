@@ -94,24 +94,9 @@ var rprofCmd = &cobra.Command{
 
 		d := make([][]string, len(r))
 		for _, rr := range r {
-			var id string
-			switch rr.trail.Type {
-			case runn.TrailTypeRunbook:
-				id = fmt.Sprintf("%srunbook[%s](%s)", strings.Repeat("  ", rr.depth), rr.trail.Desc, runn.ShortenPath(rr.trail.RunbookPath))
-			case runn.TrailTypeStep:
-				key := rr.trail.StepRunnerKey
-				if key == "" {
-					key = string(rr.trail.StepRunnerType)
-				}
-				id = fmt.Sprintf("%ssteps[%s].%s", strings.Repeat("  ", rr.depth), rr.trail.StepKey, key)
-			case runn.TrailTypeBeforeFunc:
-				id = fmt.Sprintf("%sbeforeFunc[%d]", strings.Repeat("  ", rr.depth), *rr.trail.FuncIndex)
-			case runn.TrailTypeAfterFunc:
-				id = fmt.Sprintf("%safterFunc[%d]", strings.Repeat("  ", rr.depth), *rr.trail.FuncIndex)
-			case runn.TrailTypeLoop:
-				id = fmt.Sprintf("%sloop[%d]", strings.Repeat("  ", rr.depth), *rr.trail.LoopIndex)
-			default:
-				return fmt.Errorf("invalid trail type: %s", rr.trail.Type)
+			id, err := rowID(rr)
+			if err != nil {
+				return err
 			}
 			d = append(d, []string{id, parseDuration(rr.elapsed)})
 		}
@@ -166,6 +151,34 @@ func appendBreakdown(p *stopw.Span, d, maxd int) ([]row, error) {
 	return rr, nil
 }
 
+// rowID returns the ID of the row to display.
+func rowID(r row) (string, error) {
+	switch r.trail.Type {
+	case runn.TrailTypeRunbook:
+		return fmt.Sprintf("%srunbook[%s](%s)", strings.Repeat("  ", r.depth), r.trail.Desc, runn.ShortenPath(r.trail.RunbookPath)), nil
+	case runn.TrailTypeStep:
+		key := r.trail.StepRunnerKey
+		if key == "" {
+			key = string(r.trail.StepRunnerType)
+		}
+		return fmt.Sprintf("%ssteps[%s].%s", strings.Repeat("  ", r.depth), r.trail.StepKey, key), nil
+	case runn.TrailTypeBeforeFunc:
+		return fmt.Sprintf("%sbeforeFunc[%d]", strings.Repeat("  ", r.depth), *r.trail.FuncIndex), nil
+	case runn.TrailTypeAfterFunc:
+		return fmt.Sprintf("%safterFunc[%d]", strings.Repeat("  ", r.depth), *r.trail.FuncIndex), nil
+	case runn.TrailTypeLoop:
+		return fmt.Sprintf("%sloop[%d]", strings.Repeat("  ", r.depth), *r.trail.LoopIndex), nil
+	case runn.TrailTypeHTTPTiming:
+		return fmt.Sprintf("%stiming[%s]", strings.Repeat("  ", r.depth), r.trail.TimingKey), nil
+	case runn.TrailTypeBranch:
+		return fmt.Sprintf("%sbranch[%s]", strings.Repeat("  ", r.depth), r.trail.BranchKey), nil
+	case runn.TrailTypeRetry:
+		return fmt.Sprintf("%sretry[%d]", strings.Repeat("  ", r.depth), *r.trail.RetryIndex), nil
+	default:
+		return "", fmt.Errorf("invalid trail type: %s", r.trail.Type)
+	}
+}
+
 func parseDuration(d time.Duration) string {
 	switch flgs.ProfileUnit {
 	case "ns":
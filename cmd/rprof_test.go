@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn"
+	"github.com/k1LoW/stopw"
+)
+
+func TestRowID(t *testing.T) {
+	idx := 1
+	tests := []struct {
+		r    row
+		want string
+	}{
+		{row{trail: runn.Trail{Type: runn.TrailTypeStep, StepKey: "login", StepRunnerKey: "req"}, depth: 1}, "  steps[login].req"},
+		{row{trail: runn.Trail{Type: runn.TrailTypeLoop, LoopIndex: &idx}}, "loop[1]"},
+		{row{trail: runn.Trail{Type: runn.TrailTypeBranch, BranchKey: "users"}, depth: 2}, "    branch[users]"},
+		{row{trail: runn.Trail{Type: runn.TrailTypeRetry, RetryIndex: &idx}}, "retry[1]"},
+		{row{trail: runn.Trail{Type: runn.TrailTypeHTTPTiming, TimingKey: "dns"}}, "timing[dns]"},
+	}
+	for _, tt := range tests {
+		got, err := rowID(tt.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %q\nwant %q", got, tt.want)
+		}
+	}
+	if _, err := rowID(row{trail: runn.Trail{Type: "unknown"}}); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestRowIDOfParallelProfile(t *testing.T) {
+	o, err := runn.New(runn.Book("../testdata/parallel_profile.yml"), runn.Profile(true), runn.Scopes(runn.ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := o.DumpProfile(buf); err != nil {
+		t.Fatal(err)
+	}
+	var s *stopw.Span
+	if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.Repair()
+	rows, err := appendBreakdown(s, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rows {
+		id, err := rowID(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, id)
+	}
+	for _, want := range []string{"    branch[first]", "    branch[second]"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("%q not found in %q", want, got)
+		}
+	}
+}
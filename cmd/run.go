@@ -25,7 +25,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 
@@ -70,6 +72,10 @@ var runCmd = &cobra.Command{
 			color.NoColor = false
 		}
 
+		if flgs.Watch {
+			return runWatch(ctx, pathp, opts)
+		}
+
 		o, err := runn.Load(pathp, opts...)
 		if err != nil {
 			return err
@@ -78,17 +84,8 @@ var runCmd = &cobra.Command{
 			return err
 		}
 		r := o.Result()
-		switch flgs.Format {
-		case "json":
-			if err := r.OutJSON(os.Stdout); err != nil {
-				return err
-			}
-		case "none":
-		default:
-			// If --verbose == true, leave it to cmdout to display results
-			if err := r.Out(os.Stdout, !flgs.Verbose); err != nil {
-				return err
-			}
+		if err := outResult(r); err != nil {
+			return err
 		}
 
 		if flgs.Profile {
@@ -114,22 +111,112 @@ var runCmd = &cobra.Command{
 	},
 }
 
+// clearScreen - ANSI escape sequence to clear the screen.
+const clearScreen = "\033[H\033[2J"
+
+// runWatch runs runbooks and re-runs the runbooks affected by file changes until interrupted.
+func runWatch(ctx context.Context, pathp string, opts []runn.Option) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	w, err := runn.NewWatcher(pathp, flgs.WatchPaths()...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+	targets := pathp
+	// Do not mix the screen control and the notice into the results of other formats such as JSON.
+	text := flgs.Format == ""
+	for {
+		if text {
+			_, _ = fmt.Fprint(os.Stdout, clearScreen)
+		}
+		if err := runOnce(ctx, targets, opts); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
+		if text {
+			_, _ = fmt.Fprintln(os.Stdout, "\nWatching for file changes... (Ctrl+C to quit)")
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, "Watching for file changes... (Ctrl+C to quit)")
+		}
+		affected, err := w.Wait(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		targets = strings.Join(affected, string(filepath.ListSeparator))
+	}
+}
+
+func runOnce(ctx context.Context, pathp string, opts []runn.Option) error {
+	o, err := runn.Load(pathp, opts...)
+	if err != nil {
+		return err
+	}
+	if err := o.RunN(ctx); err != nil {
+		return err
+	}
+	return outResult(o.Result())
+}
+
+type result interface {
+	Out(out io.Writer, verbose bool) error
+	OutJSON(out io.Writer) error
+	OutJUnit(out io.Writer) error
+	OutTAP(out io.Writer) error
+}
+
+func outResult(r result) error {
+	switch flgs.Format {
+	case "json":
+		if err := r.OutJSON(os.Stdout); err != nil {
+			return err
+		}
+	case "junit":
+		if err := r.OutJUnit(os.Stdout); err != nil {
+			return err
+		}
+	case "tap":
+		if err := r.OutTAP(os.Stdout); err != nil {
+			return err
+		}
+	case "none":
+	default:
+		// If --verbose == true, leave it to cmdout to display results
+		if err := r.Out(os.Stdout, !flgs.Verbose); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().BoolVarP(&flgs.Debug, "debug", "", false, flgs.Usage("Debug"))
 	runCmd.Flags().BoolVarP(&flgs.FailFast, "fail-fast", "", false, flgs.Usage("FailFast"))
 	runCmd.Flags().BoolVarP(&flgs.SkipTest, "skip-test", "", false, flgs.Usage("SkipTest"))
 	runCmd.Flags().BoolVarP(&flgs.SkipIncluded, "skip-included", "", false, flgs.Usage("SkipIncluded"))
+	runCmd.Flags().BoolVarP(&flgs.UpdateSnapshots, "update-snapshots", "", false, flgs.Usage("UpdateSnapshots"))
+	runCmd.Flags().BoolVarP(&flgs.Watch, "watch", "", false, flgs.Usage("Watch"))
 	runCmd.Flags().StringSliceVarP(&flgs.HostRules, "host-rules", "", []string{}, flgs.Usage("HostRules"))
+	runCmd.Flags().StringVarP(&flgs.Proxy, "proxy", "", "", flgs.Usage("Proxy"))
 	runCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
 	runCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
+	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufDirs, "grpc-buf-dir", "", []string{}, flgs.Usage("GRPCBufDirs"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufLocks, "grpc-buf-lock", "", []string{}, flgs.Usage("GRPCBufLocks"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufConfigs, "grpc-buf-config", "", []string{}, flgs.Usage("GRPCBufConfigs"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufModules, "grpc-buf-module", "", []string{}, flgs.Usage("GRPCBufModules"))
+	runCmd.Flags().StringVarP(&flgs.GRPCProtoCacheDir, "grpc-proto-cache-dir", "", "", flgs.Usage("GRPCProtoCacheDir"))
 	runCmd.Flags().StringVarP(&flgs.CaptureDir, "capture", "", "", flgs.Usage("CaptureDir"))
+	runCmd.Flags().StringVarP(&flgs.CaptureHARDir, "capture-har", "", "", flgs.Usage("CaptureHARDir"))
+	runCmd.Flags().StringVarP(&flgs.RecordDir, "record", "", "", flgs.Usage("RecordDir"))
+	runCmd.Flags().StringVarP(&flgs.ReplayDir, "replay", "", "", flgs.Usage("ReplayDir"))
 	runCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
 	runCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
 	runCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
@@ -143,9 +230,11 @@ func init() {
 	runCmd.Flags().IntVarP(&flgs.ShardIndex, "shard-index", "", 0, flgs.Usage("ShardIndex"))
 	runCmd.Flags().IntVarP(&flgs.ShardN, "shard-n", "", 0, flgs.Usage("ShardN"))
 	runCmd.Flags().IntVarP(&flgs.Random, "random", "", 0, flgs.Usage("Random"))
-	runCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+	// Only run supports the formats other than json
+	runCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format")+` ("json","junit","tap","none")`)
 	runCmd.Flags().BoolVarP(&flgs.Profile, "profile", "", false, flgs.Usage("Profile"))
 	runCmd.Flags().StringVarP(&flgs.ProfileOut, "profile-out", "", "runn.prof", flgs.Usage("ProfileOut"))
+	runCmd.Flags().StringVarP(&flgs.OTelTrace, "otel-trace", "", "", flgs.Usage("OTelTrace"))
 	runCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
 	runCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
 	runCmd.Flags().StringVarP(&flgs.WaitTimeout, "wait-timeout", "", "10sec", flgs.Usage("WaitTimeout"))
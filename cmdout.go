@@ -70,6 +70,13 @@ func (d *cmdOut) CaptureCDPStart(name string)
 func (d *cmdOut) CaptureCDPAction(a CDPAction)                                       {}
 func (d *cmdOut) CaptureCDPResponse(a CDPAction, res map[string]any)                 {}
 func (d *cmdOut) CaptureCDPEnd(name string)                                          {}
+func (d *cmdOut) CaptureWebSocketStart(name, url string)                             {}
+func (d *cmdOut) CaptureWebSocketSendMessage(m any)                                  {}
+func (d *cmdOut) CaptureWebSocketReceiveMessage(m any)                               {}
+func (d *cmdOut) CaptureWebSocketClose()                                             {}
+func (d *cmdOut) CaptureWebSocketEnd(name, url string)                               {}
+func (d *cmdOut) CaptureQueueProduce(name string, m *QueueMessage)                   {}
+func (d *cmdOut) CaptureQueueConsume(name string, m *QueueMessage)                   {}
 func (d *cmdOut) CaptureSSHCommand(command string)                                   {}
 func (d *cmdOut) CaptureSSHStdout(stdout string)                                     {}
 func (d *cmdOut) CaptureSSHStderr(stderr string)                                     {}
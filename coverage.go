@@ -5,13 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pb33f/libopenapi-validator/paths"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/samber/lo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var varRep = regexp.MustCompile(`\{\{([^}]+)\}\}`)
@@ -26,6 +32,9 @@ type Coverage struct {
 type SpecCoverage struct {
 	Key       string         `json:"key"`
 	Coverages map[string]int `json:"coverages"`
+	// Responses - Counts of the response statuses observed at run time per operation ( e.g. "GET /users": {"200": 1, "404": 0} ).
+	// The documented statuses of OpenAPI are counted even if not observed. The status codes of gRPC are the observed ones only.
+	Responses map[string]map[string]int `json:"responses,omitempty"`
 }
 
 func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
@@ -60,6 +69,31 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 			for op := range orderedmap.Iterate(ctx, p.Value().GetOperations()) {
 				mkey := fmt.Sprintf("%s %s", strings.ToUpper(op.Key()), p.Key())
 				scov.Coverages[mkey] += 0
+				if o.responseCoverage != nil {
+					scov.addResponses(mkey, documentedStatuses(op.Value().Responses)...)
+				}
+			}
+		}
+		if o.responseCoverage != nil {
+			for _, ob := range o.responseCoverage.httpObservations(r.operatorID, name) {
+				req := &http.Request{Method: ob.method, URL: ob.url}
+				pi, errs, pathValue := paths.FindPath(req, &v3m.Model)
+				if len(errs) > 0 {
+					o.Debugf("%s %s was not matched in %s (%s)\n", ob.method, ob.url.Path, key, o.bookPath)
+					continue
+				}
+				op, ok := pi.GetOperations().Get(strings.ToLower(ob.method))
+				if !ok {
+					continue
+				}
+				mkey := fmt.Sprintf("%s %s", ob.method, pathValue)
+				sk, ok := matchDocumentedStatus(op.Responses, ob.status)
+				if !ok {
+					o.Debugf("%d of %s was not documented in %s (%s)\n", ob.status, mkey, key, o.bookPath)
+					continue
+				}
+				scov.addResponses(mkey, sk)
+				scov.Responses[mkey][sk]++
 			}
 		}
 	L:
@@ -95,6 +129,61 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 		}
 	}
 
+	// Collect coverage for GraphQL schema
+	for name, r := range o.httpRunners {
+		gv, ok := r.validator.(*graphqlValidator)
+		if !ok {
+			o.Debugf("%s does not have graphql schema (%s)\n", name, o.bookPath)
+			continue
+		}
+		scov, ok := lo.Find(cov.Specs, func(scov *SpecCoverage) bool {
+			return scov.Key == gv.key
+		})
+		if !ok {
+			scov = &SpecCoverage{
+				Key:       gv.key,
+				Coverages: map[string]int{},
+			}
+			cov.Specs = append(cov.Specs, scov)
+		}
+		for _, f := range graphqlSchemaFields(gv.schema) {
+			scov.Coverages[f] += 0
+		}
+		for _, s := range o.steps {
+			if s.httpRunner != r {
+				continue
+			}
+			for _, m := range s.httpRequest {
+				mm, ok := m.(map[string]any)
+				if !ok {
+					continue
+				}
+				for _, mmm := range mm {
+					rm, ok := mmm.(map[string]any)
+					if !ok {
+						continue
+					}
+					gr, err := parseGraphQLRequest(rm["graphql"])
+					if err != nil {
+						continue
+					}
+					fields, err := graphqlOperationFields(gr.query, gr.operationName)
+					if err != nil {
+						o.Debugf("%s was not parsed: %s (%s)\n", gr.query, err, o.bookPath)
+						continue
+					}
+					for _, f := range fields {
+						if _, ok := scov.Coverages[f]; !ok {
+							o.Debugf("%s was not matched in %s (%s)\n", f, gv.key, o.bookPath)
+							continue
+						}
+						scov.Coverages[f]++
+					}
+				}
+			}
+		}
+	}
+
 	// Collect coverage for protocol buffers
 	for name, r := range o.grpcRunners {
 		if err := r.resolveAllMethodsUsingProtos(ctx); err != nil {
@@ -135,6 +224,237 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 				scov.Coverages[method]++
 			}
 		}
+		if o.responseCoverage != nil {
+			for _, ob := range o.responseCoverage.grpcObservations(r.operatorID, name) {
+				scov, ok := lo.Find(cov.Specs, func(scov *SpecCoverage) bool {
+					return scov.Key == ob.service
+				})
+				if !ok {
+					o.Debugf("%s/%s was not matched (%s)\n", ob.service, ob.method, o.bookPath)
+					continue
+				}
+				scov.addResponses(ob.method, ob.code.String())
+				scov.Responses[ob.method][ob.code.String()]++
+			}
+		}
 	}
 	return cov, nil
 }
+
+func (scov *SpecCoverage) addResponses(key string, statuses ...string) {
+	if scov.Responses == nil {
+		scov.Responses = map[string]map[string]int{}
+	}
+	if _, ok := scov.Responses[key]; !ok {
+		scov.Responses[key] = map[string]int{}
+	}
+	for _, s := range statuses {
+		scov.Responses[key][s] += 0
+	}
+}
+
+// documentedStatuses returns the statuses documented in the responses ( e.g. 200, 4XX, default ).
+func documentedStatuses(res *v3.Responses) []string {
+	if res == nil {
+		return nil
+	}
+	var statuses []string
+	for c := res.Codes.First(); c != nil; c = c.Next() {
+		statuses = append(statuses, strings.ToUpper(c.Key()))
+	}
+	if res.Default != nil {
+		statuses = append(statuses, "default")
+	}
+	return statuses
+}
+
+// matchDocumentedStatus returns the documented status matching the status. The exact status takes precedence over the range ( e.g. 4XX ) and default.
+func matchDocumentedStatus(res *v3.Responses, status int) (string, bool) {
+	statuses := documentedStatuses(res)
+	for _, k := range []string{strconv.Itoa(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if slices.Contains(statuses, k) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+var _ operatorCapturer = (*responseCoverageCapturer)(nil)
+
+// responseCoverageCapturer - Capturer that records the response statuses of HTTP and gRPC runners per runner.
+// Each operator has its own capturer for the in-flight gRPC call, and the capturers share the observations.
+type responseCoverageCapturer struct {
+	*responseObservations
+	// o - The operator that runs the steps ( nil for the capturer set by the option )
+	o           *operator
+	currentGRPC *grpcObservation
+}
+
+// responseObservations - Observations keyed by the id of the operator for which the runner is defined.
+type responseObservations struct {
+	http map[string][]*httpObservation
+	grpc map[string][]*grpcObservation
+	mu   sync.Mutex
+}
+
+type httpObservation struct {
+	runner string
+	method string
+	url    *url.URL
+	status int
+}
+
+type grpcObservation struct {
+	runner  string
+	service string
+	method  string
+	code    codes.Code
+}
+
+func newResponseCoverageCapturer() *responseCoverageCapturer {
+	return &responseCoverageCapturer{
+		responseObservations: &responseObservations{
+			http: map[string][]*httpObservation{},
+			grpc: map[string][]*grpcObservation{},
+		},
+	}
+}
+
+func (c *responseCoverageCapturer) forOperator(o *operator) Capturer {
+	return &responseCoverageCapturer{
+		responseObservations: c.responseObservations,
+		o:                    o,
+	}
+}
+
+func (c *responseCoverageCapturer) CaptureStart(trs Trails, bookPath, desc string)    {}
+func (c *responseCoverageCapturer) CaptureResult(trs Trails, result *RunResult)       {}
+func (c *responseCoverageCapturer) CaptureEnd(trs Trails, bookPath, desc string)      {}
+func (c *responseCoverageCapturer) CaptureResultByStep(trs Trails, result *RunResult) {}
+func (c *responseCoverageCapturer) CaptureHTTPRequest(name string, req *http.Request) {}
+
+func (c *responseCoverageCapturer) CaptureHTTPResponse(name string, res *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.httpRunnerOperatorID(name)
+	req := res.Request
+	if id == "" || req == nil {
+		return
+	}
+	// Follow redirects back to the request that the runner sent.
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	c.http[id] = append(c.http[id], &httpObservation{
+		runner: name,
+		method: req.Method,
+		url:    req.URL,
+		status: res.StatusCode,
+	})
+}
+
+func (c *responseCoverageCapturer) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	c.currentGRPC = &grpcObservation{
+		runner:  name,
+		service: service,
+		method:  method,
+	}
+}
+
+func (c *responseCoverageCapturer) CaptureGRPCRequestHeaders(h map[string][]string) {}
+func (c *responseCoverageCapturer) CaptureGRPCRequestMessage(m map[string]any)      {}
+
+func (c *responseCoverageCapturer) CaptureGRPCResponseStatus(s *status.Status) {
+	if c.currentGRPC == nil {
+		return
+	}
+	c.currentGRPC.code = s.Code()
+}
+
+func (c *responseCoverageCapturer) CaptureGRPCResponseHeaders(h map[string][]string)  {}
+func (c *responseCoverageCapturer) CaptureGRPCResponseMessage(m map[string]any)       {}
+func (c *responseCoverageCapturer) CaptureGRPCResponseTrailers(t map[string][]string) {}
+func (c *responseCoverageCapturer) CaptureGRPCClientClose()                           {}
+
+func (c *responseCoverageCapturer) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	ob := c.currentGRPC
+	c.currentGRPC = nil
+	id := c.grpcRunnerOperatorID(name)
+	if ob == nil || id == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.grpc[id] = append(c.grpc[id], ob)
+}
+
+func (c *responseCoverageCapturer) CaptureCDPStart(name string)                        {}
+func (c *responseCoverageCapturer) CaptureCDPAction(a CDPAction)                       {}
+func (c *responseCoverageCapturer) CaptureCDPResponse(a CDPAction, res map[string]any) {}
+func (c *responseCoverageCapturer) CaptureCDPEnd(name string)                          {}
+
+func (c *responseCoverageCapturer) CaptureWebSocketStart(name, url string)           {}
+func (c *responseCoverageCapturer) CaptureWebSocketSendMessage(m any)                {}
+func (c *responseCoverageCapturer) CaptureWebSocketReceiveMessage(m any)             {}
+func (c *responseCoverageCapturer) CaptureWebSocketClose()                           {}
+func (c *responseCoverageCapturer) CaptureWebSocketEnd(name, url string)             {}
+func (c *responseCoverageCapturer) CaptureQueueProduce(name string, m *QueueMessage) {}
+func (c *responseCoverageCapturer) CaptureQueueConsume(name string, m *QueueMessage) {}
+
+func (c *responseCoverageCapturer) CaptureSSHCommand(command string) {}
+func (c *responseCoverageCapturer) CaptureSSHStdout(stdout string)   {}
+func (c *responseCoverageCapturer) CaptureSSHStderr(stderr string)   {}
+
+func (c *responseCoverageCapturer) CaptureDBStatement(name string, stmt string)               {}
+func (c *responseCoverageCapturer) CaptureDBResponse(name string, res *DBResponse)            {}
+func (c *responseCoverageCapturer) CaptureExecCommand(command, shell string, background bool) {}
+func (c *responseCoverageCapturer) CaptureExecStdin(stdin string)                             {}
+func (c *responseCoverageCapturer) CaptureExecStdout(stdout string)                           {}
+func (c *responseCoverageCapturer) CaptureExecStderr(stderr string)                           {}
+
+func (c *responseCoverageCapturer) SetCurrentTrails(trs Trails) {}
+
+func (c *responseCoverageCapturer) Errs() error {
+	return nil
+}
+
+// httpRunnerOperatorID returns the id of the operator for which the HTTP runner is defined.
+// Included runbooks and parallel branches reuse the runners of the parent, so the responses are counted for the parent.
+func (c *responseCoverageCapturer) httpRunnerOperatorID(name string) string {
+	if c.o == nil {
+		return ""
+	}
+	r, ok := c.o.httpRunners[name]
+	if !ok {
+		return ""
+	}
+	return r.operatorID
+}
+
+// grpcRunnerOperatorID returns the id of the operator for which the gRPC runner is defined.
+func (c *responseCoverageCapturer) grpcRunnerOperatorID(name string) string {
+	if c.o == nil {
+		return ""
+	}
+	r, ok := c.o.grpcRunners[name]
+	if !ok {
+		return ""
+	}
+	return r.operatorID
+}
+
+func (c *responseObservations) httpObservations(id, runner string) []*httpObservation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lo.Filter(c.http[id], func(ob *httpObservation, _ int) bool {
+		return ob.runner == runner
+	})
+}
+
+func (c *responseObservations) grpcObservations(id, runner string) []*grpcObservation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lo.Filter(c.grpc[id], func(ob *grpcObservation, _ int) bool {
+		return ob.runner == runner
+	})
+}
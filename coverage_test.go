@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/grpcstub"
 	"github.com/k1LoW/runn/testutil"
 	"github.com/tenntenn/golden"
 )
@@ -48,3 +50,108 @@ func TestCoverage(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseCoverage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OpenAPI v3", func(t *testing.T) {
+		hs := testutil.HTTPServer(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", hs.URL)
+		o, err := New(Book("testdata/book/http.yml"), ResponseCoverage(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cov, err := o.collectCoverage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cov.Specs) != 1 {
+			t.Fatalf("got %d specs want 1", len(cov.Specs))
+		}
+		got := cov.Specs[0].Responses
+		want := map[string]map[string]int{
+			"GET /notfound": {"404": 1},
+			"GET /redirect": {"302": 0, "404": 1},
+			"POST /users":   {"201": 1, "400": 0},
+		}
+		for k, v := range want {
+			if diff := cmp.Diff(got[k], v); diff != "" {
+				t.Errorf("%s: %s", k, diff)
+			}
+		}
+	})
+
+	t.Run("Parallel and include", func(t *testing.T) {
+		hs := testutil.HTTPServer(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", hs.URL)
+		o, err := New(Book("testdata/response_coverage_nested.yml"), ResponseCoverage(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cov, err := o.collectCoverage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cov.Specs) != 1 {
+			t.Fatalf("got %d specs want 1", len(cov.Specs))
+		}
+		if diff := cmp.Diff(cov.Specs[0].Responses["GET /notfound"], map[string]int{"404": 2}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("gRPC", func(t *testing.T) {
+		ts := grpcstub.NewServer(t, "testdata/mock/greeter.proto")
+		t.Cleanup(func() {
+			ts.Close()
+		})
+		ts.Method("mock.Greeter/Hello").Response(map[string]any{"message": "hello alice", "num": 3})
+		p := testutil.ProxyServer(t, false)
+		t.Setenv("TEST_GRPC_ADDR", ts.Addr())
+		t.Setenv("TEST_GRPC_PROXY", p.URL)
+		o, err := New(Book("testdata/book/grpc_proxy.yml"), GRPCProtos([]string{"testdata/mock/greeter.proto"}), ResponseCoverage(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cov, err := o.collectCoverage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cov.Specs) != 1 {
+			t.Fatalf("got %d specs want 1", len(cov.Specs))
+		}
+		want := map[string]map[string]int{
+			"Hello": {"OK": 1},
+		}
+		if diff := cmp.Diff(cov.Specs[0].Responses, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		hs := testutil.HTTPServer(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", hs.URL)
+		o, err := New(Book("testdata/book/http.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cov, err := o.collectCoverage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, spec := range cov.Specs {
+			if spec.Responses != nil {
+				t.Errorf("got %v want nil", spec.Responses)
+			}
+		}
+	})
+}
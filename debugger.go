@@ -96,6 +96,32 @@ func (d *debugger) CaptureCDPEnd(name string) {
 	_, _ = fmt.Fprint(d.out, "<<<<<END CDP<<<<<\n")
 }
 
+func (d *debugger) CaptureWebSocketStart(name, url string) {
+	_, _ = fmt.Fprintf(d.out, ">>>>>START WebSocket (%s)>>>>>\n", url)
+}
+
+func (d *debugger) CaptureWebSocketSendMessage(m any) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket SEND MESSAGE-----\n%s\n-----END WebSocket SEND MESSAGE-----\n", dumpWebSocketMessage(m))
+}
+
+func (d *debugger) CaptureWebSocketReceiveMessage(m any) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket RECEIVE MESSAGE-----\n%s\n-----END WebSocket RECEIVE MESSAGE-----\n", dumpWebSocketMessage(m))
+}
+
+func (d *debugger) CaptureWebSocketClose() {}
+
+func (d *debugger) CaptureWebSocketEnd(name, url string) {
+	_, _ = fmt.Fprintf(d.out, "<<<<<END WebSocket (%s)<<<<<\n", url)
+}
+
+func (d *debugger) CaptureQueueProduce(name string, m *QueueMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START QUEUE PRODUCE MESSAGE-----\n%s\n-----END QUEUE PRODUCE MESSAGE-----\n", dumpQueueMessage(m))
+}
+
+func (d *debugger) CaptureQueueConsume(name string, m *QueueMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START QUEUE CONSUME MESSAGE-----\n%s\n-----END QUEUE CONSUME MESSAGE-----\n", dumpQueueMessage(m))
+}
+
 func (d *debugger) CaptureSSHCommand(command string) {
 	_, _ = fmt.Fprintf(d.out, "-----START COMMAND-----\n%s\n-----END COMMAND-----\n", command)
 }
@@ -192,6 +218,40 @@ var (
 	dumpGRPCMessage = dumpMapInterface
 )
 
+func dumpWebSocketMessage(m any) string {
+	switch v := m.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case map[string]any:
+		return dumpMapInterface(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func dumpQueueMessage(m *QueueMessage) string {
+	var keys []string
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var hs []string
+	for _, k := range keys {
+		hs = append(hs, fmt.Sprintf("%s: %s", k, m.Headers[k]))
+	}
+	d := fmt.Sprintf("topic: %s\n", m.Topic)
+	if len(m.Key) > 0 {
+		d += fmt.Sprintf("key: %s\n", string(m.Key))
+	}
+	if len(hs) > 0 {
+		d += strings.Join(hs, "\n") + "\n"
+	}
+	return d + "\n" + string(m.Value)
+}
+
 func dumpGRPCMetadata(m map[string][]string) string {
 	var keys []string
 	for k := range m {
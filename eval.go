@@ -275,4 +275,12 @@ func onPrintTraceCallNode(tp exprtrace.TreePrinter, tree treeprint.Tree, callNod
 
 		tree.AddNode(fmt.Sprintf("(diff) => %s", strings.TrimSuffix(diff, "\n")))
 	}
+
+	if callNode.Callee.String() == "jsonschema" {
+		errs, ok := callOutput.(builtin.JSONSchemaErrors)
+		if !ok || len(errs) == 0 {
+			return
+		}
+		tree.AddNode(fmt.Sprintf("(errors) => %s", strings.Join(errs, "\n")))
+	}
 }
@@ -473,6 +473,34 @@ func TestEvalWithTraceFormatTraceTree(t *testing.T) {
         - 	1,
         + 	2,
           )
+`,
+		},
+		{
+			"len(jsonschema(vars.schema, vars.v)) == 0",
+			exprtrace.EvalEnv{
+				"vars": map[string]any{
+					"schema": map[string]any{
+						"type":     "object",
+						"required": []any{"id"},
+						"properties": map[string]any{
+							"name": map[string]any{"type": "string"},
+						},
+					},
+					"v": map[string]any{
+						"name": 1,
+					},
+				},
+				"jsonschema": builtin.JSONSchema,
+			},
+			`len(jsonschema(vars.schema, vars.v)) == 0
+│
+├── len(jsonschema(vars.schema, vars.v)) => 2
+│   └── jsonschema(vars.schema, vars.v) => ["/: missing properties: 'id'","/name: expected string, but got number"]
+│       ├── (errors) => /: missing properties: 'id'
+│       │   /name: expected string, but got number
+│       ├── vars.schema => {"properties":{"name":{"type":"string"}},"required":["id"],"type":"object"}
+│       └── vars.v => {"name":1}
+└── 0
 `,
 		},
 		{
@@ -760,8 +760,10 @@ func (p *secondPhasePatcher) Visit(node *ast.Node) {
 }
 
 func (p *secondPhasePatcher) patchNode(node *ast.Node, tracerCallee *ast.IdentifierNode, args []ast.Node) {
+	// The checker sets the type of the callee, so copy the shared identifier node to avoid data races on concurrent evaluation.
+	callee := &ast.IdentifierNode{Value: tracerCallee.Value}
 	patchNode := &ast.CallNode{
-		Callee:    tracerCallee,
+		Callee:    callee,
 		Arguments: args,
 	}
 	patchNode.SetType((*node).Type())
@@ -0,0 +1,647 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	validator "github.com/pb33f/libopenapi-validator"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// fuzzOversizedLength - Length of the oversized string for the schema without maxLength.
+const fuzzOversizedLength = 8192
+
+// maxFuzzDepth - Max depth of nested objects of the request body to mutate.
+const maxFuzzDepth = 3
+
+const fuzzLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// FuzzResult is a result of sending the mutated requests derived from OpenAPI v3 documents.
+type FuzzResult struct {
+	Seed     uint64         `json:"seed"`
+	Total    int            `json:"total"`
+	Findings []*FuzzFinding `json:"findings"`
+}
+
+// FuzzFinding is a response to the mutated request that is a server error or does not match the document.
+type FuzzFinding struct {
+	Spec      string `json:"spec"`
+	Operation string `json:"operation"`
+	Mutation  string `json:"mutation"`
+	Request   string `json:"request"`
+	Status    int    `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+type fuzzer struct {
+	rng *rand.Rand
+	// n - Max number of mutated requests per operation. 0 means all
+	n      int
+	seen   map[string]struct{}
+	result *FuzzResult
+}
+
+func newFuzzer(seed uint64, n int) *fuzzer {
+	return &fuzzer{
+		rng:  rand.New(rand.NewPCG(seed, seed)), //nolint:gosec
+		n:    n,
+		seen: map[string]struct{}{},
+		result: &FuzzResult{
+			Seed:     seed,
+			Findings: []*FuzzFinding{},
+		},
+	}
+}
+
+// fuzzRequest is the request of the operation before being converted into the step of the HTTP runner.
+type fuzzRequest struct {
+	method      string
+	path        string
+	params      map[string]any
+	query       map[string]any
+	headers     map[string]any
+	contentType string
+	body        any
+}
+
+type fuzzMutation struct {
+	desc string
+	// violation - Whether the mutated request violates the document
+	violation bool
+	req       *fuzzRequest
+}
+
+// fuzzValidator skips validating requests because the mutated requests violate the document on purpose,
+// and keeps the errors of validating responses instead of returning them.
+type fuzzValidator struct {
+	doc       libopenapi.Document
+	validator validator.Validator
+	errs      []string
+}
+
+func newFuzzValidator(doc libopenapi.Document) (*fuzzValidator, error) {
+	v, errs := validator.NewValidator(doc)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &fuzzValidator{
+		doc:       doc,
+		validator: v,
+	}, nil
+}
+
+func (v *fuzzValidator) ValidateRequest(ctx context.Context, req *http.Request) error {
+	return nil
+}
+
+func (v *fuzzValidator) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	v.errs = nil
+	_, errs := v.validator.ValidateHttpResponse(req, res)
+	if len(errs) == 0 {
+		return nil
+	}
+	// renew validator (workaround)
+	// ref: https://github.com/k1LoW/runn/issues/882
+	vv, errrs := validator.NewValidator(v.doc)
+	if len(errrs) > 0 {
+		return errors.Join(errrs...)
+	}
+	v.validator = vv
+	for _, e := range errs {
+		// nullable type workaround.
+		if nullableError(e) {
+			continue
+		}
+		v.errs = append(v.errs, e.Message)
+	}
+	return nil
+}
+
+// Fuzz sends the mutated requests derived from the OpenAPI v3 documents of HTTP runners, and reports the responses that are server errors or do not match the documents.
+// The requests are reproducible with the same seed. n is the max number of mutated requests per operation ( 0 means all ).
+func (ops *operators) Fuzz(ctx context.Context, seed uint64, n int) (*FuzzResult, error) {
+	fz := newFuzzer(seed, n)
+	for _, o := range ops.ops {
+		if err := o.fuzz(ctx, fz); err != nil {
+			return nil, err
+		}
+	}
+	return fz.result, nil
+}
+
+func (o *operator) fuzz(ctx context.Context, fz *fuzzer) error {
+	names := make([]string, 0, len(o.httpRunners))
+	for name := range o.httpRunners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := o.httpRunners[name]
+		ov, ok := r.validator.(*openAPI3Validator)
+		if !ok {
+			o.Debugf("%s does not have openapi3 spec document (%s)\n", name, o.bookPath)
+			continue
+		}
+		if err := o.fuzzHTTPRunner(ctx, fz, r, ov); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *operator) fuzzHTTPRunner(ctx context.Context, fz *fuzzer, r *httpRunner, ov *openAPI3Validator) error {
+	v3m, errs := ov.doc.BuildV3Model()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	spec := fmt.Sprintf("%s:%s", v3m.Model.Info.Title, v3m.Model.Info.Version)
+	var endpoint string
+	if r.endpoint != nil {
+		endpoint = r.endpoint.String()
+	}
+	// The same operations of the same endpoint are fuzzed only once
+	sk := fmt.Sprintf("%s %s", spec, endpoint)
+	if _, ok := fz.seen[sk]; ok {
+		return nil
+	}
+	fz.seen[sk] = struct{}{}
+	if v3m.Model.Paths == nil {
+		return nil
+	}
+
+	fv, err := newFuzzValidator(ov.doc)
+	if err != nil {
+		return err
+	}
+	// Mutated requests are sent through the runner with the fuzz validator
+	orig := r.validator
+	r.validator = fv
+	defer func() {
+		r.validator = orig
+	}()
+	// Responses are recorded to the operator for fuzzing, not to the runbook
+	fo, err := New()
+	if err != nil {
+		return err
+	}
+	defer fo.Close(true)
+	fo.root = o.root
+	fo.debug = o.debug
+
+	for p := v3m.Model.Paths.PathItems.First(); p != nil; p = p.Next() {
+		for op := p.Value().GetOperations().First(); op != nil; op = op.Next() {
+			opk := fmt.Sprintf("%s %s", strings.ToUpper(op.Key()), p.Key())
+			ms, err := fz.mutations(p.Key(), op.Key(), p.Value(), op.Value())
+			if err != nil {
+				return fmt.Errorf("failed to mutate request of %s: %w", opk, err)
+			}
+			for _, m := range ms {
+				fz.result.Total++
+				f, err := fo.sendMutation(ctx, r, fv, op.Value(), m)
+				if err != nil {
+					return err
+				}
+				if f == nil {
+					continue
+				}
+				f.Spec = spec
+				f.Operation = opk
+				fz.result.Findings = append(fz.result.Findings, f)
+			}
+		}
+	}
+	return nil
+}
+
+// sendMutation sends the mutated request through the HTTP runner and returns the finding if the response is unexpected.
+func (o *operator) sendMutation(ctx context.Context, r *httpRunner, fv *fuzzValidator, op *v3.Operation, m *fuzzMutation) (*FuzzFinding, error) {
+	path, rm := m.req.toRequest()
+	req, err := parseHTTPRequest(rm)
+	if err != nil {
+		return nil, err
+	}
+	f := &FuzzFinding{
+		Mutation: m.desc,
+		Request:  fmt.Sprintf("%s %s", m.req.method, path),
+	}
+	// Only the response of the latest mutated request is needed, so the steps and the store are reset for each request
+	s := newStep(0, "0", o, nil)
+	o.steps = []*step{s}
+	o.store.clearSteps()
+	if err := r.run(ctx, req, s); err != nil {
+		f.Reason = fmt.Sprintf("request failed: %s", err)
+		return f, nil
+	}
+	res, ok := o.store.latest()[httpStoreResponseKey].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid response of %s", f.Request)
+	}
+	f.Status, ok = res[httpStoreStatusKey].(int)
+	if !ok {
+		return nil, fmt.Errorf("invalid response status of %s", f.Request)
+	}
+	switch {
+	case f.Status >= http.StatusInternalServerError:
+		f.Reason = "server error"
+	case m.violation && f.Status < http.StatusBadRequest:
+		f.Reason = "invalid request was accepted"
+	case !documentedStatus(op.Responses, f.Status):
+		f.Reason = "undocumented status"
+	case len(fv.errs) > 0:
+		f.Reason = fmt.Sprintf("response does not match the document: %s", strings.Join(fv.errs, ", "))
+	default:
+		return nil, nil
+	}
+	return f, nil
+}
+
+// documentedStatus returns whether the status is documented in the responses ( e.g. 400, 4XX or default ).
+func documentedStatus(res *v3.Responses, status int) bool {
+	if res == nil {
+		return false
+	}
+	if res.Default != nil {
+		return true
+	}
+	for c := res.Codes.First(); c != nil; c = c.Next() {
+		k := strings.ToUpper(c.Key())
+		if k == strconv.Itoa(status) || k == fmt.Sprintf("%dXX", status/100) {
+			return true
+		}
+	}
+	return false
+}
+
+// mutations returns the mutated requests of the operation in random order.
+func (fz *fuzzer) mutations(path, method string, pi *v3.PathItem, op *v3.Operation) ([]*fuzzMutation, error) {
+	base, err := fuzzBaseRequest(path, method, pi, op)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*fuzzMutation
+
+	// Parameters
+	for _, p := range operationParameters(pi, op) {
+		if base.target(p.In) == nil {
+			// cookie
+			continue
+		}
+		loc := fmt.Sprintf("%s parameter %s", p.In, p.Name)
+		if p.In != "path" && p.Required != nil && *p.Required {
+			req := base.clone()
+			delete(req.target(p.In), p.Name)
+			ms = append(ms, &fuzzMutation{desc: fmt.Sprintf("missing required %s", loc), violation: true, req: req})
+		}
+		if p.Schema == nil {
+			continue
+		}
+		s, err := p.Schema.BuildSchema()
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+		for _, vm := range fz.valueMutations(s, false) {
+			req := base.clone()
+			req.target(p.In)[p.Name] = vm.value
+			ms = append(ms, &fuzzMutation{desc: fmt.Sprintf("%s %s", vm.desc, loc), violation: vm.violation, req: req})
+		}
+	}
+
+	// Request body
+	if s, err := requestBodySchema(op.RequestBody, base.contentType); err != nil {
+		return nil, err
+	} else if s != nil {
+		if _, ok := base.body.(map[string]any); ok && schemaType(s) == "object" {
+			req := base.clone()
+			req.body = []any{}
+			ms = append(ms, &fuzzMutation{desc: "wrong type body (want object)", violation: true, req: req})
+			bms, err := fz.bodyMutations(base, s, nil, 0)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, bms...)
+		}
+	}
+
+	fz.rng.Shuffle(len(ms), func(i, j int) {
+		ms[i], ms[j] = ms[j], ms[i]
+	})
+	if fz.n > 0 && len(ms) > fz.n {
+		ms = ms[:fz.n]
+	}
+	return ms, nil
+}
+
+// bodyMutations returns the mutated requests of the properties of the object in the request body.
+func (fz *fuzzer) bodyMutations(base *fuzzRequest, s *base.Schema, keys []string, depth int) ([]*fuzzMutation, error) {
+	if depth >= maxFuzzDepth {
+		return nil, nil
+	}
+	props, required, err := schemaProperties(s)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*fuzzMutation
+	for _, r := range required {
+		k := append(slices.Clone(keys), r)
+		req := base.clone()
+		if !deleteBodyValue(req.body, k) {
+			continue
+		}
+		ms = append(ms, &fuzzMutation{desc: fmt.Sprintf("missing required body.%s", strings.Join(k, ".")), violation: true, req: req})
+	}
+	for _, p := range props {
+		ps, err := p.schema.BuildSchema()
+		if err != nil {
+			return nil, err
+		}
+		if ps == nil || (ps.ReadOnly != nil && *ps.ReadOnly) {
+			continue
+		}
+		k := append(slices.Clone(keys), p.name)
+		for _, vm := range fz.valueMutations(ps, true) {
+			req := base.clone()
+			if !setBodyValue(req.body, k, vm.value) {
+				continue
+			}
+			ms = append(ms, &fuzzMutation{desc: fmt.Sprintf("%s body.%s", vm.desc, strings.Join(k, ".")), violation: vm.violation, req: req})
+		}
+		if schemaType(ps) == "object" {
+			nms, err := fz.bodyMutations(base, ps, k, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, nms...)
+		}
+	}
+	return ms, nil
+}
+
+type fuzzValue struct {
+	desc      string
+	value     any
+	violation bool
+}
+
+// valueMutations returns the values that violate the schema or are on the boundary of the schema.
+// typed is whether the value keeps its type ( request body ) or is sent as a string ( parameters ).
+func (fz *fuzzer) valueMutations(s *base.Schema, typed bool) []fuzzValue {
+	var vs []fuzzValue
+	t := schemaType(s)
+	if len(s.Enum) > 0 {
+		vs = append(vs, fuzzValue{desc: "out of enum", value: fmt.Sprintf("runn-%s", fz.randomString(8)), violation: true})
+	}
+	switch t {
+	case "string":
+		if typed {
+			vs = append(vs, fuzzValue{desc: "wrong type (want string)", value: fz.rng.IntN(1000000), violation: true})
+		}
+		switch {
+		case len(s.Enum) > 0:
+			// out of enum
+		case s.MaxLength != nil:
+			vs = append(vs, fuzzValue{desc: fmt.Sprintf("too long (maxLength %d)", *s.MaxLength), value: fz.randomString(int(*s.MaxLength) + 1), violation: true})
+		default:
+			vs = append(vs, fuzzValue{desc: "oversized string", value: fz.randomString(fuzzOversizedLength)})
+		}
+		if s.MinLength != nil && *s.MinLength > 0 {
+			vs = append(vs, fuzzValue{desc: fmt.Sprintf("too short (minLength %d)", *s.MinLength), value: fz.randomString(int(*s.MinLength) - 1), violation: true})
+		}
+	case "integer", "number":
+		vs = append(vs, fuzzValue{desc: fmt.Sprintf("wrong type (want %s)", t), value: fz.randomString(8), violation: true})
+		if s.Minimum != nil {
+			vs = append(vs, fuzzValue{desc: fmt.Sprintf("below minimum (minimum %v)", *s.Minimum), value: boundaryNumber(t, *s.Minimum, -1), violation: true})
+		}
+		if s.Maximum != nil {
+			vs = append(vs, fuzzValue{desc: fmt.Sprintf("above maximum (maximum %v)", *s.Maximum), value: boundaryNumber(t, *s.Maximum, 1), violation: true})
+		}
+	case "boolean":
+		vs = append(vs, fuzzValue{desc: "wrong type (want boolean)", value: fz.randomString(8), violation: true})
+	case "object", "array":
+		if typed {
+			vs = append(vs, fuzzValue{desc: fmt.Sprintf("wrong type (want %s)", t), value: fz.randomString(8), violation: true})
+		}
+	}
+	return vs
+}
+
+func (fz *fuzzer) randomString(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = fuzzLetters[fz.rng.IntN(len(fuzzLetters))]
+	}
+	return string(b)
+}
+
+func boundaryNumber(t string, v float64, delta int) any {
+	if t == "integer" {
+		return int64(v) + int64(delta)
+	}
+	return v + float64(delta)
+}
+
+// fuzzBaseRequest returns the valid request of the operation that is the base of the mutated requests.
+func fuzzBaseRequest(path, method string, pi *v3.PathItem, op *v3.Operation) (*fuzzRequest, error) {
+	req := &fuzzRequest{
+		method:  strings.ToUpper(method),
+		path:    path,
+		params:  map[string]any{},
+		query:   map[string]any{},
+		headers: map[string]any{},
+	}
+	for _, p := range operationParameters(pi, op) {
+		if p.In != "path" && (p.Required == nil || !*p.Required) {
+			continue
+		}
+		v, err := parameterExample(p)
+		if err != nil {
+			return nil, err
+		}
+		if t := req.target(p.In); t != nil {
+			t[p.Name] = v
+		}
+	}
+	body, err := requestBodyExample(method, op.RequestBody)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := body.(yaml.MapSlice); ok && len(b) == 1 {
+		req.contentType = fmt.Sprint(b[0].Key)
+		req.body = mapSliceToMap(b[0].Value)
+	}
+	return req, nil
+}
+
+func (r *fuzzRequest) target(in string) map[string]any {
+	switch in {
+	case "path":
+		return r.params
+	case "query":
+		return r.query
+	case "header":
+		return r.headers
+	default:
+		return nil
+	}
+}
+
+func (r *fuzzRequest) clone() *fuzzRequest {
+	c := &fuzzRequest{
+		method:      r.method,
+		path:        r.path,
+		params:      dcopy(r.params).(map[string]any),
+		query:       dcopy(r.query).(map[string]any),
+		headers:     dcopy(r.headers).(map[string]any),
+		contentType: r.contentType,
+	}
+	if r.body != nil {
+		c.body = dcopy(r.body)
+	}
+	return c
+}
+
+// toRequest returns the path with the parameters and the request of the HTTP runner.
+func (r *fuzzRequest) toRequest() (string, map[string]any) {
+	path := r.path
+	for k, v := range r.params {
+		path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", k), url.PathEscape(fmt.Sprint(v)))
+	}
+	if len(r.query) > 0 {
+		q := url.Values{}
+		for k, v := range r.query {
+			q.Add(k, fmt.Sprint(v))
+		}
+		path = fmt.Sprintf("%s?%s", path, q.Encode())
+	}
+	headers := map[string]any{}
+	for k, v := range r.headers {
+		headers[k] = fmt.Sprint(v)
+	}
+	req := map[string]any{
+		"headers": headers,
+		"body":    nil,
+	}
+	if r.contentType != "" {
+		req["body"] = map[string]any{r.contentType: r.body}
+	}
+	return path, map[string]any{
+		path: map[string]any{
+			strings.ToLower(r.method): req,
+		},
+	}
+}
+
+func requestBodySchema(rb *v3.RequestBody, contentType string) (*base.Schema, error) {
+	if rb == nil || rb.Content == nil || contentType == "" {
+		return nil, nil
+	}
+	mt, ok := rb.Content.Get(contentType)
+	if !ok || mt.Schema == nil {
+		return nil, nil
+	}
+	return mt.Schema.BuildSchema()
+}
+
+type schemaProperty struct {
+	name   string
+	schema *base.SchemaProxy
+}
+
+// schemaProperties returns the properties and the required properties of the object schema including allOf.
+func schemaProperties(s *base.Schema) ([]schemaProperty, []string, error) {
+	var (
+		props    []schemaProperty
+		required []string
+	)
+	for _, a := range s.AllOf {
+		as, err := a.BuildSchema()
+		if err != nil {
+			return nil, nil, err
+		}
+		if as == nil {
+			continue
+		}
+		ap, ar, err := schemaProperties(as)
+		if err != nil {
+			return nil, nil, err
+		}
+		props = append(props, ap...)
+		required = append(required, ar...)
+	}
+	if s.Properties != nil {
+		for p := s.Properties.First(); p != nil; p = p.Next() {
+			props = append(props, schemaProperty{name: p.Key(), schema: p.Value()})
+		}
+	}
+	required = append(required, s.Required...)
+	return props, required, nil
+}
+
+func setBodyValue(body any, keys []string, v any) bool {
+	m, ok := bodyParent(body, keys)
+	if !ok {
+		return false
+	}
+	m[keys[len(keys)-1]] = v
+	return true
+}
+
+func deleteBodyValue(body any, keys []string) bool {
+	m, ok := bodyParent(body, keys)
+	if !ok {
+		return false
+	}
+	if _, ok := m[keys[len(keys)-1]]; !ok {
+		return false
+	}
+	delete(m, keys[len(keys)-1])
+	return true
+}
+
+// bodyParent returns the object that has the value of the keys.
+func bodyParent(body any, keys []string) (map[string]any, bool) {
+	m, ok := body.(map[string]any)
+	if !ok || len(keys) == 0 {
+		return nil, false
+	}
+	for _, k := range keys[:len(keys)-1] {
+		m, ok = m[k].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// mapSliceToMap converts yaml.MapSlice in v into map[string]any.
+func mapSliceToMap(v any) any {
+	switch vv := v.(type) {
+	case yaml.MapSlice:
+		m := map[string]any{}
+		for _, i := range vv {
+			m[fmt.Sprint(i.Key)] = mapSliceToMap(i.Value)
+		}
+		return m
+	case []any:
+		s := make([]any, len(vv))
+		for i, ii := range vv {
+			s[i] = mapSliceToMap(ii)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,126 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func fuzzTestHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var u map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid body"}`))
+			return
+		}
+		if _, ok := u["name"]; !ok {
+			// The document requires "error"
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"name is required"}`))
+			return
+		}
+		if _, ok := u["name"].(string); !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid name"}`))
+			return
+		}
+		if role, ok := u["role"]; ok {
+			if _, ok := role.(string); !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid role"}`))
+				return
+			}
+			if role != "admin" && role != "member" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if id < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func TestFuzz(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(HTTPRunnerWithHandler("req", fuzzTestHandler(), OpenAPI3("testdata/openapi3_fuzz.yml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fz := newFuzzer(1, 0)
+	if err := o.fuzz(ctx, fz); err != nil {
+		t.Fatal(err)
+	}
+	if want := 8; fz.result.Total != want {
+		t.Errorf("got %v want %v", fz.result.Total, want)
+	}
+	var got []string
+	for _, f := range fz.result.Findings {
+		reason, _, _ := strings.Cut(f.Reason, ":")
+		got = append(got, strings.Join([]string{f.Operation, f.Mutation, strconv.Itoa(f.Status), reason}, " | "))
+	}
+	sort.Strings(got)
+	want := []string{
+		"GET /users/{id} | wrong type (want integer) path parameter id | 404 | undocumented status",
+		"POST /users | missing required body.name | 400 | response does not match the document",
+		"POST /users | out of enum body.role | 500 | server error",
+		"POST /users | too long (maxLength 10) body.name | 201 | invalid request was accepted",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	// The validator of the runner is restored
+	if _, ok := o.httpRunners["req"].validator.(*openAPI3Validator); !ok {
+		t.Errorf("got %T want *openAPI3Validator", o.httpRunners["req"].validator)
+	}
+}
+
+func TestFuzzSeed(t *testing.T) {
+	ctx := context.Background()
+	// Every mutated request is reported as a finding
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	run := func(seed uint64) []string {
+		o, err := New(HTTPRunnerWithHandler("req", h, OpenAPI3("testdata/openapi3_fuzz.yml")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fz := newFuzzer(seed, 2)
+		if err := o.fuzz(ctx, fz); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range fz.result.Findings {
+			got = append(got, f.Request)
+		}
+		return got
+	}
+	got := run(42)
+	if want := 4; len(got) != want {
+		t.Errorf("got %v want %v", len(got), want)
+	}
+	if diff := cmp.Diff(got, run(42)); diff != "" {
+		t.Error(diff)
+	}
+}
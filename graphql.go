@@ -0,0 +1,544 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+const (
+	graphqlQueryKey         = "query"
+	graphqlVariablesKey     = "variables"
+	graphqlOperationNameKey = "operationName"
+	graphqlErrorsKey        = "errors"
+)
+
+type graphqlRequest struct {
+	query         string
+	variables     map[string]any
+	operationName string
+}
+
+// globalGraphQLSchemaRegistory - global registory of GraphQL schemas.
+var globalGraphQLSchemaRegistory = map[string]*ast.Schema{}
+var globalGraphQLSchemaRegistoryMu sync.RWMutex
+
+type graphqlValidator struct {
+	skipValidateRequest  bool
+	skipValidateResponse bool
+	key                  string
+	schema               *ast.Schema
+}
+
+func (r *graphqlRequest) body() map[string]any {
+	b := map[string]any{
+		graphqlQueryKey: r.query,
+	}
+	if len(r.variables) > 0 {
+		b[graphqlVariablesKey] = r.variables
+	}
+	if r.operationName != "" {
+		b[graphqlOperationNameKey] = r.operationName
+	}
+	return b
+}
+
+func (r *graphqlRequest) appendQuery(p string) (string, error) {
+	q := url.Values{}
+	q.Set(graphqlQueryKey, r.query)
+	if len(r.variables) > 0 {
+		b, err := json.Marshal(r.variables)
+		if err != nil {
+			return "", err
+		}
+		q.Set(graphqlVariablesKey, string(b))
+	}
+	if r.operationName != "" {
+		q.Set(graphqlOperationNameKey, r.operationName)
+	}
+	if strings.Contains(p, "?") {
+		return fmt.Sprintf("%s&%s", p, q.Encode()), nil
+	}
+	return fmt.Sprintf("%s?%s", p, q.Encode()), nil
+}
+
+func newGraphQLValidator(c *httpRunnerConfig) (*graphqlValidator, error) {
+	l := c.GraphQLSchemaLocation
+	if l == "" {
+		return nil, errors.New("cannot load graphql schema")
+	}
+	var b []byte
+	switch {
+	case strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://"):
+		u, err := url.Parse(l)
+		if err != nil {
+			return nil, err
+		}
+		res, err := http.Get(u.String())
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		b, err = io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		var err error
+		b, err = os.ReadFile(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	schema, err := loadGraphQLSchema(l, b)
+	if err != nil {
+		return nil, err
+	}
+	return &graphqlValidator{
+		skipValidateRequest:  c.SkipValidateRequest,
+		skipValidateResponse: c.SkipValidateResponse,
+		key:                  fmt.Sprintf("graphql:%s", filepath.Base(l)),
+		schema:               schema,
+	}, nil
+}
+
+// loadGraphQLSchema loads GraphQL schema from SDL or introspection result (JSON).
+func loadGraphQLSchema(name string, b []byte) (*ast.Schema, error) {
+	hash := hashBytes(b)
+	globalGraphQLSchemaRegistoryMu.RLock()
+	s, ok := globalGraphQLSchemaRegistory[hash]
+	globalGraphQLSchemaRegistoryMu.RUnlock()
+	if ok {
+		return s, nil
+	}
+	sdl := string(b)
+	if strings.HasPrefix(strings.TrimSpace(sdl), "{") {
+		var err error
+		sdl, err = introspectionToSDL(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load graphql introspection result: %s: %w", name, err)
+		}
+	}
+	s, err := gqlparser.LoadSchema(&ast.Source{Name: name, Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graphql schema: %s: %w", name, err)
+	}
+	globalGraphQLSchemaRegistoryMu.Lock()
+	globalGraphQLSchemaRegistory[hash] = s
+	globalGraphQLSchemaRegistoryMu.Unlock()
+	return s, nil
+}
+
+func (v *graphqlValidator) ValidateRequest(ctx context.Context, req *http.Request) error {
+	if v.skipValidateRequest {
+		return nil
+	}
+	gr, err := readGraphQLRequest(req)
+	if err != nil {
+		return err
+	}
+	if gr == nil {
+		// not GraphQL request
+		return nil
+	}
+	if err := v.validateRequest(gr); err != nil {
+		b, errr := httputil.DumpRequest(req, true)
+		if errr != nil {
+			return fmt.Errorf("runn error: %w", errr)
+		}
+		return fmt.Errorf("graphql validation error: %w\n-----START HTTP REQUEST-----\n%s\n-----END HTTP REQUEST-----\n", err, string(b))
+	}
+	return nil
+}
+
+func (v *graphqlValidator) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	if v.skipValidateResponse {
+		return nil
+	}
+	gr, err := readGraphQLRequest(req)
+	if err != nil {
+		return err
+	}
+	if gr == nil {
+		// not GraphQL request
+		return nil
+	}
+	if !strings.Contains(res.Header.Get("Content-Type"), "json") {
+		return &UnsupportedError{Cause: fmt.Errorf("unsupported content type of graphql response: %s", res.Header.Get("Content-Type"))}
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	if err := validateGraphQLResponse(b); err != nil {
+		return fmt.Errorf("graphql validation error: %w\n-----START HTTP RESPONSE-----\n%s\n-----END HTTP RESPONSE-----\n", err, string(b))
+	}
+	return nil
+}
+
+func (v *graphqlValidator) validateRequest(gr *graphqlRequest) error {
+	doc, errs := gqlparser.LoadQuery(v.schema, gr.query)
+	if len(errs) > 0 {
+		return errs
+	}
+	var op *ast.OperationDefinition
+	switch {
+	case gr.operationName != "":
+		op = doc.Operations.ForName(gr.operationName)
+		if op == nil {
+			return fmt.Errorf("operation %q not found", gr.operationName)
+		}
+	case len(doc.Operations) == 1:
+		op = doc.Operations[0]
+	default:
+		return errors.New("operationName is required when the document contains multiple operations")
+	}
+	if _, err := validator.VariableValues(v.schema, op, gr.variables); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readGraphQLRequest reads GraphQL request from *http.Request. It returns nil if the request is not GraphQL request.
+func readGraphQLRequest(req *http.Request) (*graphqlRequest, error) {
+	switch req.Method {
+	case http.MethodGet:
+		q := req.URL.Query()
+		if q.Get(graphqlQueryKey) == "" {
+			return nil, nil
+		}
+		gr := &graphqlRequest{
+			query:         q.Get(graphqlQueryKey),
+			operationName: q.Get(graphqlOperationNameKey),
+		}
+		if vs := q.Get(graphqlVariablesKey); vs != "" {
+			if err := decodeJSONUseNumber([]byte(vs), &gr.variables); err != nil {
+				return nil, fmt.Errorf("invalid graphql variables: %w", err)
+			}
+		}
+		return gr, nil
+	case http.MethodPost:
+		if !strings.Contains(req.Header.Get("Content-Type"), "json") {
+			return nil, nil
+		}
+		b, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		var body struct {
+			Query         *string        `json:"query"`
+			Variables     map[string]any `json:"variables"`
+			OperationName string         `json:"operationName"`
+		}
+		if err := decodeJSONUseNumber(b, &body); err != nil {
+			return nil, nil //nolint:nilerr
+		}
+		if body.Query == nil {
+			return nil, nil
+		}
+		return &graphqlRequest{
+			query:         *body.Query,
+			variables:     body.Variables,
+			operationName: body.OperationName,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// readRequestBody reads the body of the request and makes it re-readable.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	return b, nil
+}
+
+func decodeJSONUseNumber(b []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// validateGraphQLResponse validates that the response body is a well-formed GraphQL response.
+func validateGraphQLResponse(b []byte) error {
+	var body map[string]any
+	if err := json.Unmarshal(b, &body); err != nil {
+		return fmt.Errorf("invalid graphql response: %w", err)
+	}
+	_, hasData := body["data"]
+	errs, hasErrors := body[graphqlErrorsKey]
+	if !hasData && !hasErrors {
+		return errors.New("invalid graphql response: either data or errors is required")
+	}
+	if !hasErrors || errs == nil {
+		return nil
+	}
+	l, ok := errs.([]any)
+	if !ok {
+		return errors.New("invalid graphql response: errors should be a list")
+	}
+	for i, e := range l {
+		m, ok := e.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid graphql response: errors[%d] should be a map", i)
+		}
+		if _, ok := m["message"].(string); !ok {
+			return fmt.Errorf("invalid graphql response: errors[%d].message is required", i)
+		}
+	}
+	return nil
+}
+
+// graphqlOperationFields returns the root fields ( e.g. "Query user" ) of the operation in the GraphQL query.
+func graphqlOperationFields(query, operationName string) ([]string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for _, op := range doc.Operations {
+		if operationName != "" && op.Name != operationName {
+			continue
+		}
+		typ := "Query"
+		switch op.Operation {
+		case ast.Mutation:
+			typ = "Mutation"
+		case ast.Subscription:
+			typ = "Subscription"
+		}
+		for _, f := range collectRootFields(doc, op.SelectionSet) {
+			fields = append(fields, fmt.Sprintf("%s %s", typ, f))
+		}
+	}
+	return fields, nil
+}
+
+func collectRootFields(doc *ast.QueryDocument, set ast.SelectionSet) []string {
+	var fields []string
+	for _, s := range set {
+		switch v := s.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(v.Name, "__") {
+				continue
+			}
+			fields = append(fields, v.Name)
+		case *ast.InlineFragment:
+			fields = append(fields, collectRootFields(doc, v.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if f := doc.Fragments.ForName(v.Name); f != nil {
+				fields = append(fields, collectRootFields(doc, f.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
+
+// graphqlSchemaFields returns all root fields ( e.g. "Query user" ) of the schema.
+func graphqlSchemaFields(s *ast.Schema) []string {
+	var fields []string
+	for _, d := range []struct {
+		typ string
+		def *ast.Definition
+	}{
+		{"Query", s.Query},
+		{"Mutation", s.Mutation},
+		{"Subscription", s.Subscription},
+	} {
+		if d.def == nil {
+			continue
+		}
+		for _, f := range d.def.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			fields = append(fields, fmt.Sprintf("%s %s", d.typ, f.Name))
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+type introspectionSchema struct {
+	QueryType        *introspectionNamedType `json:"queryType"`
+	MutationType     *introspectionNamedType `json:"mutationType"`
+	SubscriptionType *introspectionNamedType `json:"subscriptionType"`
+	Types            []*introspectionType    `json:"types"`
+}
+
+type introspectionNamedType struct {
+	Name string `json:"name"`
+}
+
+type introspectionType struct {
+	Kind          string                     `json:"kind"`
+	Name          string                     `json:"name"`
+	Fields        []*introspectionField      `json:"fields"`
+	InputFields   []*introspectionInputValue `json:"inputFields"`
+	Interfaces    []*introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []*introspectionNamedType  `json:"enumValues"`
+	PossibleTypes []*introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name string                     `json:"name"`
+	Args []*introspectionInputValue `json:"args"`
+	Type *introspectionTypeRef      `json:"type"`
+}
+
+type introspectionInputValue struct {
+	Name         string                `json:"name"`
+	Type         *introspectionTypeRef `json:"type"`
+	DefaultValue *string               `json:"defaultValue"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+var graphqlBuiltinScalars = []string{"String", "Int", "Float", "Boolean", "ID"}
+
+// introspectionToSDL converts the result of introspection query to SDL.
+func introspectionToSDL(b []byte) (string, error) {
+	var res struct {
+		Data *struct {
+			Schema *introspectionSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return "", err
+	}
+	s := res.Schema
+	if res.Data != nil && res.Data.Schema != nil {
+		s = res.Data.Schema
+	}
+	if s == nil {
+		return "", errors.New("__schema not found")
+	}
+	var sb strings.Builder
+	sb.WriteString("schema {\n")
+	if s.QueryType != nil {
+		fmt.Fprintf(&sb, "  query: %s\n", s.QueryType.Name)
+	}
+	if s.MutationType != nil {
+		fmt.Fprintf(&sb, "  mutation: %s\n", s.MutationType.Name)
+	}
+	if s.SubscriptionType != nil {
+		fmt.Fprintf(&sb, "  subscription: %s\n", s.SubscriptionType.Name)
+	}
+	sb.WriteString("}\n")
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || contains(graphqlBuiltinScalars, t.Name) {
+			continue
+		}
+		switch t.Kind {
+		case "SCALAR":
+			fmt.Fprintf(&sb, "scalar %s\n", t.Name)
+		case "OBJECT", "INTERFACE":
+			kw := "type"
+			if t.Kind == "INTERFACE" {
+				kw = "interface"
+			}
+			fmt.Fprintf(&sb, "%s %s", kw, t.Name)
+			if len(t.Interfaces) > 0 {
+				var is []string
+				for _, i := range t.Interfaces {
+					is = append(is, i.Name)
+				}
+				fmt.Fprintf(&sb, " implements %s", strings.Join(is, " & "))
+			}
+			sb.WriteString(" {\n")
+			for _, f := range t.Fields {
+				fmt.Fprintf(&sb, "  %s%s: %s\n", f.Name, introspectionArgs(f.Args), f.Type.String())
+			}
+			sb.WriteString("}\n")
+		case "UNION":
+			var ps []string
+			for _, p := range t.PossibleTypes {
+				ps = append(ps, p.Name)
+			}
+			fmt.Fprintf(&sb, "union %s = %s\n", t.Name, strings.Join(ps, " | "))
+		case "ENUM":
+			fmt.Fprintf(&sb, "enum %s {\n", t.Name)
+			for _, e := range t.EnumValues {
+				fmt.Fprintf(&sb, "  %s\n", e.Name)
+			}
+			sb.WriteString("}\n")
+		case "INPUT_OBJECT":
+			fmt.Fprintf(&sb, "input %s {\n", t.Name)
+			for _, f := range t.InputFields {
+				fmt.Fprintf(&sb, "  %s\n", f.String())
+			}
+			sb.WriteString("}\n")
+		default:
+			return "", fmt.Errorf("unknown kind of type: %s", t.Kind)
+		}
+	}
+	return sb.String(), nil
+}
+
+func introspectionArgs(args []*introspectionInputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var as []string
+	for _, a := range args {
+		as = append(as, a.String())
+	}
+	return fmt.Sprintf("(%s)", strings.Join(as, ", "))
+}
+
+func (v *introspectionInputValue) String() string {
+	if v.DefaultValue != nil {
+		return fmt.Sprintf("%s: %s = %s", v.Name, v.Type.String(), *v.DefaultValue)
+	}
+	return fmt.Sprintf("%s: %s", v.Name, v.Type.String())
+}
+
+func (t *introspectionTypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return fmt.Sprintf("%s!", t.OfType.String())
+	case "LIST":
+		return fmt.Sprintf("[%s]", t.OfType.String())
+	default:
+		return t.Name
+	}
+}
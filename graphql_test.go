@@ -0,0 +1,167 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraphQLValidatorValidateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"valid query", `{"query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":1}}`, false},
+		{"valid mutation", `{"query":"mutation { createUser(input: {name: \"alice\"}) { id } }"}`, false},
+		{"unknown field", `{"query":"{ user(id: 1) { email } }"}`, true},
+		{"missing variable", `{"query":"query GetUser($id: ID!) { user(id: $id) { name } }"}`, true},
+		{"invalid variable", `{"query":"query GetUsers($limit: Int) { users(limit: $limit) { name } }","variables":{"limit":"ten"}}`, true},
+		{"multiple operations without operationName", `{"query":"query A { users { name } } query B { users { id } }"}`, true},
+		{"multiple operations with operationName", `{"query":"query A { users { name } } query B { users { id } }","operationName":"B"}`, false},
+		{"not graphql request", `{"key":"value"}`, false},
+	}
+	v, err := newGraphQLValidator(&httpRunnerConfig{GraphQLSchemaLocation: "testdata/schema.graphql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", MediaTypeApplicationJSON)
+			if err := v.ValidateRequest(context.Background(), req); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestValidateGraphQLResponse(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr bool
+	}{
+		{`{"data":{"user":{"name":"alice"}}}`, false},
+		{`{"data":null,"errors":[{"message":"not found"}]}`, false},
+		{`{"user":{"name":"alice"}}`, true},
+		{`{"errors":"not found"}`, true},
+		{`{"errors":[{"code":404}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			if err := validateGraphQLResponse([]byte(tt.body)); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestLoadGraphQLSchemaFromIntrospection(t *testing.T) {
+	b, err := os.ReadFile("testdata/graphql_introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadGraphQLSchema("graphql_introspection.json", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := graphqlSchemaFields(s)
+	want := []string{"Query user", "Query users"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if s.Types["Role"] == nil || len(s.Types["Role"].EnumValues) != 2 {
+		t.Errorf("invalid enum: %v", s.Types["Role"])
+	}
+}
+
+func TestGraphQLOperationFields(t *testing.T) {
+	tests := []struct {
+		query         string
+		operationName string
+		want          []string
+	}{
+		{`{ users { name } }`, "", []string{"Query users"}},
+		{`query { user(id: 1) { name } users { name } __typename }`, "", []string{"Query user", "Query users"}},
+		{`mutation { createUser(input: {name: "alice"}) { id } }`, "", []string{"Mutation createUser"}},
+		{`query A { users { name } } mutation B { deleteUser(id: 1) }`, "B", []string{"Mutation deleteUser"}},
+		{`query { ...F } fragment F on Query { user(id: 1) { name } }`, "", []string{"Query user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := graphqlOperationFields(tt.query, tt.operationName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGraphQLRunbook(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		gr, err := readGraphQLRequest(r)
+		if err != nil || gr == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(gr.query, "mutation") {
+			_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"permission denied"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"alice"}}}`))
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_GRAPHQL_ENDPOINT", ts.URL)
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/graphql.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("Coverage", func(t *testing.T) {
+		cov, err := o.collectCoverage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &Coverage{
+			Specs: []*SpecCoverage{
+				{
+					Key: "graphql:schema.graphql",
+					Coverages: map[string]int{
+						"Query user":          1,
+						"Query users":         0,
+						"Mutation createUser": 1,
+						"Mutation deleteUser": 0,
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(cov, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
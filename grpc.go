@@ -1,12 +1,15 @@
 package runn
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
 	"slices"
 	"strings"
@@ -71,19 +74,29 @@ type grpcRunner struct {
 	skipVerify      bool
 	importPaths     []string
 	protos          []string
+	protosets       []string
+	protoCacheDir   string
 	bufDirs         []string
 	bufLocks        []string
 	bufConfigs      []string
 	bufModules      []string
-	cc              *grpc.ClientConn
+	cc              grpcClientConn
 	refc            *grpcreflect.Client
 	mds             map[string]protoreflect.MethodDescriptor
 	hostRules       hostRules
+	proxy           *url.URL
 	trace           *bool
 	traceHeaderName string
-	mu              sync.Mutex
+	auth            *authenticator
+	retry           *retrier
+	// protocol - Protocol to call methods ( grpc, grpc-web or connect )
+	protocol string
+	mu       sync.Mutex
 	// operatorID - The id of the operator for which the runner is defined.
 	operatorID string
+	// protoFiles - Descriptors compiled from the proto sources ( shared with the Protobuf bodies of HTTP Runners )
+	protoFiles linker.Files
+	protoMu    sync.Mutex
 }
 
 type grpcMessage struct {
@@ -120,6 +133,24 @@ func (rnr *grpcRunner) Renew() error {
 	return nil
 }
 
+// contextDialerFunc returns Dialer() for grpc.WithContextDialer.
+// If the proxy is set, the connection to the proxy is established according to the host rules.
+func (rnr *grpcRunner) contextDialerFunc() (func(ctx context.Context, address string) (net.Conn, error), error) {
+	dial := rnr.hostRules.contextDialerFunc()
+	if rnr.proxy == nil {
+		return dial, nil
+	}
+	pd, err := proxyDialContextFunc(rnr.proxy, func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dial(ctx, addr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, address string) (net.Conn, error) {
+		return pd(ctx, "tcp", address)
+	}, nil
+}
+
 func (rnr *grpcRunner) Close() error {
 	if rnr.cc == nil {
 		rnr.refc = nil
@@ -143,6 +174,9 @@ func (rnr *grpcRunner) Run(ctx context.Context, s *step) error {
 
 func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	o := s.parent
+	if o.replayer != nil {
+		return rnr.replay(r, s)
+	}
 	if err := rnr.connectAndResolve(ctx, o); err != nil {
 		return err
 	}
@@ -162,7 +196,7 @@ func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	}
 	r.mu.Unlock()
 	rnr.mu.Unlock()
-	if err := r.setTraceHeader(s); err != nil {
+	if err := r.setTraceHeader(ctx, s); err != nil {
 		return err
 	}
 	switch {
@@ -187,57 +221,133 @@ func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	}
 }
 
-func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error {
-	if rnr.cc == nil {
-		opts := []grpc.DialOption{
-			grpc.WithReturnConnectionError(), //nolint:staticcheck
-			grpc.WithUserAgent(fmt.Sprintf("runn/%s", version.Version)),
+// replay records the exchange recorded in the cassette without connecting to the server.
+func (rnr *grpcRunner) replay(r *grpcRequest, s *step) error {
+	o := s.parent
+	var messages []map[string]any
+	for _, m := range r.messages {
+		if m.op != GRPCOpMessage {
+			continue
 		}
-		if len(rnr.hostRules) > 0 {
-			opts = append(opts, grpc.WithContextDialer(rnr.hostRules.contextDialerFunc()))
+		e, err := o.expandBeforeRecord(m.params)
+		if err != nil {
+			return err
 		}
-		useTLS := true
-		if strings.HasSuffix(rnr.target, ":80") {
-			useTLS = false
+		mm, ok := e.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid message: %v", e)
 		}
-		if rnr.tls != nil {
-			useTLS = *rnr.tls
+		messages = append(messages, mm)
+	}
+	g, err := o.replayer.replayGRPC(rnr.name, r.service, r.method, messages)
+	if err != nil {
+		return err
+	}
+	o.capturers.captureGRPCStart(rnr.name, g.Type, r.service, r.method)
+	defer o.capturers.captureGRPCEnd(rnr.name, g.Type, r.service, r.method)
+	o.capturers.captureGRPCRequestHeaders(r.headers)
+	for _, m := range messages {
+		o.capturers.captureGRPCRequestMessage(m)
+	}
+	if g.Status != nil {
+		o.capturers.captureGRPCResponseStatus(status.New(codes.Code(*g.Status), g.StatusMessage)) //nolint:gosec
+	}
+	o.capturers.captureGRPCResponseHeaders(g.Headers)
+	for _, m := range g.Messages {
+		o.capturers.captureGRPCResponseMessage(m)
+	}
+	o.capturers.captureGRPCResponseTrailers(g.Trailers)
+
+	o.record(map[string]any{
+		string(grpcStoreResponseKey): g.toStoreValue(),
+	})
+	return nil
+}
+
+// tlsConfig returns the TLS settings of the connection. It returns nil if TLS is not used.
+func (rnr *grpcRunner) tlsConfig() (*tls.Config, error) {
+	useTLS := true
+	if strings.HasSuffix(rnr.target, ":80") {
+		useTLS = false
+	}
+	if rnr.tls != nil {
+		useTLS = *rnr.tls
+	}
+	if !useTLS {
+		return nil, nil
+	}
+	tlsc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(rnr.cert) != 0 {
+		certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+		if err != nil {
+			return nil, err
 		}
-		if useTLS {
-			tlsc := tls.Config{MinVersion: tls.VersionTLS12}
-			if len(rnr.cert) != 0 {
-				certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
-				if err != nil {
-					return err
-				}
-				tlsc.Certificates = []tls.Certificate{certificate}
-			}
-			if rnr.skipVerify {
-				//#nosec G402
-				tlsc.InsecureSkipVerify = true
-			} else if len(rnr.cacert) != 0 {
-				certpool, err := x509.SystemCertPool()
-				if err != nil {
-					// FIXME for Windows
-					// ref: https://github.com/golang/go/issues/18609
-					certpool = x509.NewCertPool()
-				}
-				if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
-					return errors.New("failed to append cacert")
-				}
-				tlsc.RootCAs = certpool
-			}
-			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tlsc)))
-		} else {
-			opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		tlsc.Certificates = []tls.Certificate{certificate}
+	}
+	if rnr.skipVerify {
+		//#nosec G402
+		tlsc.InsecureSkipVerify = true
+	} else if len(rnr.cacert) != 0 {
+		certpool, err := x509.SystemCertPool()
+		if err != nil {
+			// FIXME for Windows
+			// ref: https://github.com/golang/go/issues/18609
+			certpool = x509.NewCertPool()
 		}
-		cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		defer cancel()
-		cc, err := grpc.DialContext(cctx, rnr.target, opts...) //nolint:staticcheck
+		if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
+			return nil, errors.New("failed to append cacert")
+		}
+		tlsc.RootCAs = certpool
+	}
+	return tlsc, nil
+}
+
+// dial connects to the server using the gRPC protocol.
+func (rnr *grpcRunner) dial(ctx context.Context, tlsc *tls.Config) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
+		grpc.WithReturnConnectionError(), //nolint:staticcheck
+		grpc.WithUserAgent(fmt.Sprintf("runn/%s", version.Version)),
+	}
+	if len(rnr.hostRules) > 0 || rnr.proxy != nil {
+		dialer, err := rnr.contextDialerFunc()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithContextDialer(dialer))
+	}
+	if rnr.auth != nil {
+		opts = append(opts, grpc.WithUnaryInterceptor(rnr.auth.unaryClientInterceptor()), grpc.WithStreamInterceptor(rnr.auth.streamClientInterceptor()))
+	}
+	if tlsc != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsc)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return grpc.DialContext(cctx, rnr.target, opts...) //nolint:staticcheck
+}
+
+func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error {
+	if rnr.cc == nil {
+		tlsc, err := rnr.tlsConfig()
 		if err != nil {
 			return err
 		}
-		rnr.cc = cc
+		switch rnr.protocol {
+		case grpcProtocolGRPCWeb, grpcProtocolConnect:
+			cc, err := rnr.newConnectClientConn(tlsc)
+			if err != nil {
+				return err
+			}
+			rnr.cc = cc
+		default:
+			cc, err := rnr.dial(ctx, tlsc)
+			if err != nil {
+				return err
+			}
+			rnr.cc = cc
+		}
 		if rnr.target != "" {
 			if err := donegroup.Cleanup(ctx, func() error {
 				// In the case of Reused runners, leave the cleanup to the main cleanup
@@ -250,7 +360,7 @@ func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error
 			}
 		}
 	}
-	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0 {
+	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.protosets) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0 {
 		if err := rnr.resolveAllMethodsUsingProtos(ctx); err != nil {
 			return err
 		}
@@ -283,16 +393,47 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 
 	o.capturers.captureGRPCRequestHeaders(r.headers)
 
-	if err := rnr.setMessage(req, r.messages[0].params, s); err != nil {
+	m, err := rnr.setMessage(req, r.messages[0].params, s)
+	if err != nil {
 		return err
 	}
 
-	var (
-		resHeaders  metadata.MD
-		resTrailers metadata.MD
-	)
-	res := dynamicpb.NewMessage(md.Output())
-	err := rnr.cc.Invoke(ctx, toEndpoint(md.FullName()), req, res, grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))
+	invoke := func(ctx context.Context) (*dynamicpb.Message, metadata.MD, metadata.MD, error) {
+		var (
+			resHeaders  metadata.MD
+			resTrailers metadata.MD
+		)
+		res := dynamicpb.NewMessage(md.Output())
+		err := rnr.cc.Invoke(ctx, toEndpoint(md.FullName()), req, res, grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))
+		return res, resHeaders, resTrailers, err
+	}
+	res, resHeaders, resTrailers, err := invoke(ctx)
+	if rnr.retry != nil {
+		// Restore the trails of the step changed by the retries
+		defer o.capturers.setCurrentTrails(s.trails())
+		ra := rnr.retry.start(ctx)
+		defer ra.stop()
+		for rnr.retry.retryableCode(status.Code(err)) && ra.remaining() {
+			stat := status.Convert(err)
+			o.capturers.captureGRPCResponseStatus(stat)
+			o.capturers.captureGRPCResponseHeaders(resHeaders)
+			o.capturers.captureGRPCResponseTrailers(resTrailers)
+			if err := ra.wait(ctx, parseGRPCRetryPushback(resTrailers)); err != nil {
+				return err
+			}
+			o.capturers.discardAttempt(rnr.name)
+			trs := retryTrails(s, ra.index())
+			o.capturers.setCurrentTrails(trs)
+			o.Debugf("Retry the call due to the status code %s: %s\n", stat.Code(), trs[len(trs)-1])
+			o.capturers.captureGRPCRequestHeaders(r.headers)
+			o.capturers.captureGRPCRequestMessage(m)
+			sw := o.sw.Start(trs.toProfileIDs()...)
+			_, span := o.startSpan(ctx, trs[len(trs)-1])
+			res, resHeaders, resTrailers, err = invoke(ctx)
+			endSpan(span, err)
+			sw.Stop()
+		}
+	}
 	stat, ok := status.FromError(err)
 	if !ok {
 		return err
@@ -351,7 +492,8 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 
 	o.capturers.captureGRPCRequestHeaders(r.headers)
 
-	if err := rnr.setMessage(req, r.messages[0].params, s); err != nil {
+	m, err := rnr.setMessage(req, r.messages[0].params, s)
+	if err != nil {
 		return err
 	}
 
@@ -361,11 +503,49 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		ClientStreams: md.IsStreamingClient(),
 	}
 
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
-	if err != nil {
-		return err
+	// open opens the stream, sends the message and receives the first message.
+	open := func(ctx context.Context) (grpc.ClientStream, *dynamicpb.Message, error) {
+		stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := stream.SendMsg(req); err != nil {
+			return nil, nil, err
+		}
+		res := dynamicpb.NewMessage(md.Output())
+		return stream, res, stream.RecvMsg(res)
+	}
+	stream, res, err := open(ctx)
+	if rnr.retry != nil {
+		// The call is retried only until the first message arrives, as the received messages cannot be undone
+		defer o.capturers.setCurrentTrails(s.trails())
+		ra := rnr.retry.start(ctx)
+		defer ra.stop()
+		for stream != nil && rnr.retry.retryableCode(status.Code(err)) && ra.remaining() {
+			stat := status.Convert(err)
+			o.capturers.captureGRPCResponseStatus(stat)
+			if h, err := stream.Header(); err == nil {
+				o.capturers.captureGRPCResponseHeaders(h)
+			}
+			resTrailers := stream.Trailer()
+			o.capturers.captureGRPCResponseTrailers(resTrailers)
+			if err := ra.wait(ctx, parseGRPCRetryPushback(resTrailers)); err != nil {
+				return err
+			}
+			o.capturers.discardAttempt(rnr.name)
+			trs := retryTrails(s, ra.index())
+			o.capturers.setCurrentTrails(trs)
+			o.Debugf("Retry the call due to the status code %s: %s\n", stat.Code(), trs[len(trs)-1])
+			o.capturers.captureGRPCRequestHeaders(r.headers)
+			o.capturers.captureGRPCRequestMessage(m)
+			sw := o.sw.Start(trs.toProfileIDs()...)
+			_, span := o.startSpan(ctx, trs[len(trs)-1])
+			stream, res, err = open(ctx)
+			endSpan(span, err)
+			sw.Stop()
+		}
 	}
-	if err := stream.SendMsg(req); err != nil {
+	if stream == nil {
 		return err
 	}
 
@@ -376,9 +556,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 	}
 	var messages []map[string]any
 
-	for err == nil {
-		res := dynamicpb.NewMessage(md.Output())
-		err = stream.RecvMsg(res)
+	for {
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				break
@@ -412,6 +590,11 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		} else {
 			d[grpcStoreMessageKey] = stat.Message()
 		}
+		if err != nil {
+			break
+		}
+		res = dynamicpb.NewMessage(md.Output())
+		err = stream.RecvMsg(res)
 	}
 	d[grpcStoreMessagesKey] = messages
 	if h, err := stream.Header(); err == nil {
@@ -463,7 +646,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		case GRPCOpMessage:
 			req := dynamicpb.NewMessage(md.Input())
 
-			if err := rnr.setMessage(req, m.params, s); err != nil {
+			if _, err := rnr.setMessage(req, m.params, s); err != nil {
 				return err
 			}
 
@@ -560,7 +743,7 @@ L:
 		switch m.op {
 		case GRPCOpMessage:
 			req := dynamicpb.NewMessage(md.Input())
-			if err := rnr.setMessage(req, m.params, s); err != nil {
+			if _, err := rnr.setMessage(req, m.params, s); err != nil {
 				return err
 			}
 			err = stream.SendMsg(req)
@@ -724,23 +907,24 @@ func setHeaders(ctx context.Context, h metadata.MD) context.Context {
 	return ctx
 }
 
-func (rnr *grpcRunner) setMessage(req proto.Message, message map[string]any, s *step) error {
+// setMessage sets the expanded message to req and returns the expanded message.
+func (rnr *grpcRunner) setMessage(req proto.Message, message map[string]any, s *step) (map[string]any, error) {
 	o := s.parent
 	// Lazy expand due to the possibility of computing variables between multiple messages.
 	e, err := o.expandBeforeRecord(message)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	m, ok := e.(map[string]any)
 	if !ok {
-		return fmt.Errorf("invalid message: %v", e)
+		return nil, fmt.Errorf("invalid message: %v", e)
 	}
 	o.capturers.captureGRPCRequestMessage(m)
 	b, err := json.Marshal(e)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return protojson.Unmarshal(b, req)
+	return m, protojson.Unmarshal(b, req)
 }
 
 func (rnr *grpcRunner) resolveAllMethodsUsingReflection(ctx context.Context) error {
@@ -786,28 +970,75 @@ func (rnr *grpcRunner) findDescripter(svc protoreflect.FullName) (protoreflect.D
 }
 
 func (rnr *grpcRunner) resolveAllMethodsUsingProtos(ctx context.Context) error {
-	protos, err := fetchPaths(strings.Join(rnr.protos, string(os.PathListSeparator)))
+	fds, err := rnr.compileProtoSources(ctx)
 	if err != nil {
 		return err
 	}
-	pr, err := protoresolv.New(rnr.importPaths, protoresolv.Proto(protos...))
+	for _, fd := range fds {
+		for i := 0; i < fd.Services().Len(); i++ {
+			svc := fd.Services().Get(i)
+			for j := 0; j < svc.Methods().Len(); j++ {
+				m := svc.Methods().Get(j)
+				key := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
+				rnr.mds[key] = m
+			}
+		}
+	}
+	return nil
+}
+
+// compileProtoSources returns the descriptors of the protosets and the proto sources of the runner.
+// The descriptors are compiled once per runner.
+func (rnr *grpcRunner) compileProtoSources(ctx context.Context) (linker.Files, error) {
+	rnr.protoMu.Lock()
+	defer rnr.protoMu.Unlock()
+	if rnr.protoFiles != nil {
+		return rnr.protoFiles, nil
+	}
+	var fds linker.Files
+	if len(rnr.protosets) > 0 {
+		files, err := loadProtosets(rnr.protosets)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, files...)
+	}
+	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0 {
+		files, err := compileProtos(ctx, rnr.protoCacheDir, rnr.importPaths, rnr.protos, rnr.bufDirs, rnr.bufLocks, rnr.bufConfigs, rnr.bufModules)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, files...)
+	}
+	rnr.protoFiles = fds
+	return fds, nil
+}
+
+// compileProtos compiles the proto files and the buf modules, and registers them to protoregistry.GlobalFiles.
+// If cacheDir is specified, the compiled descriptors are cached on disk, so repeated runs skip compiling while the sources are unchanged.
+func compileProtos(ctx context.Context, cacheDir string, importPaths, protoPaths, bufDirs, bufLocks, bufConfigs, bufModules []string) (linker.Files, error) {
+	protos, err := fetchPaths(strings.Join(protoPaths, string(os.PathListSeparator)))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pr, err := protoresolv.New(importPaths, protoresolv.Proto(protos...))
+	if err != nil {
+		return nil, err
 	}
 	var bufresolvOpts []bufresolv.Option
-	for _, d := range rnr.bufDirs {
+	for _, d := range bufDirs {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufDir(d))
 	}
-	for _, c := range rnr.bufConfigs {
+	for _, c := range bufConfigs {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufConfig(c))
 	}
-	for _, l := range rnr.bufLocks {
+	for _, l := range bufLocks {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufLock(l))
 	}
-	bufresolvOpts = append(bufresolvOpts, bufresolv.BufModule(rnr.bufModules...))
+	bufresolvOpts = append(bufresolvOpts, bufresolv.BufModule(bufModules...))
 	br, err := bufresolv.New(bufresolvOpts...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	comp := protocompile.Compiler{
 		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver([]protocompile.Resolver{
@@ -815,27 +1046,53 @@ func (rnr *grpcRunner) resolveAllMethodsUsingProtos(ctx context.Context) error {
 		})),
 	}
 	protos = unique(slices.Concat(pr.Paths(), br.Paths()))
-	fds, err := comp.Compile(ctx, protos...)
+	// Reuse the compiled descriptors while the sources are unchanged ( e.g. re-runs of `runn run --watch` ).
+	key := fmt.Sprintf("%q", [][]string{importPaths, protoPaths, bufDirs, bufLocks, bufConfigs, bufModules})
+	hash, err := hashProtoSources(comp.Resolver, protos)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := registerFiles(fds); err != nil {
-		return err
+	globalProtoDescriptorRegistoryMu.Lock()
+	defer globalProtoDescriptorRegistoryMu.Unlock()
+	prev, ok := globalProtoDescriptorRegistory[key]
+	if ok && prev.hash == hash {
+		return prev.fds, nil
+	}
+	// Reuse the descriptors compiled in the previous runs.
+	var fds linker.Files
+	if cacheDir != "" {
+		if cached, err := readProtoDescriptorCache(cacheDir, hash, protos); err == nil {
+			fds = cached
+		}
 	}
-	for _, fd := range fds {
-		for i := 0; i < fd.Services().Len(); i++ {
-			svc := fd.Services().Get(i)
-			for j := 0; j < svc.Methods().Len(); j++ {
-				m := svc.Methods().Get(j)
-				key := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
-				rnr.mds[key] = m
-			}
+	if fds == nil {
+		fds, err = comp.Compile(ctx, protos...)
+		if err != nil {
+			return nil, err
+		}
+		if cacheDir != "" {
+			// The cache is an optimization, so failures of writing it are ignored.
+			_ = writeProtoDescriptorCache(cacheDir, hash, fds)
 		}
 	}
-	return nil
+	if ok {
+		// The sources have been changed, so replace the descriptors registered from the previous sources.
+		if err := replaceFiles(prev.fds, fds); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := registerFiles(fds); err != nil {
+			return nil, err
+		}
+	}
+	// Keep only the latest descriptors of the sources.
+	globalProtoDescriptorRegistory[key] = &protoDescriptors{hash: hash, fds: fds}
+	return fds, nil
 }
 
-func (r *grpcRequest) setTraceHeader(s *step) error {
+func (r *grpcRequest) setTraceHeader(ctx context.Context, s *step) error {
+	// Propagate the span of the step
+	injectTraceContextToMetadata(ctx, r.headers)
 	if r.trace == nil || !*r.trace {
 		return nil
 	}
@@ -867,16 +1124,53 @@ func toEndpoint(mn protoreflect.FullName) string {
 	return fmt.Sprintf("/%s/%s", service, method)
 }
 
+// globalProtoDescriptorRegistory - global registory of the latest compiled proto descriptors per set of sources.
+var globalProtoDescriptorRegistory = map[string]*protoDescriptors{}
+var globalProtoDescriptorRegistoryMu sync.Mutex
+
+type protoDescriptors struct {
+	// hash - Hash of the proto sources that the descriptors are compiled from.
+	hash string
+	fds  linker.Files
+}
+
+// hashProtoSources returns the hash of the proto sources to be compiled.
+func hashProtoSources(r protocompile.Resolver, protos []string) (string, error) {
+	sorted := slices.Clone(protos)
+	slices.Sort(sorted)
+	var b bytes.Buffer
+	for _, p := range sorted {
+		_, _ = b.WriteString(p)
+		_ = b.WriteByte(0)
+		res, err := r.FindFileByPath(p)
+		if err != nil {
+			// Let the compiler report the error.
+			continue
+		}
+		if res.Source != nil {
+			if _, err := io.Copy(&b, res.Source); err != nil {
+				return "", err
+			}
+		}
+		_ = b.WriteByte(0)
+	}
+	return hashBytes(b.Bytes()), nil
+}
+
 func registerFiles(fds linker.Files) (err error) {
+	return registerFilesTo(protoregistry.GlobalFiles, fds)
+}
+
+func registerFilesTo(files *protoregistry.Files, fds linker.Files) (err error) {
 	for _, fd := range fds {
 		// Skip registration of already registered descriptors
-		if _, err := protoregistry.GlobalFiles.FindFileByPath(fd.Path()); !errors.Is(protoregistry.NotFound, err) {
+		if _, err := files.FindFileByPath(fd.Path()); !errors.Is(protoregistry.NotFound, err) {
 			continue
 		}
 		// Skip registration of conflicted descriptors
 		conflict := false
 		rangeTopLevelDescriptors(fd, func(d protoreflect.Descriptor) {
-			if _, err := protoregistry.GlobalFiles.FindDescriptorByName(d.FullName()); err == nil {
+			if _, err := files.FindDescriptorByName(d.FullName()); err == nil {
 				conflict = true
 			}
 		})
@@ -884,13 +1178,35 @@ func registerFiles(fds linker.Files) (err error) {
 			continue
 		}
 
-		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		if err := files.RegisterFile(fd); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// replaceFiles replaces the descriptors registered from prev in protoregistry.GlobalFiles with fds.
+// protoregistry.Files cannot unregister descriptors, so protoregistry.GlobalFiles is replaced with a new registry.
+func replaceFiles(prev, fds linker.Files) error {
+	files := new(protoregistry.Files)
+	var err error
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if pf := prev.FindFileByPath(fd.Path()); pf != nil && protoreflect.FileDescriptor(pf) == fd {
+			return true
+		}
+		err = files.RegisterFile(fd)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := registerFilesTo(files, fds); err != nil {
+		return err
+	}
+	protoregistry.GlobalFiles = files
+	return nil
+}
+
 // copy from google.golang.org/protobuf/reflect/protoregistry.
 func rangeTopLevelDescriptors(fd protoreflect.FileDescriptor, f func(protoreflect.Descriptor)) {
 	eds := fd.Enums()
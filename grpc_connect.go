@@ -0,0 +1,462 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"github.com/k1LoW/runn/version"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcProtocolGRPC    = "grpc"
+	grpcProtocolGRPCWeb = "grpc-web"
+	grpcProtocolConnect = "connect"
+)
+
+// grpcClientConn - Connection to call methods of the server.
+type grpcClientConn interface {
+	grpc.ClientConnInterface
+	Close() error
+}
+
+var _ grpcClientConn = (*grpc.ClientConn)(nil)
+var _ grpcClientConn = (*connectClientConn)(nil)
+
+func parseGRPCProtocol(p string) (string, error) {
+	switch p {
+	case "", grpcProtocolGRPC:
+		return grpcProtocolGRPC, nil
+	case grpcProtocolGRPCWeb, grpcProtocolConnect:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid gRPC protocol: %s", p)
+	}
+}
+
+// connectClientConn - grpc.ClientConnInterface that calls methods using the gRPC-Web or the Connect protocol.
+type connectClientConn struct {
+	// ctx - Context canceled on Close to abort the active streams like grpc.ClientConn
+	ctx     context.Context
+	cancel  context.CancelFunc
+	baseURL string
+	client  *http.Client
+	// bidiClient - Client for bidirectional streaming that requires HTTP/2 ( h2c if TLS is not used )
+	bidiClient        *http.Client
+	opts              []connect.ClientOption
+	unaryInterceptor  grpc.UnaryClientInterceptor
+	streamInterceptor grpc.StreamClientInterceptor
+}
+
+// connectMessage - Message of connect.Client that wraps the message of the method ( e.g. *dynamicpb.Message ).
+type connectMessage struct {
+	proto.Message
+}
+
+func (rnr *grpcRunner) newConnectClientConn(tlsc *tls.Config) (*connectClientConn, error) {
+	dial, err := rnr.contextDialerFunc()
+	if err != nil {
+		return nil, err
+	}
+	dialContext := func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dial(ctx, addr)
+	}
+	ts := &http.Transport{
+		DialContext:       dialContext,
+		TLSClientConfig:   tlsc,
+		ForceAttemptHTTP2: true,
+	}
+	client := &http.Client{Transport: ts}
+	bidiClient := client
+	scheme := "https"
+	if tlsc == nil {
+		scheme = "http"
+		bidiClient = &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialContext(ctx, network, addr)
+			},
+		}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := &connectClientConn{
+		ctx:        ctx,
+		cancel:     cancel,
+		baseURL:    fmt.Sprintf("%s://%s", scheme, rnr.target),
+		client:     client,
+		bidiClient: bidiClient,
+	}
+	if rnr.protocol == grpcProtocolGRPCWeb {
+		cc.opts = append(cc.opts, connect.WithGRPCWeb())
+	}
+	if rnr.auth != nil {
+		cc.unaryInterceptor = rnr.auth.unaryClientInterceptor()
+		cc.streamInterceptor = rnr.auth.streamClientInterceptor()
+	}
+	return cc, nil
+}
+
+// newClient returns the client of the method. The messages are received into the message returned by recv.
+func (cc *connectClientConn) newClient(hc *http.Client, method string, recv func() proto.Message) *connect.Client[connectMessage, connectMessage] {
+	opts := slices.Concat(cc.opts, []connect.ClientOption{connect.WithResponseInitializer(func(_ connect.Spec, msg any) error {
+		m, ok := msg.(*connectMessage)
+		if !ok {
+			return fmt.Errorf("invalid message: %T", msg)
+		}
+		m.Message = recv()
+		return nil
+	})})
+	return connect.NewClient[connectMessage, connectMessage](hc, cc.baseURL+method, opts...)
+}
+
+func (cc *connectClientConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	if cc.unaryInterceptor != nil {
+		return cc.unaryInterceptor(ctx, method, args, reply, nil, cc.invoke, opts...)
+	}
+	return cc.invoke(ctx, method, args, reply, nil, opts...)
+}
+
+func (cc *connectClientConn) invoke(ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+	req, ok := args.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid request message: %T", args)
+	}
+	res, ok := reply.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid response message: %T", reply)
+	}
+	client := cc.newClient(cc.client, method, func() proto.Message {
+		return res
+	})
+	creq := connect.NewRequest(&connectMessage{req})
+	setConnectHeaders(ctx, creq.Header())
+	cres, err := client.CallUnary(ctx, creq)
+	if err != nil {
+		var cerr *connect.Error
+		if errors.As(err, &cerr) {
+			setCallOptions(opts, cerr.Meta(), cerr.Meta())
+		}
+		return connectErrorToStatus(err)
+	}
+	setCallOptions(opts, cres.Header(), cres.Trailer())
+	return nil
+}
+
+func (cc *connectClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if cc.streamInterceptor != nil {
+		return cc.streamInterceptor(ctx, desc, nil, method, cc.newStream, opts...)
+	}
+	return cc.newStream(ctx, desc, nil, method, opts...)
+}
+
+func (cc *connectClientConn) newStream(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var recv proto.Message
+	recvFn := func() proto.Message {
+		return recv
+	}
+	setRecv := func(m proto.Message) {
+		recv = m
+	}
+	switch {
+	case desc.ServerStreams && !desc.ClientStreams:
+		context.AfterFunc(cc.ctx, cancel)
+		return &connectServerStream{
+			ctx:     ctx,
+			client:  cc.newClient(cc.client, method, recvFn),
+			setRecv: setRecv,
+		}, nil
+	case !desc.ServerStreams && desc.ClientStreams:
+		cs := &connectClientStreamingStream{
+			ctx:     ctx,
+			stream:  cc.newClient(cc.client, method, recvFn).CallClientStream(ctx),
+			setRecv: setRecv,
+		}
+		setConnectHeaders(ctx, cs.stream.RequestHeader())
+		// Canceling the context does not interrupt sending the request body, so also close the request
+		context.AfterFunc(cc.ctx, func() {
+			cancel()
+			cs.closeAndReceive()
+		})
+		return cs, nil
+	default:
+		cs := &connectBidiStream{
+			ctx:     ctx,
+			stream:  cc.newClient(cc.bidiClient, method, recvFn).CallBidiStream(ctx),
+			setRecv: setRecv,
+		}
+		setConnectHeaders(ctx, cs.stream.RequestHeader())
+		// Canceling the context does not interrupt sending the request body, so also close the request
+		context.AfterFunc(cc.ctx, func() {
+			cancel()
+			_ = cs.stream.CloseRequest()
+		})
+		return cs, nil
+	}
+}
+
+func (cc *connectClientConn) Close() error {
+	cc.cancel()
+	cc.client.CloseIdleConnections()
+	cc.bidiClient.CloseIdleConnections()
+	return nil
+}
+
+// connectServerStream - grpc.ClientStream of the server streaming RPC using connect.ServerStreamForClient.
+type connectServerStream struct {
+	ctx     context.Context
+	client  *connect.Client[connectMessage, connectMessage]
+	stream  *connect.ServerStreamForClient[connectMessage]
+	setRecv func(proto.Message)
+}
+
+func (cs *connectServerStream) Header() (metadata.MD, error) {
+	if cs.stream == nil {
+		return nil, errors.New("the request has not been sent")
+	}
+	return headerToMD(cs.stream.ResponseHeader()), nil
+}
+
+func (cs *connectServerStream) Trailer() metadata.MD {
+	if cs.stream == nil {
+		return metadata.MD{}
+	}
+	return headerToMD(cs.stream.ResponseTrailer())
+}
+
+func (cs *connectServerStream) CloseSend() error {
+	return nil
+}
+
+func (cs *connectServerStream) Context() context.Context {
+	return cs.ctx
+}
+
+func (cs *connectServerStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid request message: %T", m)
+	}
+	if cs.stream != nil {
+		return errors.New("the request has already been sent")
+	}
+	req := connect.NewRequest(&connectMessage{msg})
+	setConnectHeaders(cs.ctx, req.Header())
+	stream, err := cs.client.CallServerStream(cs.ctx, req)
+	if err != nil {
+		return connectErrorToStatus(err)
+	}
+	cs.stream = stream
+	return nil
+}
+
+func (cs *connectServerStream) RecvMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid response message: %T", m)
+	}
+	if cs.stream == nil {
+		return errors.New("the request has not been sent")
+	}
+	cs.setRecv(msg)
+	if cs.stream.Receive() {
+		return nil
+	}
+	if err := cs.stream.Err(); err != nil {
+		return connectErrorToStatus(err)
+	}
+	return io.EOF
+}
+
+// connectClientStreamingStream - grpc.ClientStream of the client streaming RPC using connect.ClientStreamForClient.
+type connectClientStreamingStream struct {
+	ctx     context.Context
+	stream  *connect.ClientStreamForClient[connectMessage, connectMessage]
+	setRecv func(proto.Message)
+	once    sync.Once
+	res     *connect.Response[connectMessage]
+	err     error
+}
+
+// closeAndReceive closes the request and receives the response only once.
+func (cs *connectClientStreamingStream) closeAndReceive() {
+	cs.once.Do(func() {
+		cs.res, cs.err = cs.stream.CloseAndReceive()
+	})
+}
+
+func (cs *connectClientStreamingStream) Header() (metadata.MD, error) {
+	cs.closeAndReceive()
+	header, _ := cs.metadata()
+	return header, nil
+}
+
+func (cs *connectClientStreamingStream) Trailer() metadata.MD {
+	cs.closeAndReceive()
+	_, trailer := cs.metadata()
+	return trailer
+}
+
+func (cs *connectClientStreamingStream) metadata() (metadata.MD, metadata.MD) {
+	if cs.res != nil {
+		return headerToMD(cs.res.Header()), headerToMD(cs.res.Trailer())
+	}
+	var cerr *connect.Error
+	if errors.As(cs.err, &cerr) {
+		return headerToMD(cerr.Meta()), headerToMD(cerr.Meta())
+	}
+	return metadata.MD{}, metadata.MD{}
+}
+
+func (cs *connectClientStreamingStream) CloseSend() error {
+	// The request is closed when receiving the response
+	return nil
+}
+
+func (cs *connectClientStreamingStream) Context() context.Context {
+	return cs.ctx
+}
+
+func (cs *connectClientStreamingStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid request message: %T", m)
+	}
+	if err := cs.stream.Send(&connectMessage{msg}); err != nil {
+		return connectErrorToStatus(err)
+	}
+	return nil
+}
+
+func (cs *connectClientStreamingStream) RecvMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid response message: %T", m)
+	}
+	cs.setRecv(msg)
+	cs.closeAndReceive()
+	if cs.err != nil {
+		return connectErrorToStatus(cs.err)
+	}
+	return nil
+}
+
+// connectBidiStream - grpc.ClientStream of the bidirectional streaming RPC using connect.BidiStreamForClient.
+type connectBidiStream struct {
+	ctx     context.Context
+	stream  *connect.BidiStreamForClient[connectMessage, connectMessage]
+	setRecv func(proto.Message)
+}
+
+func (cs *connectBidiStream) Header() (metadata.MD, error) {
+	return headerToMD(cs.stream.ResponseHeader()), nil
+}
+
+func (cs *connectBidiStream) Trailer() metadata.MD {
+	return headerToMD(cs.stream.ResponseTrailer())
+}
+
+func (cs *connectBidiStream) CloseSend() error {
+	return cs.stream.CloseRequest()
+}
+
+func (cs *connectBidiStream) Context() context.Context {
+	return cs.ctx
+}
+
+func (cs *connectBidiStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid request message: %T", m)
+	}
+	if err := cs.stream.Send(&connectMessage{msg}); err != nil {
+		return connectErrorToStatus(err)
+	}
+	return nil
+}
+
+func (cs *connectBidiStream) RecvMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid response message: %T", m)
+	}
+	cs.setRecv(msg)
+	if _, err := cs.stream.Receive(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return connectErrorToStatus(err)
+	}
+	return nil
+}
+
+// setConnectHeaders sets the outgoing metadata of the context to the request headers.
+func setConnectHeaders(ctx context.Context, h http.Header) {
+	h.Set("User-Agent", fmt.Sprintf("runn/%s", version.Version))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return
+	}
+	for k, vs := range md {
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				v = connect.EncodeBinaryHeader([]byte(v))
+			}
+			h.Add(k, v)
+		}
+	}
+}
+
+// setCallOptions sets the response headers and trailers to grpc.Header() and grpc.Trailer().
+func setCallOptions(opts []grpc.CallOption, header, trailer http.Header) {
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = headerToMD(header)
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = headerToMD(trailer)
+		}
+	}
+}
+
+func headerToMD(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, vs := range h {
+		k = strings.ToLower(k)
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				if b, err := connect.DecodeBinaryHeader(v); err == nil {
+					v = string(b)
+				}
+			}
+			md.Append(k, v)
+		}
+	}
+	return md
+}
+
+// connectErrorToStatus converts the error of the Connect client into the error of the gRPC status.
+func connectErrorToStatus(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	var cerr *connect.Error
+	if !errors.As(err, &cerr) {
+		return err
+	}
+	return status.Error(codes.Code(cerr.Code()), cerr.Message()) //nolint:gosec
+}
@@ -0,0 +1,127 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc/interop/grpc_testing"
+)
+
+func TestGRPCRunnerProtocols(t *testing.T) {
+	for _, useTLS := range []bool{true, false} {
+		ts := connectTestServer(t, useTLS)
+		for _, protocol := range []string{grpcProtocolGRPC, grpcProtocolGRPCWeb, grpcProtocolConnect} {
+			t.Run(fmt.Sprintf("%s tls:%v", protocol, useTLS), func(t *testing.T) {
+				t.Setenv("TEST_GRPC_ADDR", ts.Listener.Addr().String())
+				t.Setenv("TEST_GRPC_PROTOCOL", protocol)
+				t.Setenv("TEST_GRPC_TLS", strconv.FormatBool(useTLS))
+				o, err := New(Book("testdata/grpc_connect.yml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := o.Run(context.Background()); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func TestParseGRPCProtocol(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", grpcProtocolGRPC, false},
+		{"grpc", grpcProtocolGRPC, false},
+		{"grpc-web", grpcProtocolGRPCWeb, false},
+		{"connect", grpcProtocolConnect, false},
+		{"http", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseGRPCProtocol(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// connectTestServer returns the server of grpc.testing.TestService that supports gRPC, gRPC-Web and Connect protocols.
+// If useTLS is false, the server serves HTTP/2 without TLS ( h2c ).
+func connectTestServer(t *testing.T, useTLS bool) *httptest.Server {
+	t.Helper()
+	const svc = "/grpc.testing.TestService/"
+	mux := http.NewServeMux()
+	mux.Handle(svc+"UnaryCall", connect.NewUnaryHandler(svc+"UnaryCall", func(ctx context.Context, req *connect.Request[grpc_testing.SimpleRequest]) (*connect.Response[grpc_testing.SimpleResponse], error) {
+		if s := req.Msg.GetResponseStatus(); s != nil {
+			return nil, connect.NewError(connect.Code(s.GetCode()), errors.New(s.GetMessage()))
+		}
+		res := connect.NewResponse(&grpc_testing.SimpleResponse{
+			Payload:  req.Msg.GetPayload(),
+			Username: req.Header().Get("x-user"),
+		})
+		res.Header().Set("x-echo", "unary")
+		res.Trailer().Set("x-echo-trailer", "unary")
+		return res, nil
+	}))
+	mux.Handle(svc+"StreamingOutputCall", connect.NewServerStreamHandler(svc+"StreamingOutputCall", func(ctx context.Context, req *connect.Request[grpc_testing.StreamingOutputCallRequest], stream *connect.ServerStream[grpc_testing.StreamingOutputCallResponse]) error {
+		for range req.Msg.GetResponseParameters() {
+			if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{Payload: req.Msg.GetPayload()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	mux.Handle(svc+"StreamingInputCall", connect.NewClientStreamHandler(svc+"StreamingInputCall", func(ctx context.Context, stream *connect.ClientStream[grpc_testing.StreamingInputCallRequest]) (*connect.Response[grpc_testing.StreamingInputCallResponse], error) {
+		var size int32
+		for stream.Receive() {
+			size += int32(len(stream.Msg().GetPayload().GetBody())) //nolint:gosec
+		}
+		if err := stream.Err(); err != nil {
+			return nil, err
+		}
+		return connect.NewResponse(&grpc_testing.StreamingInputCallResponse{AggregatedPayloadSize: size}), nil
+	}))
+	mux.Handle(svc+"FullDuplexCall", connect.NewBidiStreamHandler(svc+"FullDuplexCall", func(ctx context.Context, stream *connect.BidiStream[grpc_testing.StreamingOutputCallRequest, grpc_testing.StreamingOutputCallResponse]) error {
+		for {
+			req, err := stream.Receive()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{Payload: req.GetPayload()}); err != nil {
+				return err
+			}
+		}
+	}))
+	reflector := grpcreflect.NewStaticReflector("grpc.testing.TestService")
+	mux.Handle(grpcreflect.NewHandlerV1(reflector))
+	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
+	if !useTLS {
+		ts := httptest.NewServer(h2c.NewHandler(mux, &http2.Server{}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	ts := httptest.NewUnstartedServer(mux)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts
+}
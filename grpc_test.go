@@ -3,6 +3,7 @@ package runn
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"github.com/k1LoW/runn/version"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 func TestGrpcRunner(t *testing.T) {
@@ -597,3 +600,36 @@ func TestGrpcTraceHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileProtosWithChangedSources(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	p := filepath.Join(dir, "watchtest.proto")
+	for i, fields := range []string{"string name = 1;", "string name = 1;\n  int32 age = 2;"} {
+		src := fmt.Sprintf("syntax = \"proto3\";\n\npackage watchtest;\n\nmessage User {\n  %s\n}\n", fields)
+		if err := os.WriteFile(p, []byte(src), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := compileProtos(ctx, cacheDir, []string{dir}, nil, nil, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName("watchtest.User")
+		if err != nil {
+			t.Fatal(err)
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			t.Fatalf("invalid descriptor: %v", d)
+		}
+		if got, want := md.Fields().Len(), i+1; got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	}
+	key := fmt.Sprintf("%q", [][]string{{dir}, nil, nil, nil, nil, nil})
+	globalProtoDescriptorRegistoryMu.Lock()
+	defer globalProtoDescriptorRegistoryMu.Unlock()
+	if _, ok := globalProtoDescriptorRegistory[key]; !ok {
+		t.Errorf("the descriptors of %s are not registered", key)
+	}
+}
@@ -20,10 +20,12 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ajg/form"
 	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 const (
@@ -35,12 +37,13 @@ const (
 )
 
 const (
-	httpStoreStatusKey   = "status"
-	httpStoreBodyKey     = "body"
-	httpStoreRawBodyKey  = "rawBody"
-	httpStoreHeaderKey   = "headers"
-	httpStoreCookieKey   = "cookies"
-	httpStoreResponseKey = "res"
+	httpStoreStatusKey        = "status"
+	httpStoreBodyKey          = "body"
+	httpStoreRawBodyKey       = "rawBody"
+	httpStoreHeaderKey        = "headers"
+	httpStoreCookieKey        = "cookies"
+	httpStoreGraphQLErrorsKey = "errors"
+	httpStoreResponseKey      = "res"
 )
 
 var notFollowRedirectFn = func(req *http.Request, via []*http.Request) error {
@@ -61,6 +64,16 @@ type httpRunner struct {
 	useCookie         *bool
 	trace             *bool
 	traceHeaderName   string
+	auth              *authenticator
+	signer            HTTPSigner
+	retry             *retrier
+	// proxy - Proxy to send requests. The transport of the client is configured by the operator
+	proxy *url.URL
+	// clientConfigured - TLS settings of the client are already configured
+	clientConfigured bool
+	mu               sync.Mutex
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
 }
 
 type httpRequest struct {
@@ -71,11 +84,17 @@ type httpRequest struct {
 	body      any
 	useCookie *bool
 	trace     *bool
+	graphql   *graphqlRequest
+	sse       *sseConfig
+	// responseMessageType - Message type to decode the Protobuf response body
+	responseMessageType string
 
 	multipartWriter   *multipart.Writer
 	multipartBoundary string
 	// operator.root
 	root string
+	// protoMessage - Message type to encode the Protobuf request body
+	protoMessage protoreflect.MessageDescriptor
 }
 
 func newHTTPRunner(name, endpoint string) (*httpRunner, error) {
@@ -120,6 +139,16 @@ func (r *httpRequest) validate() error {
 	switch r.mediaType {
 	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, "":
 	default:
+		mt := r.baseMediaType()
+		if isXMLMediaType(mt) || isMsgPackMediaType(mt) {
+			return nil
+		}
+		if isProtobufMediaType(mt) {
+			if r.body != nil && protobufMessageType(r.mediaType) == "" {
+				return fmt.Errorf("%s requires %s parameter: %s", mt, protobufMessageTypeParam, r.mediaType)
+			}
+			return nil
+		}
 		return fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
 	return nil
@@ -193,6 +222,18 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 		}
 		return strings.NewReader(s), nil
 	default:
+		mt := r.baseMediaType()
+		switch {
+		case isXMLMediaType(mt):
+			return encodeXML(r.body)
+		case isMsgPackMediaType(mt):
+			return encodeMsgPack(r.body)
+		case isProtobufMediaType(mt):
+			if r.protoMessage == nil {
+				return nil, fmt.Errorf("message type is not resolved: %s", r.mediaType)
+			}
+			return encodeProtobuf(r.body, r.protoMessage)
+		}
 		return nil, fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
 }
@@ -325,7 +366,9 @@ func (r *httpRequest) setCookieHeader(req *http.Request, cookies map[string]map[
 	}
 }
 
-func (r *httpRequest) setTraceHeader(s *step) error {
+func (r *httpRequest) setTraceHeader(ctx context.Context, s *step) error {
+	// Propagate the span of the step
+	injectTraceContext(ctx, r.headers)
 	if r.trace == nil || !*r.trace {
 		return nil
 	}
@@ -380,14 +423,157 @@ func (rnr *httpRunner) Run(ctx context.Context, s *step) error {
 	return nil
 }
 
+// configureClient configures TLS settings of the client.
+// It is configured only once because the client may be used by steps running concurrently ( `parallel:` ).
+func (rnr *httpRunner) configureClient() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.clientConfigured {
+		return nil
+	}
+	if rnr.client.Transport == nil {
+		rnr.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if ts, ok := rnr.client.Transport.(*http.Transport); ok {
+		existingConfig := ts.TLSClientConfig
+		if existingConfig != nil {
+			ts.TLSClientConfig = existingConfig.Clone()
+		} else {
+			ts.TLSClientConfig = new(tls.Config)
+		}
+		ts.TLSClientConfig.InsecureSkipVerify = rnr.skipVerify
+	}
+	if len(rnr.cacert) != 0 {
+		certpool, err := x509.SystemCertPool()
+		if err != nil {
+			// FIXME for Windows
+			// ref: https://github.com/golang/go/issues/18609
+			certpool = x509.NewCertPool()
+		}
+		if !certpool.AppendCertsFromPEM(rnr.cacert) {
+			return err
+		}
+		ts, ok := rnr.client.Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("could not set cacert: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
+		}
+		ts.TLSClientConfig.RootCAs = certpool
+	}
+	if len(rnr.cert) != 0 && len(rnr.key) != 0 {
+		cert, err := tls.X509KeyPair(rnr.cert, rnr.key)
+		if err != nil {
+			return err
+		}
+		ts, ok := rnr.client.Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("could not set certificates: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
+		}
+		ts.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	rnr.clientConfigured = true
+	return nil
+}
+
+// setCredentials sets the access token and signs the request. It reports whether the access token is set.
+func (rnr *httpRunner) setCredentials(ctx context.Context, req *http.Request, body []byte) (bool, error) {
+	var authorized bool
+	if rnr.auth != nil {
+		var err error
+		authorized, err = rnr.auth.setAuthHeader(ctx, req)
+		if err != nil {
+			return false, err
+		}
+	}
+	if rnr.signer != nil {
+		if err := rnr.signer(req, body); err != nil {
+			return false, fmt.Errorf("failed to sign the request: %w", err)
+		}
+	}
+	return authorized, nil
+}
+
+// retryWithNewToken resends the request without the credentials ( base ) using send with a new access token.
+// The attempt has the same trails as the step ( trs ).
+func (rnr *httpRunner) retryWithNewToken(ctx context.Context, base *http.Request, res *http.Response, body []byte, trs Trails, send func(*http.Request, Trails) (*http.Response, error)) (*http.Request, *http.Response, error) {
+	_ = res.Body.Close()
+	rnr.auth.Invalidate()
+	retry := base.Clone(ctx)
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if _, err := rnr.setCredentials(ctx, retry, body); err != nil {
+		return nil, nil, err
+	}
+	res, err := send(retry, trs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return retry, res, nil
+}
+
+// retryOnTransientFailure resends the request without the credentials ( base ) using send while the response status is retryable.
+// Each attempt is captured with the trail of the retry before setting the credentials.
+func (rnr *httpRunner) retryOnTransientFailure(ctx context.Context, s *step, req, base *http.Request, res *http.Response, body []byte, send func(*http.Request, Trails) (*http.Response, error)) (*http.Request, *http.Response, Trails, error) {
+	o := s.parent
+	trs := s.trails()
+	ra := rnr.retry.start(ctx)
+	defer ra.stop()
+	for rnr.retry.retryableStatus(res.StatusCode) && ra.remaining() {
+		o.capturers.captureHTTPResponse(rnr.name, res)
+		_ = res.Body.Close()
+		if err := ra.wait(ctx, parseRetryAfter(res.Header.Get("Retry-After"))); err != nil {
+			return nil, nil, nil, err
+		}
+		o.capturers.discardAttempt(rnr.name)
+		trs = retryTrails(s, ra.index())
+		o.capturers.setCurrentTrails(trs)
+		o.Debugf("Retry the request due to the response status %d: %s\n", res.StatusCode, trs[len(trs)-1])
+		retry := base.Clone(ctx)
+		if body != nil {
+			retry.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		o.capturers.captureHTTPRequest(rnr.name, retry)
+		if _, err := rnr.setCredentials(ctx, retry, body); err != nil {
+			return nil, nil, nil, err
+		}
+		sw := o.sw.Start(trs.toProfileIDs()...)
+		_, span := o.startSpan(ctx, trs[len(trs)-1])
+		var err error
+		res, err = send(retry, trs)
+		endSpan(span, err)
+		sw.Stop()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		req = retry
+	}
+	return req, res, trs, nil
+}
+
 func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	o := s.parent
 	r.multipartBoundary = rnr.multipartBoundary
 	r.root = o.root
+	if r.body != nil && isProtobufMediaType(r.baseMediaType()) {
+		md, err := o.resolveMessageDescriptor(ctx, protobufMessageType(r.mediaType))
+		if err != nil {
+			return err
+		}
+		r.protoMessage = md
+	}
 	reqBody, err := r.encodeBody()
 	if err != nil {
 		return err
 	}
+	// The signer and the retries ( including the retry with a new access token ) require the encoded body
+	var rawBody []byte
+	if (rnr.signer != nil || rnr.retry != nil || rnr.auth != nil) && reqBody != nil {
+		rawBody, err = io.ReadAll(reqBody)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(rawBody)
+	}
 
 	// Override useCookie
 	if r.useCookie == nil && rnr.useCookie != nil && *rnr.useCookie {
@@ -401,61 +587,31 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	case r.trace == nil && rnr.trace != nil:
 		r.trace = rnr.trace
 	}
-	if err := r.setTraceHeader(s); err != nil {
+	if err := r.setTraceHeader(ctx, s); err != nil {
 		return err
 	}
 
 	var (
-		req *http.Request
-		res *http.Response
+		req     *http.Request
+		res     *http.Response
+		timings *httpTimings
+		// trs - Trails of the last attempt
+		trs = s.trails()
 	)
+	// Restore the trails of the step changed by the retries
+	defer o.capturers.setCurrentTrails(s.trails())
 	switch {
 	case rnr.client != nil:
-		if rnr.client.Transport == nil {
-			rnr.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
-		}
-		if ts, ok := rnr.client.Transport.(*http.Transport); ok {
-			existingConfig := ts.TLSClientConfig
-			if existingConfig != nil {
-				ts.TLSClientConfig = existingConfig.Clone()
-			} else {
-				ts.TLSClientConfig = new(tls.Config)
-			}
-			ts.TLSClientConfig.InsecureSkipVerify = rnr.skipVerify
-		}
-		if len(rnr.cacert) != 0 {
-			certpool, err := x509.SystemCertPool()
-			if err != nil {
-				// FIXME for Windows
-				// ref: https://github.com/golang/go/issues/18609
-				certpool = x509.NewCertPool()
-			}
-			if !certpool.AppendCertsFromPEM(rnr.cacert) {
-				return err
-			}
-			ts, ok := rnr.client.Transport.(*http.Transport)
-			if !ok {
-				return fmt.Errorf("could not set cacert: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
-			}
-			ts.TLSClientConfig.RootCAs = certpool
-		}
-		if len(rnr.cert) != 0 && len(rnr.key) != 0 {
-			cert, err := tls.X509KeyPair(rnr.cert, rnr.key)
-			if err != nil {
-				return err
-			}
-			ts, ok := rnr.client.Transport.(*http.Transport)
-			if !ok {
-				return fmt.Errorf("could not set certificates: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
-			}
-			ts.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		if err := rnr.configureClient(); err != nil {
+			return err
 		}
 
 		u, err := mergeURL(rnr.endpoint, r.path)
 		if err != nil {
 			return err
 		}
-		req, err = http.NewRequestWithContext(ctx, r.method, u.String(), reqBody)
+		timings = newHTTPTimings()
+		req, err = http.NewRequestWithContext(timings.withClientTrace(ctx), r.method, u.String(), reqBody)
 		if err != nil {
 			return err
 		}
@@ -471,16 +627,65 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 			}
 		}
 
+		// Capture the request before setting the credentials so that they are not leaked into the captured results
 		o.capturers.captureHTTPRequest(rnr.name, req)
+		// The retries resend the request without the credentials, and set them again
+		base := req.Clone(ctx)
+
+		// Replay offline without obtaining the access token
+		var authorized bool
+		if rnr.auth != nil && o.replayer == nil {
+			authorized, err = rnr.auth.setAuthHeader(ctx, req)
+			if err != nil {
+				return err
+			}
+		}
+		if rnr.signer != nil {
+			if err := rnr.signer(req, rawBody); err != nil {
+				return fmt.Errorf("failed to sign the request: %w", err)
+			}
+		}
 
 		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
 			return err
 		}
 
-		res, err = rnr.client.Do(req)
+		// attemptTrs - Trails of the attempt measured by timings
+		attemptTrs := s.trails()
+		send := func(req *http.Request, trs Trails) (*http.Response, error) {
+			// Record the timings of the previous attempt before measuring the next one
+			timings.Done()
+			timings.recordProfile(o.sw, attemptTrs)
+			attemptTrs = trs
+			timings = newHTTPTimings()
+			req = req.WithContext(timings.withClientTrace(req.Context()))
+			timings.Start()
+			return rnr.client.Do(req)
+		}
+		if o.replayer != nil {
+			// There are no network timings in replay
+			timings = nil
+			res, err = o.replayer.replayHTTP(rnr.name, req)
+		} else {
+			timings.Start()
+			res, err = rnr.client.Do(req)
+		}
 		if err != nil {
 			return err
 		}
+		if authorized && res.StatusCode == http.StatusUnauthorized {
+			// The access token may be revoked, so retry once with a new token
+			req, res, err = rnr.retryWithNewToken(ctx, base, res, rawBody, s.trails(), send)
+			if err != nil {
+				return err
+			}
+		}
+		if rnr.retry != nil && o.replayer == nil {
+			req, res, trs, err = rnr.retryOnTransientFailure(ctx, s, req, base, res, rawBody, send)
+			if err != nil {
+				return err
+			}
+		}
 		defer res.Body.Close()
 	case rnr.handler != nil:
 		req = httptest.NewRequest(r.method, r.path, reqBody)
@@ -497,19 +702,78 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 			}
 		}
 
+		// Capture the request before setting the credentials so that they are not leaked into the captured results
 		o.capturers.captureHTTPRequest(rnr.name, req)
+		// The retries resend the request without the credentials, and set them again
+		base := req.Clone(ctx)
+
+		var authorized bool
+		if rnr.auth != nil && o.replayer == nil {
+			authorized, err = rnr.auth.setAuthHeader(ctx, req)
+			if err != nil {
+				return err
+			}
+		}
+		if rnr.signer != nil {
+			if err := rnr.signer(req, rawBody); err != nil {
+				return fmt.Errorf("failed to sign the request: %w", err)
+			}
+		}
 
 		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
 			return err
 		}
-		w := httptest.NewRecorder()
-		rnr.handler.ServeHTTP(w, req)
-		res = w.Result()
+		send := func(req *http.Request, _ Trails) (*http.Response, error) {
+			w := httptest.NewRecorder()
+			rnr.handler.ServeHTTP(w, req)
+			res := w.Result()
+			res.Request = req
+			return res, nil
+		}
+		if o.replayer != nil {
+			res, err = o.replayer.replayHTTP(rnr.name, req)
+			if err != nil {
+				return err
+			}
+		} else {
+			res, _ = send(req, s.trails())
+		}
+		if authorized && res.StatusCode == http.StatusUnauthorized {
+			// The access token may be revoked, so retry once with a new token
+			req, res, err = rnr.retryWithNewToken(ctx, base, res, rawBody, s.trails(), send)
+			if err != nil {
+				return err
+			}
+		}
+		if rnr.retry != nil && o.replayer == nil {
+			req, res, trs, err = rnr.retryOnTransientFailure(ctx, s, req, base, res, rawBody, send)
+			if err != nil {
+				return err
+			}
+		}
 		defer res.Body.Close()
 	default:
 		return fmt.Errorf("invalid http runner: %s", rnr.name)
 	}
 
+	var events []any
+	if isEventStream(res) {
+		c := r.sse
+		if c == nil {
+			c, err = parseSSEConfig(nil)
+			if err != nil {
+				return err
+			}
+		}
+		store := o.store.toMap()
+		store[storeRootKeyIncluded] = o.included
+		store[storeRootKeyPrevious] = o.store.latest()
+		events, err = collectEvents(res, c, store)
+		if err != nil {
+			return err
+		}
+	}
+
 	o.capturers.captureHTTPResponse(rnr.name, res)
 
 	if err := rnr.validator.ValidateResponse(ctx, req, res); err != nil {
@@ -525,20 +789,34 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	if err != nil {
 		return err
 	}
+	if timings != nil {
+		timings.Done()
+		timings.recordProfile(o.sw, trs)
+	}
 
 	d := map[string]any{}
 	d[httpStoreStatusKey] = res.StatusCode
-	if strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0 {
-		var b any
-		if err := json.Unmarshal(resBody, &b); err != nil {
-			return err
-		}
-		d[httpStoreBodyKey] = b
-	} else {
-		d[httpStoreBodyKey] = nil
+	d[httpStoreBodyKey], err = o.decodeBody(ctx, r, res.Header, resBody)
+	if err != nil {
+		return err
 	}
 	d[httpStoreRawBodyKey] = string(resBody)
 	d[httpStoreHeaderKey] = res.Header
+	if timings != nil {
+		d[httpStoreTimingsKey] = timings.toMap()
+	}
+	if events != nil {
+		d[httpStoreEventsKey] = events
+	}
+	if r.graphql != nil {
+		// GraphQL errors are returned with 200 OK, so record them separately
+		d[httpStoreGraphQLErrorsKey] = []any{}
+		if b, ok := d[httpStoreBodyKey].(map[string]any); ok {
+			if errs, ok := b[graphqlErrorsKey].([]any); ok {
+				d[httpStoreGraphQLErrorsKey] = errs
+			}
+		}
+	}
 
 	cookies := res.Cookies()
 
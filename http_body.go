@@ -0,0 +1,252 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/clbanning/mxj/v2"
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	MediaTypeApplicationXML      = "application/xml"
+	MediaTypeTextXML             = "text/xml"
+	MediaTypeApplicationMsgPack  = "application/msgpack"
+	MediaTypeApplicationProtobuf = "application/x-protobuf"
+)
+
+// protobufMessageTypeParam - Parameter of the media type to specify the message type of the Protobuf body.
+const protobufMessageTypeParam = "messageType"
+
+// protobufMessageHeader - Header to specify the message type of the Protobuf response body.
+const protobufMessageHeader = "X-Protobuf-Message"
+
+func isXMLMediaType(mt string) bool {
+	return mt == MediaTypeApplicationXML || mt == MediaTypeTextXML || strings.HasSuffix(mt, "+xml")
+}
+
+func isMsgPackMediaType(mt string) bool {
+	switch mt {
+	case MediaTypeApplicationMsgPack, "application/x-msgpack", "application/vnd.msgpack":
+		return true
+	}
+	return false
+}
+
+func isProtobufMediaType(mt string) bool {
+	switch mt {
+	case MediaTypeApplicationProtobuf, "application/protobuf", "application/vnd.google.protobuf":
+		return true
+	}
+	return false
+}
+
+// encodeXML encodes the body to XML. The string body is sent as is ( e.g. SOAP envelope ).
+func encodeXML(body any) (io.Reader, error) {
+	switch v := body.(type) {
+	case string:
+		return strings.NewReader(v), nil
+	case map[string]any:
+		b, err := mxj.Map(v).Xml()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	default:
+		return nil, fmt.Errorf("invalid body: %v", body)
+	}
+}
+
+func encodeMsgPack(body any) (io.Reader, error) {
+	b, err := msgpack.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+func encodeProtobuf(body any, md protoreflect.MessageDescriptor) (io.Reader, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err := protojson.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	pb, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(pb), nil
+}
+
+// decodeXML decodes XML to map. Attributes are prefixed with `-`, and the text of the element with attributes is `#text`.
+func decodeXML(b []byte) (any, error) {
+	m, err := mxj.NewMapXml(b)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any(m), nil
+}
+
+func decodeMsgPack(b []byte) (any, error) {
+	var v any
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	// Decode integers as int64/uint64 instead of the smallest types
+	dec.UseLooseInterfaceDecoding(true)
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decodeProtobuf decodes the Protobuf message to map in the same form as the gRPC Runner.
+func decodeProtobuf(b []byte, md protoreflect.MessageDescriptor) (any, error) {
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	jb, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]any
+	if err := json.Unmarshal(jb, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *httpRequest) baseMediaType() string {
+	mt, _, err := mime.ParseMediaType(r.mediaType)
+	if err != nil {
+		return r.mediaType
+	}
+	return mt
+}
+
+// protobufMessageType returns the message type specified by the parameter of the media type.
+func protobufMessageType(mediaType string) string {
+	_, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return ""
+	}
+	// Parameter names are case-insensitive, and mime.ParseMediaType lowercases them
+	return params[strings.ToLower(protobufMessageTypeParam)]
+}
+
+// resolveMessageDescriptor resolves the message type using the proto sources of the gRPC Runners of the runbook.
+// The sources are compiled on demand, so the result does not depend on the order of the steps.
+// If not found, the message type registered in protoregistry.GlobalFiles is used.
+func (o *operator) resolveMessageDescriptor(ctx context.Context, name string) (protoreflect.MessageDescriptor, error) {
+	if name == "" {
+		return nil, errors.New("message type of the protobuf body is not specified")
+	}
+	fn := protoreflect.FullName(name)
+	keys := make([]string, 0, len(o.grpcRunners))
+	for k := range o.grpcRunners {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var d protoreflect.Descriptor
+	for _, k := range keys {
+		fds, err := o.grpcRunners[k].compileProtoSources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(fds) == 0 {
+			continue
+		}
+		if dd, err := fds.AsResolver().FindDescriptorByName(fn); err == nil {
+			d = dd
+			break
+		}
+	}
+	if d == nil {
+		dd, err := protoregistry.GlobalFiles.FindDescriptorByName(fn)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find message type: %s: %w", name, err)
+		}
+		d = dd
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", name)
+	}
+	return md, nil
+}
+
+// decodeBody decodes the response body according to Content-Type.
+// The body of unsupported media types and the malformed body of XML, MessagePack and Protobuf are nil ( rawBody is always recorded ).
+func (o *operator) decodeBody(ctx context.Context, r *httpRequest, h http.Header, b []byte) (any, error) {
+	contentType := h.Get("Content-Type")
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if strings.Contains(contentType, "json") {
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil //nolint:nilerr
+	}
+	switch {
+	case isXMLMediaType(mt):
+		v, err := decodeXML(b)
+		if err != nil {
+			return nil, nil //nolint:nilerr
+		}
+		return v, nil
+	case isMsgPackMediaType(mt):
+		v, err := decodeMsgPack(b)
+		if err != nil {
+			return nil, nil //nolint:nilerr
+		}
+		return v, nil
+	case isProtobufMediaType(mt):
+		name := r.responseMessageType
+		if name == "" {
+			name = protobufMessageType(contentType)
+		}
+		if name == "" {
+			name = h.Get(protobufMessageHeader)
+		}
+		if name == "" {
+			// The message type is unknown
+			return nil, nil
+		}
+		md, err := o.resolveMessageDescriptor(ctx, name)
+		if err != nil {
+			if r.responseMessageType != "" {
+				// The message type specified in the step must exist
+				return nil, err
+			}
+			return nil, nil
+		}
+		v, err := decodeProtobuf(b, md)
+		if err != nil {
+			return nil, nil //nolint:nilerr
+		}
+		return v, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,161 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clbanning/mxj/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestHTTPRunnerBody(t *testing.T) {
+	ctx := context.Background()
+	fds, err := compileProtos(ctx, "", nil, []string{"testdata/mock/greeter.proto"}, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findMessage := func(name string) protoreflect.MessageDescriptor {
+		d, err := fds.AsResolver().FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.(protoreflect.MessageDescriptor)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xml", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		m, err := mxj.NewMapXml(b)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name, _ := m.ValueForPath("user.name")
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		_, _ = fmt.Fprintf(w, `<user id="1"><name>%s</name></user>`, name)
+	})
+	mux.HandleFunc("/msgpack", func(w http.ResponseWriter, r *http.Request) {
+		var v any
+		if err := msgpack.NewDecoder(r.Body).Decode(&v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := msgpack.Marshal(v)
+		w.Header().Set("Content-Type", MediaTypeApplicationMsgPack)
+		_, _ = w.Write(b)
+	})
+	mux.HandleFunc("/protobuf", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req := dynamicpb.NewMessage(findMessage("mock.HelloRequest"))
+		if err := proto.Unmarshal(b, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		md := findMessage("mock.HelloResponse")
+		res := dynamicpb.NewMessage(md)
+		res.Set(md.Fields().ByName("message"), protoreflect.ValueOfString("hello "+req.Get(req.Descriptor().Fields().ByName("name")).String()))
+		res.Set(md.Fields().ByName("num"), req.Get(req.Descriptor().Fields().ByName("num")))
+		rb, _ := proto.Marshal(res)
+		if r.URL.Query().Get("messageType") != "" {
+			w.Header().Set("Content-Type", MediaTypeApplicationProtobuf+"; messageType=mock.HelloResponse")
+		} else {
+			w.Header().Set("Content-Type", MediaTypeApplicationProtobuf)
+		}
+		_, _ = w.Write(rb)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	o, err := New(Book("testdata/book/http_body.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodeDecodeBody(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		body      any
+		want      any
+	}{
+		{
+			MediaTypeApplicationXML,
+			map[string]any{"user": map[string]any{"name": "alice", "age": uint64(3)}},
+			map[string]any{"user": map[string]any{"name": "alice", "age": "3"}},
+		},
+		{
+			MediaTypeApplicationMsgPack,
+			map[string]any{"name": "alice", "age": uint64(3), "tags": []any{"a"}},
+			map[string]any{"name": "alice", "age": uint64(3), "tags": []any{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			r := &httpRequest{method: http.MethodPost, mediaType: tt.mediaType, body: tt.body}
+			if err := r.validate(); err != nil {
+				t.Fatal(err)
+			}
+			rd, err := r.encodeBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := &operator{}
+			got, err := o.decodeBody(context.Background(), r, http.Header{"Content-Type": []string{tt.mediaType}}, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformedBody(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		body      []byte
+	}{
+		{MediaTypeApplicationXML, []byte("<user><name>alice</user>")},
+		{"application/atom+xml", []byte("not xml <")},
+		{MediaTypeApplicationMsgPack, []byte{0xc1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			o := &operator{}
+			r := &httpRequest{method: http.MethodGet}
+			got, err := o.decodeBody(context.Background(), r, http.Header{"Content-Type": []string{tt.mediaType}}, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != nil {
+				t.Errorf("got %v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestValidateProtobufMediaType(t *testing.T) {
+	r := &httpRequest{method: http.MethodPost, mediaType: MediaTypeApplicationProtobuf, body: map[string]any{"name": "alice"}}
+	if err := r.validate(); err == nil {
+		t.Error("want error")
+	}
+	r.mediaType = MediaTypeApplicationProtobuf + "; messageType=mock.HelloRequest"
+	if err := r.validate(); err != nil {
+		t.Error(err)
+	}
+}
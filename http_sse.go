@@ -0,0 +1,206 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/duration"
+	"github.com/spf13/cast"
+)
+
+const MediaTypeTextEventStream = "text/event-stream"
+
+const (
+	httpStoreEventsKey = "events"
+	// sseStoreEventKey - Key of the current event in the store evaluating `until:`
+	sseStoreEventKey = "event"
+)
+
+// Fields of the event.
+const (
+	sseEventTypeKey = "event"
+	sseEventIDKey   = "id"
+	sseEventDataKey = "data"
+)
+
+// sseDefaultEventType - Event type when the `event` field is not specified.
+const sseDefaultEventType = "message"
+
+const sseDefaultTimeout = 10 * time.Second
+
+// sseConfig - Conditions to stop collecting Server-Sent Events.
+type sseConfig struct {
+	count   int
+	until   string
+	timeout time.Duration
+}
+
+func parseSSEConfig(v any) (*sseConfig, error) {
+	c := &sseConfig{
+		timeout: sseDefaultTimeout,
+	}
+	if v == nil {
+		return c, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid sse: %v", v)
+	}
+	for k, vv := range m {
+		switch k {
+		case "count":
+			n, err := cast.ToIntE(vv)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid sse count: %v", vv)
+			}
+			c.count = n
+		case "until":
+			s, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid sse until: %v", vv)
+			}
+			c.until = s
+		case "timeout":
+			d, err := duration.Parse(cast.ToString(vv))
+			if err != nil {
+				return nil, fmt.Errorf("invalid sse timeout: %w", err)
+			}
+			c.timeout = d
+		default:
+			return nil, fmt.Errorf("invalid sse key: %s", k)
+		}
+	}
+	return c, nil
+}
+
+func isEventStream(res *http.Response) bool {
+	return strings.HasPrefix(res.Header.Get("Content-Type"), MediaTypeTextEventStream)
+}
+
+// collectEvents reads Server-Sent Events from the response body until the count, the until condition or the timeout is reached, or the stream ends.
+// The body of the response is replaced with the consumed bytes so that capturers can read it.
+func collectEvents(res *http.Response, c *sseConfig, store map[string]any) ([]any, error) {
+	var raw bytes.Buffer
+	body := res.Body
+	ch := make(chan map[string]any)
+	done := make(chan struct{})
+	var rerr error
+	go func() {
+		defer close(ch)
+		r := bufio.NewReader(io.TeeReader(body, &raw))
+		ev := map[string]any{}
+		var data []string
+		for {
+			line, err := r.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			if line != "" {
+				parseEventLine(line, ev, &data)
+				if err == nil {
+					continue
+				}
+			}
+			// A blank line ( or the end of the stream ) dispatches the event. The event without data is not dispatched.
+			if len(data) > 0 {
+				ev[sseEventDataKey] = decodeEventData(strings.Join(data, "\n"))
+				if _, ok := ev[sseEventTypeKey]; !ok {
+					ev[sseEventTypeKey] = sseDefaultEventType
+				}
+				select {
+				case ch <- ev:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					rerr = err
+				}
+				return
+			}
+			ev = map[string]any{}
+			data = nil
+		}
+	}()
+
+	events, ended, err := receiveEvents(ch, c, store)
+	// Stop the reader and wait for it to finish.
+	close(done)
+	_ = body.Close()
+	for range ch {
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ended && rerr != nil {
+		return nil, rerr
+	}
+	// Replace the body with the consumed bytes so that capturers can read it.
+	res.Body = io.NopCloser(&raw)
+	return events, nil
+}
+
+// receiveEvents receives events until the conditions are met. ended reports whether the stream has ended.
+func receiveEvents(ch <-chan map[string]any, c *sseConfig, store map[string]any) (events []any, ended bool, err error) {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	events = []any{}
+	for {
+		select {
+		case <-timer.C:
+			return events, false, nil
+		case ev, ok := <-ch:
+			if !ok {
+				return events, true, nil
+			}
+			events = append(events, ev)
+			if c.count > 0 && len(events) >= c.count {
+				return events, false, nil
+			}
+			if c.until != "" {
+				store[storeRootKeyCurrent] = map[string]any{
+					sseStoreEventKey:   ev,
+					httpStoreEventsKey: events,
+				}
+				tf, err := EvalCond(c.until, store)
+				if err != nil {
+					return nil, false, err
+				}
+				if tf {
+					return events, false, nil
+				}
+			}
+		}
+	}
+}
+
+func parseEventLine(line string, ev map[string]any, data *[]string) {
+	if strings.HasPrefix(line, ":") {
+		// comment
+		return
+	}
+	k, v, _ := strings.Cut(line, ":")
+	v = strings.TrimPrefix(v, " ")
+	switch k {
+	case "event":
+		ev[sseEventTypeKey] = v
+	case "id":
+		ev[sseEventIDKey] = v
+	case "data":
+		*data = append(*data, v)
+	}
+}
+
+func decodeEventData(data string) any {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err == nil {
+		return v
+	}
+	return data
+}
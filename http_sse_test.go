@@ -0,0 +1,122 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestHTTPRunnerSSE(t *testing.T) {
+	ts := testutil.HTTPServer(t)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	o, err := New(Book("testdata/book/http_sse.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		c     *sseConfig
+		want  []any
+		ended bool
+	}{
+		{
+			"until the stream ends",
+			": comment\n\nevent: greet\nid: 1\ndata: {\"name\": \"alice\"}\n\nid: 2\n\ndata: a\ndata: b\n\ndata: last",
+			&sseConfig{timeout: time.Second},
+			[]any{
+				map[string]any{"event": "greet", "id": "1", "data": map[string]any{"name": "alice"}},
+				map[string]any{"event": "message", "data": "a\nb"},
+				map[string]any{"event": "message", "data": "last"},
+			},
+			true,
+		},
+		{
+			"count",
+			"data: 1\n\ndata: 2\n\ndata: 3\n\n",
+			&sseConfig{count: 2, timeout: time.Second},
+			[]any{
+				map[string]any{"event": "message", "data": float64(1)},
+				map[string]any{"event": "message", "data": float64(2)},
+			},
+			false,
+		},
+		{
+			"until",
+			"data: 1\n\ndata: 2\n\ndata: 3\n\n",
+			&sseConfig{until: "current.event.data == 2", timeout: time.Second},
+			[]any{
+				map[string]any{"event": "message", "data": float64(1)},
+				map[string]any{"event": "message", "data": float64(2)},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{
+				Header: http.Header{"Content-Type": []string{MediaTypeTextEventStream}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+			got, err := collectEvents(res, tt.c, map[string]any{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+			if tt.ended {
+				b, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != tt.body {
+					t.Errorf("got %q\nwant %q", string(b), tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSSEConfig(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    *sseConfig
+		wantErr bool
+	}{
+		{nil, &sseConfig{timeout: sseDefaultTimeout}, false},
+		{map[string]any{"count": 3}, &sseConfig{count: 3, timeout: sseDefaultTimeout}, false},
+		{map[string]any{"until": "event.id == '3'", "timeout": "1sec"}, &sseConfig{until: "event.id == '3'", timeout: time.Second}, false},
+		{map[string]any{"count": -1}, nil, true},
+		{map[string]any{"unknown": 1}, nil, true},
+		{"invalid", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSSEConfig(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want, cmp.AllowUnexported(sseConfig{})); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
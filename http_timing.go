@@ -0,0 +1,139 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/stopw"
+)
+
+const httpStoreTimingsKey = "timings"
+
+const (
+	httpTimingDNS     = "dns"
+	httpTimingConnect = "connect"
+	httpTimingTLS     = "tls"
+	httpTimingTTFB    = "ttfb"
+	httpTimingTotal   = "total"
+)
+
+// httpTimings - Timings of the phases of the HTTP request collected using net/http/httptrace.
+type httpTimings struct {
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	done         time.Time
+	mu           sync.Mutex
+}
+
+func newHTTPTimings() *httpTimings {
+	return &httpTimings{}
+}
+
+// withClientTrace returns the context to collect the timings of the request.
+func (t *httpTimings) withClientTrace(ctx context.Context) context.Context {
+	set := func(p *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*p = time.Now()
+	}
+	setOnce := func(p *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// Dialing multiple addresses ( e.g. Happy Eyeballs ) starts more than once
+		if p.IsZero() {
+			*p = time.Now()
+		}
+	}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(_ httptrace.DNSStartInfo) {
+			setOnce(&t.dnsStart)
+		},
+		DNSDone: func(_ httptrace.DNSDoneInfo) {
+			set(&t.dnsDone)
+		},
+		ConnectStart: func(_, _ string) {
+			setOnce(&t.connectStart)
+		},
+		ConnectDone: func(_, _ string, _ error) {
+			set(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			set(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
+			set(&t.tlsDone)
+		},
+		GotFirstResponseByte: func() {
+			set(&t.firstByte)
+		},
+	})
+}
+
+// Start records the time the request is started.
+func (t *httpTimings) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = time.Now()
+}
+
+// Done records the time the response body is read.
+func (t *httpTimings) Done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done = time.Now()
+}
+
+// toMap returns the timings in milliseconds. Phases that did not occur ( e.g. reused connection ) are 0.
+func (t *httpTimings) toMap() map[string]any {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return map[string]any{
+		httpTimingDNS:     elapsedMilliseconds(t.dnsStart, t.dnsDone),
+		httpTimingConnect: elapsedMilliseconds(t.connectStart, t.connectDone),
+		httpTimingTLS:     elapsedMilliseconds(t.tlsStart, t.tlsDone),
+		httpTimingTTFB:    elapsedMilliseconds(t.start, t.firstByte),
+		httpTimingTotal:   elapsedMilliseconds(t.start, t.done),
+	}
+}
+
+// recordProfile records the timings as sub-spans of the step.
+func (t *httpTimings) recordProfile(sw *stopw.Span, trs Trails) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range []struct {
+		key   string
+		start time.Time
+		end   time.Time
+	}{
+		{httpTimingDNS, t.dnsStart, t.dnsDone},
+		{httpTimingConnect, t.connectStart, t.connectDone},
+		{httpTimingTLS, t.tlsStart, t.tlsDone},
+		{httpTimingTTFB, t.start, t.firstByte},
+	} {
+		if p.start.IsZero() || p.end.IsZero() {
+			continue
+		}
+		ids := append(trs.toProfileIDs(), Trail{
+			Type:      TrailTypeHTTPTiming,
+			TimingKey: p.key,
+		})
+		sw.StartAt(p.start, ids...)
+		sw.StopAt(p.end, ids...)
+	}
+}
+
+func elapsedMilliseconds(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
@@ -0,0 +1,130 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/stopw"
+)
+
+func TestHTTPRunnerTimings(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	o, err := New(Book("testdata/book/http_timings.yml"), HTTPRunner("req", ts.URL, ts.Client()), Profile(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := o.DumpProfile(buf); err != nil {
+		t.Fatal(err)
+	}
+	p := &stopw.Span{}
+	if err := json.Unmarshal(buf.Bytes(), p); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	var walk func(s *stopw.Span)
+	walk = func(s *stopw.Span) {
+		b, err := json.Marshal(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tr Trail
+		if err := json.Unmarshal(b, &tr); err == nil && tr.Type == TrailTypeHTTPTiming {
+			got[tr.TimingKey]++
+		}
+		for _, ss := range s.Breakdown {
+			walk(ss)
+		}
+	}
+	walk(p)
+	// The connection is reused in the second step
+	want := map[string]int{httpTimingConnect: 1, httpTimingTLS: 1, httpTimingTTFB: 2}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %v\nwant %v", got, want)
+			break
+		}
+	}
+}
+
+func TestHTTPRunnerTimingsOfRetries(t *testing.T) {
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	o, err := New(Profile(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.retry, err = newRetrier(&retryConfig{Interval: "1ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:    "/users",
+		method:  http.MethodGet,
+		headers: http.Header{},
+	}
+	s := newStep(0, "stepKey", o, nil)
+	if err := r.run(context.Background(), req, s); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := o.DumpProfile(buf); err != nil {
+		t.Fatal(err)
+	}
+	p := &stopw.Span{}
+	if err := json.Unmarshal(buf.Bytes(), p); err != nil {
+		t.Fatal(err)
+	}
+	toTrail := func(s *stopw.Span) Trail {
+		b, err := json.Marshal(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tr Trail
+		_ = json.Unmarshal(b, &tr)
+		return tr
+	}
+	// Trails of the attempts having the TTFB timing
+	got := map[string]bool{}
+	var walk func(s *stopw.Span)
+	walk = func(s *stopw.Span) {
+		for _, ss := range s.Breakdown {
+			if tr := toTrail(ss); tr.Type == TrailTypeHTTPTiming && tr.TimingKey == httpTimingTTFB {
+				got[toTrail(s).String()] = true
+			}
+			walk(ss)
+		}
+	}
+	walk(p)
+	want := map[string]bool{"steps[stepKey]": true, "retry[0]": true, "retry[1]": true}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
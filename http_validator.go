@@ -40,9 +40,15 @@ func (e *UnsupportedError) Unwrap() error {
 }
 
 func newHttpValidator(c *httpRunnerConfig) (httpValidator, error) {
+	if (c.OpenAPI3DocLocation != "" || c.openAPI3Doc != nil) && c.GraphQLSchemaLocation != "" {
+		return nil, errors.New("openapi3 and graphql cannot be used at the same time")
+	}
 	if c.OpenAPI3DocLocation != "" || c.openAPI3Doc != nil {
 		return newOpenAPI3Validator(c)
 	}
+	if c.GraphQLSchemaLocation != "" {
+		return newGraphQLValidator(c)
+	}
 	return newNopValidator(), nil
 }
 
@@ -187,13 +187,27 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	for k, r := range o.sshRunners {
 		popts = append(popts, reuseSSHRunner(k, r))
 	}
+	for k, r := range o.websocketRunners {
+		popts = append(popts, reuseWebSocketRunner(k, r))
+	}
+	for k, r := range o.mockRunners {
+		popts = append(popts, reuseMockRunner(k, r))
+	}
+	for k, r := range o.queueRunners {
+		popts = append(popts, reuseQueueRunner(k, r))
+	}
 
 	popts = append(popts, Debug(o.debug))
 	popts = append(popts, Profile(o.profile))
 	popts = append(popts, SkipTest(o.skipTest))
+	popts = append(popts, UpdateSnapshots(o.updateSnapshots))
 	popts = append(popts, Force(o.force))
 	popts = append(popts, Trace(o.trace))
 	for k, f := range o.store.funcs {
+		if _, ok := f.(jsonSchemaFunc); ok {
+			// The included runbook binds its own root
+			continue
+		}
 		popts = append(popts, Func(k, f))
 	}
 
@@ -208,7 +222,10 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	oo.t = o.thisT
 	oo.thisT = o.thisT
 	oo.sw = o.sw
-	oo.capturers = o.capturers
+	oo.tracer = o.tracer
+	oo.otel = o.otel
+	oo.capturers = o.capturers.forOperator(oo)
+	oo.replayer = o.replayer
 	oo.parent = parent
 	oo.store.parentVars = o.store.toMap()
 	oo.store.kv = o.store.kv
@@ -309,3 +309,16 @@ func TestHostRulesWithContainer(t *testing.T) {
 		}
 	})
 }
+
+func TestRunUsingKafka(t *testing.T) {
+	dsn := testutil.CreateKafkaContainer(t)
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	o, err := New(Book("testdata/book/queue.yml"), QueueRunnerWithDSN("q", dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
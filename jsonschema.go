@@ -0,0 +1,100 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/k1LoW/runn/builtin"
+)
+
+const jsonSchemaRunnerKey = "jsonSchema"
+
+type jsonSchemaRunner struct{}
+
+type jsonSchemaRequest struct {
+	// schema - Path to the schema file ( relative to the root of the runbook ) or the schema object
+	schema any
+	expr   string
+}
+
+type jsonSchemaMismatchError struct {
+	errs builtin.JSONSchemaErrors
+}
+
+func newJSONSchemaMismatchError(errs builtin.JSONSchemaErrors) *jsonSchemaMismatchError {
+	return &jsonSchemaMismatchError{
+		errs: errs,
+	}
+}
+
+func (je *jsonSchemaMismatchError) Error() string {
+	errs := SprintMultilinef("  %s\n", "%s", strings.Join(je.errs, "\n"))
+	return fmt.Sprintf("value does not match the JSON Schema\n\nErrors:\n%s", errs)
+}
+
+func newJSONSchemaRunner() *jsonSchemaRunner {
+	return &jsonSchemaRunner{}
+}
+
+func (rnr *jsonSchemaRunner) Run(ctx context.Context, s *step, first bool) error {
+	r := s.jsonSchemaRequest
+	o := s.parent
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	if first {
+		store[storeRootKeyPrevious] = o.store.latest()
+	} else {
+		store[storeRootKeyPrevious] = o.store.previous()
+		store[storeRootKeyCurrent] = o.store.latest()
+	}
+	v, err := Eval(r.expr, store)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, r.schema, v, s, first); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *jsonSchemaRunner) run(_ context.Context, schema, v any, s *step, first bool) error {
+	o := s.parent
+	errs, err := builtin.NewJSONSchema(o.root, readFile)(schema, v)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return newJSONSchemaMismatchError(errs)
+	}
+	if first {
+		o.record(nil)
+	}
+	return nil
+}
+
+// jsonSchemaFunc - The built-in jsonschema() that loads the schema files relative to the root of the runbook.
+// Included runbooks have their own roots, so it is not passed to them.
+type jsonSchemaFunc func(schema, v any) (builtin.JSONSchemaErrors, error)
+
+// newJSONSchemaFunc returns the built-in jsonschema() bound to the root of the runbook.
+// The root is resolved on the first call because it is fixed only after all options are applied.
+func newJSONSchemaFunc(bk *book) jsonSchemaFunc {
+	var (
+		validate func(schema, v any) (builtin.JSONSchemaErrors, error)
+		err      error
+		once     sync.Once
+	)
+	return func(schema, v any) (builtin.JSONSchemaErrors, error) {
+		once.Do(func() {
+			var root string
+			root, err = bk.generateOperatorRoot()
+			validate = builtin.NewJSONSchema(root, readFile)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return validate(schema, v)
+	}
+}
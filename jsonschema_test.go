@@ -0,0 +1,50 @@
+package runn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/runn/builtin"
+)
+
+func TestJSONSchemaRunner(t *testing.T) {
+	tests := []struct {
+		userID  string
+		wantErr string
+	}{
+		{"1", ""},
+		{"0", "/id: must be >= 1 but found 0"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			t.Setenv("TEST_USER_ID", tt.userID)
+			o, err := New(Book("testdata/jsonschema_step.yml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant contains %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaMismatchError(t *testing.T) {
+	err := newJSONSchemaMismatchError(builtin.JSONSchemaErrors{"/id: must be >= 1 but found 0", "/name: missing"})
+	want := "value does not match the JSON Schema\n\nErrors:\n  /id: must be >= 1 but found 0\n  /name: missing\n"
+	if got := err.Error(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
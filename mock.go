@@ -0,0 +1,448 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	mockStoreURLKey      = "url"
+	mockStoreGRPCAddrKey = "grpcAddr"
+	mockStoreRequestsKey = "requests"
+)
+
+const (
+	mockRequestTypeKey     = "type"
+	mockRequestMethodKey   = "method"
+	mockRequestPathKey     = "path"
+	mockRequestQueryKey    = "query"
+	mockRequestHeadersKey  = "headers"
+	mockRequestParamsKey   = "params"
+	mockRequestBodyKey     = "body"
+	mockRequestRawBodyKey  = "rawBody"
+	mockRequestMessageKey  = "message"
+	mockRequestMessagesKey = "messages"
+)
+
+const (
+	mockRequestTypeHTTP = "http"
+	mockRequestTypeGRPC = "grpc"
+)
+
+// mockEnvRequestKey - Key of the received request in the environment to expand responses.
+const mockEnvRequestKey = "request"
+
+const mockDefaultAddr = "127.0.0.1:0"
+
+var mockPathParamRe = regexp.MustCompile(`\{([^{}$.]+)(?:\.\.\.)?\}`)
+
+// mockRunner - Runner that starts local HTTP ( and gRPC ) servers returning canned responses.
+type mockRunner struct {
+	name       string
+	addr       string
+	routes     []*mockHTTPRoute
+	grpcAddr   string
+	grpcRoutes []*mockGRPCRoute
+	// grpcResolver - Resolve method descriptors of the gRPC server from protos.
+	grpcResolver *grpcRunner
+	httpServer   *http.Server
+	grpcServer   *grpc.Server
+	url          string
+	requests     []any
+	started      bool
+	mu           sync.Mutex
+}
+
+func newMockRunner(name string, c *mockConfig) (*mockRunner, error) {
+	r := &mockRunner{
+		name:   name,
+		addr:   c.Addr,
+		routes: c.Routes,
+	}
+	if r.addr == "" {
+		r.addr = mockDefaultAddr
+	}
+	for _, rt := range r.routes {
+		if rt.Path == "" {
+			return nil, fmt.Errorf("invalid mock route: path is required: %s", name)
+		}
+	}
+	if c.GRPC != nil {
+		r.grpcAddr = c.GRPC.Addr
+		if r.grpcAddr == "" {
+			r.grpcAddr = mockDefaultAddr
+		}
+		r.grpcRoutes = c.GRPC.Routes
+		for _, rt := range r.grpcRoutes {
+			if rt.Method == "" {
+				return nil, fmt.Errorf("invalid mock gRPC route: method is required: %s", name)
+			}
+		}
+		gr, err := newGrpcRunner(name, "")
+		if err != nil {
+			return nil, err
+		}
+		r.grpcResolver = gr
+	}
+	return r, nil
+}
+
+func (rnr *mockRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	if len(s.mockRequest) > 0 {
+		return fmt.Errorf("mock runner step does not take any parameters: %v", s.mockRequest)
+	}
+	if err := rnr.start(ctx); err != nil {
+		return err
+	}
+	rnr.mu.Lock()
+	requests := rnr.requests
+	rnr.requests = nil
+	v := map[string]any{
+		mockStoreURLKey: rnr.url,
+	}
+	if rnr.grpcServer != nil {
+		v[mockStoreGRPCAddrKey] = rnr.grpcAddr
+	}
+	rnr.mu.Unlock()
+	if requests == nil {
+		requests = []any{}
+	}
+	v[mockStoreRequestsKey] = requests
+	o.record(v)
+	return nil
+}
+
+// start starts the servers if they have not been started yet.
+func (rnr *mockRunner) start(ctx context.Context) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.started {
+		return nil
+	}
+	h, err := rnr.httpHandler()
+	if err != nil {
+		return err
+	}
+	var mds map[string]protoreflect.MethodDescriptor
+	if rnr.grpcResolver != nil {
+		if err := rnr.grpcResolver.resolveAllMethodsUsingProtos(ctx); err != nil {
+			return fmt.Errorf("failed to resolve methods of mock gRPC server %s: %w", rnr.name, err)
+		}
+		mds = rnr.grpcResolver.mds
+	}
+	ln, err := net.Listen("tcp", rnr.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start mock server %s: %w", rnr.name, err)
+	}
+	rnr.httpServer = &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = rnr.httpServer.Serve(ln)
+	}()
+	rnr.url = fmt.Sprintf("http://%s", ln.Addr().String())
+	if rnr.grpcResolver != nil {
+		gln, err := net.Listen("tcp", rnr.grpcAddr)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to start mock gRPC server %s: %w", rnr.name, err), rnr.httpServer.Close())
+		}
+		rnr.grpcServer = grpc.NewServer(grpc.UnknownServiceHandler(rnr.grpcHandler(mds)))
+		go func() {
+			_ = rnr.grpcServer.Serve(gln)
+		}()
+		rnr.grpcAddr = gln.Addr().String()
+	}
+	rnr.started = true
+	return nil
+}
+
+// Close shuts down the servers.
+func (rnr *mockRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if !rnr.started {
+		return nil
+	}
+	var err error
+	if rnr.httpServer != nil {
+		err = rnr.httpServer.Close()
+		rnr.httpServer = nil
+	}
+	if rnr.grpcServer != nil {
+		rnr.grpcServer.Stop()
+		rnr.grpcServer = nil
+	}
+	rnr.requests = nil
+	rnr.started = false
+	return err
+}
+
+func (rnr *mockRunner) httpHandler() (http.Handler, error) {
+	mux := http.NewServeMux()
+	catchAll := true
+	for _, rt := range rnr.routes {
+		pattern := rt.Path
+		if rt.Method != "" {
+			pattern = fmt.Sprintf("%s %s", strings.ToUpper(rt.Method), rt.Path)
+		}
+		if pattern == "/" {
+			catchAll = false
+		}
+		if err := handleMockRoute(mux, pattern, rnr.routeHandler(rt)); err != nil {
+			return nil, err
+		}
+	}
+	if catchAll {
+		// Requests that do not match any route are also recorded.
+		mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+			if _, err := rnr.recordHTTPRequest(req, nil); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.NotFound(w, req)
+		})
+	}
+	return mux, nil
+}
+
+func (rnr *mockRunner) routeHandler(rt *mockHTTPRoute) http.HandlerFunc {
+	var names []string
+	for _, m := range mockPathParamRe.FindAllStringSubmatch(rt.Path, -1) {
+		names = append(names, m[1])
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		m, err := rnr.recordHTTPRequest(req, names)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := writeMockHTTPResponse(w, rt, m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (rnr *mockRunner) recordHTTPRequest(req *http.Request, paramNames []string) (map[string]any, error) {
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var body any
+	if strings.Contains(req.Header.Get("Content-Type"), "json") && len(b) > 0 {
+		if err := json.Unmarshal(b, &body); err != nil {
+			body = nil
+		}
+	}
+	params := map[string]any{}
+	for _, n := range paramNames {
+		params[n] = req.PathValue(n)
+	}
+	m := map[string]any{
+		mockRequestTypeKey:    mockRequestTypeHTTP,
+		mockRequestMethodKey:  req.Method,
+		mockRequestPathKey:    req.URL.Path,
+		mockRequestQueryKey:   req.URL.Query(),
+		mockRequestHeadersKey: req.Header,
+		mockRequestParamsKey:  params,
+		mockRequestBodyKey:    body,
+		mockRequestRawBodyKey: string(b),
+	}
+	rnr.mu.Lock()
+	rnr.requests = append(rnr.requests, m)
+	rnr.mu.Unlock()
+	return m, nil
+}
+
+func writeMockHTTPResponse(w http.ResponseWriter, rt *mockHTTPRoute, req map[string]any) error {
+	env := map[string]any{mockEnvRequestKey: req}
+	headers, err := expandMockMetadata(rt.Headers, env)
+	if err != nil {
+		return err
+	}
+	body, err := EvalExpand(rt.Body, env)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	switch v := body.(type) {
+	case nil:
+	case string:
+		b = []byte(v)
+	default:
+		b, err = json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, ok := headers["Content-Type"]; !ok {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+	for k, v := range headers {
+		w.Header().Set(k, v)
+	}
+	st := rt.Status
+	if st == 0 {
+		st = http.StatusOK
+	}
+	w.WriteHeader(st)
+	_, _ = w.Write(b)
+	return nil
+}
+
+func (rnr *mockRunner) grpcHandler(mds map[string]protoreflect.MethodDescriptor) grpc.StreamHandler {
+	return func(_ any, stream grpc.ServerStream) error {
+		fm, ok := grpc.MethodFromServerStream(stream)
+		if !ok {
+			return status.Error(codes.Internal, "failed to get the method")
+		}
+		key := strings.TrimPrefix(fm, "/")
+		md, ok := mds[key]
+		if !ok {
+			return status.Errorf(codes.Unimplemented, "unknown method: %s", key)
+		}
+		// Receive all request messages before responding.
+		var messages []any
+		for {
+			req := dynamicpb.NewMessage(md.Input())
+			if err := stream.RecvMsg(req); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(req)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			var msg map[string]any
+			if err := json.Unmarshal(b, &msg); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			messages = append(messages, msg)
+			if !md.IsStreamingClient() {
+				break
+			}
+		}
+		h, _ := metadata.FromIncomingContext(stream.Context())
+		m := map[string]any{
+			mockRequestTypeKey:     mockRequestTypeGRPC,
+			mockRequestMethodKey:   key,
+			mockRequestHeadersKey:  h,
+			mockRequestMessageKey:  nil,
+			mockRequestMessagesKey: messages,
+		}
+		if len(messages) > 0 {
+			m[mockRequestMessageKey] = messages[len(messages)-1]
+		}
+		rnr.mu.Lock()
+		rnr.requests = append(rnr.requests, m)
+		rnr.mu.Unlock()
+
+		var rt *mockGRPCRoute
+		for _, r := range rnr.grpcRoutes {
+			if r.Method == key {
+				rt = r
+				break
+			}
+		}
+		if rt == nil {
+			return status.Errorf(codes.Unimplemented, "no mock route for the method: %s", key)
+		}
+		return sendMockGRPCResponse(stream, md, rt, m)
+	}
+}
+
+func sendMockGRPCResponse(stream grpc.ServerStream, md protoreflect.MethodDescriptor, rt *mockGRPCRoute, req map[string]any) error {
+	env := map[string]any{mockEnvRequestKey: req}
+	headers, err := expandMockMetadata(rt.Headers, env)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	trailers, err := expandMockMetadata(rt.Trailers, env)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := stream.SetHeader(metadata.New(headers)); err != nil {
+		return err
+	}
+	stream.SetTrailer(metadata.New(trailers))
+	if rt.Status != int(codes.OK) {
+		return status.Error(codes.Code(rt.Status), rt.StatusMessage) //nolint:gosec
+	}
+	var messages []any
+	if rt.Message != nil {
+		messages = append(messages, rt.Message)
+	}
+	messages = append(messages, rt.Messages...)
+	if !md.IsStreamingServer() && len(messages) != 1 {
+		return status.Errorf(codes.Internal, "mock route of non-server-streaming method should have one message: %s", rt.Method)
+	}
+	for _, msg := range messages {
+		e, err := EvalExpand(msg, env)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		res := dynamicpb.NewMessage(md.Output())
+		if err := protojson.Unmarshal(b, res); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expandMockMetadata(in map[string]any, env map[string]any) (map[string]string, error) {
+	out := map[string]string{}
+	if len(in) == 0 {
+		return out, nil
+	}
+	e, err := EvalExpand(in, env)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := e.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid headers: %v", e)
+	}
+	for k, v := range m {
+		out[k] = fmt.Sprintf("%v", v)
+	}
+	return out, nil
+}
+
+func handleMockRoute(mux *http.ServeMux, pattern string, h http.HandlerFunc) (err error) {
+	// http.ServeMux panics on invalid or conflicting patterns.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid mock route %q: %v", pattern, r)
+		}
+	}()
+	mux.HandleFunc(pattern, h)
+	return nil
+}
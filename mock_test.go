@@ -0,0 +1,74 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMockRunner(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/mock.yml"},
+		{"testdata/book/mock_grpc.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMockRunnerClose(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/mock.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// operator.Run closes runners after running the steps.
+	r := o.mockRunners["upstream"]
+	if r.started {
+		t.Error("the mock runner should be closed")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+"/healthz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err == nil {
+		_ = res.Body.Close()
+		t.Error("the mock server should be shut down")
+	}
+}
+
+func TestMockRunnerInvalidRoute(t *testing.T) {
+	tests := []struct {
+		c *mockConfig
+	}{
+		{&mockConfig{Routes: []*mockHTTPRoute{{Method: "GET"}}}},
+		{&mockConfig{GRPC: &mockGRPCConfig{Routes: []*mockGRPCRoute{{Status: 5}}}}},
+	}
+	for _, tt := range tests {
+		if _, err := newMockRunner("upstream", tt.c); err == nil {
+			t.Errorf("want error: %v", tt.c)
+		}
+	}
+	r, err := newMockRunner("upstream", &mockConfig{Routes: []*mockHTTPRoute{{Path: "/a"}, {Path: "/a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.start(context.Background()); err == nil {
+		t.Error("want error for conflicting routes")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/ryo-yamaoka/otchkiss"
 	"github.com/samber/lo"
 	"github.com/spf13/cast"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 var errStepSkiped = errors.New("step skipped")
@@ -32,22 +33,25 @@ var errStepSkiped = errors.New("step skipped")
 var _ otchkiss.Requester = (*operators)(nil)
 
 type operator struct {
-	id             string
-	httpRunners    map[string]*httpRunner
-	dbRunners      map[string]*dbRunner
-	grpcRunners    map[string]*grpcRunner
-	cdpRunners     map[string]*cdpRunner
-	sshRunners     map[string]*sshRunner
-	includeRunners map[string]*includeRunner
-	steps          []*step
-	store          store
-	desc           string
-	labels         []string
-	useMap         bool // Use map syntax in `steps:`.
-	debug          bool
-	profile        bool
-	interval       time.Duration
-	loop           *Loop
+	id               string
+	httpRunners      map[string]*httpRunner
+	dbRunners        map[string]*dbRunner
+	grpcRunners      map[string]*grpcRunner
+	cdpRunners       map[string]*cdpRunner
+	sshRunners       map[string]*sshRunner
+	websocketRunners map[string]*websocketRunner
+	mockRunners      map[string]*mockRunner
+	queueRunners     map[string]*queueRunner
+	includeRunners   map[string]*includeRunner
+	steps            []*step
+	store            store
+	desc             string
+	labels           []string
+	useMap           bool // Use map syntax in `steps:`.
+	debug            bool
+	profile          bool
+	interval         time.Duration
+	loop             *Loop
 	// loopIndex - Index of the loop is dynamically recorded at runtime
 	loopIndex   *int
 	concurrency []string
@@ -70,14 +74,22 @@ type operator struct {
 	newOnly  bool
 	bookPath string
 	// Number of steps for `runn list`
-	numberOfSteps   int
-	beforeFuncs     []func(*RunResult) error
-	afterFuncs      []func(*RunResult) error
-	sw              *stopw.Span
-	capturers       capturers
-	runResult       *RunResult
-	dbg             *dbg
-	hasRunnerRunner bool
+	numberOfSteps int
+	beforeFuncs   []func(*RunResult) error
+	afterFuncs    []func(*RunResult) error
+	sw            *stopw.Span
+	tracer        oteltrace.Tracer
+	otel          *otelTracer
+	capturers     capturers
+	replayer      *cassetteReplayer
+	// responseCoverage - Capturer that records the response statuses for coverage
+	responseCoverage *responseCoverageCapturer
+	runResult        *RunResult
+	dbg              *dbg
+	hasRunnerRunner  bool
+	updateSnapshots  bool
+	// branchKey - Key of the branch of `parallel:` that the operator runs.
+	branchKey string
 
 	mu sync.Mutex
 }
@@ -131,6 +143,21 @@ func (o *operator) Close(force bool) {
 	for _, r := range o.sshRunners {
 		_ = r.Close()
 	}
+	for _, r := range o.websocketRunners {
+		_ = r.Close()
+	}
+	// Mock runners are always created from the runbook ( there is no option to pass a running mock server ),
+	// and the operators nested by include, loop and parallel only reuse them without closing.
+	// So they are never shared with other operators, and are closed even if not forced to release their listening addresses.
+	for _, r := range o.mockRunners {
+		_ = r.Close()
+	}
+	for _, r := range o.queueRunners {
+		if !force && r.dsn == "" {
+			continue
+		}
+		_ = r.Close()
+	}
 	for _, r := range o.dbRunners {
 		if !force && r.dsn == "" {
 			continue
@@ -139,7 +166,7 @@ func (o *operator) Close(force bool) {
 	}
 }
 
-func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
+func (o *operator) runStep(ctx context.Context, idx int, s *step) (rerr error) {
 	if o.t != nil {
 		o.t.Helper()
 	}
@@ -148,6 +175,10 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 	}
 	trs := s.trails()
 	defer o.sw.Start(trs.toProfileIDs()...).Stop()
+	ctx, span := o.startSpan(ctx, trs[len(trs)-1])
+	defer func() {
+		endSpan(span, rerr)
+	}()
 	o.capturers.setCurrentTrails(trs)
 	if idx != 0 {
 		// interval:
@@ -176,7 +207,7 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 		o.Debugf(cyan("Run %q on %s\n"), s.runnerKey, o.stepName(idx))
 	}
 
-	stepFn := func(t *testing.T) error {
+	stepFn := func(ctx context.Context, t *testing.T) error {
 		s.clearResult()
 		if t != nil {
 			t.Helper()
@@ -203,6 +234,18 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 				s.sshRunner = r
 				s.sshCommand = s.runnerValues
 			}
+			if r, ok := o.websocketRunners[s.runnerKey]; ok {
+				s.websocketRunner = r
+				s.websocketRequest = s.runnerValues
+			}
+			if r, ok := o.mockRunners[s.runnerKey]; ok {
+				s.mockRunner = r
+				s.mockRequest = s.runnerValues
+			}
+			if r, ok := o.queueRunners[s.runnerKey]; ok {
+				s.queueRunner = r
+				s.queueRequest = s.runnerValues
+			}
 		}
 		switch {
 		case s.httpRunner != nil && s.httpRequest != nil:
@@ -230,6 +273,21 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 				return fmt.Errorf("ssh command failed on %s: %w", o.stepName(idx), err)
 			}
 			run = true
+		case s.websocketRunner != nil && s.websocketRequest != nil:
+			if err := s.websocketRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("websocket request failed on %s: %w", o.stepName(idx), err)
+			}
+			run = true
+		case s.mockRunner != nil && s.mockRequest != nil:
+			if err := s.mockRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("mock failed on %s: %w", o.stepName(idx), err)
+			}
+			run = true
+		case s.queueRunner != nil && s.queueRequest != nil:
+			if err := s.queueRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("queue request failed on %s: %w", o.stepName(idx), err)
+			}
+			run = true
 		case s.execRunner != nil && s.execCommand != nil:
 			if err := s.execRunner.Run(ctx, s); err != nil {
 				return fmt.Errorf("exec command failed on %s: %w", o.stepName(idx), err)
@@ -240,6 +298,11 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 				return fmt.Errorf("include failed on %s: %w", o.stepName(idx), err)
 			}
 			run = true
+		case s.parallelRunner != nil && s.parallelConfig != nil:
+			if err := s.parallelRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("parallel failed on %s: %w", o.stepName(idx), err)
+			}
+			run = true
 		case s.runnerRunner != nil && s.runnerDefinition != nil:
 			if err := s.runnerRunner.Run(ctx, s); err != nil {
 				return fmt.Errorf("runner definition failed on %s: %w", o.stepName(idx), err)
@@ -262,6 +325,36 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 			}
 			run = true
 		}
+		// snapshot runner
+		if s.snapshotRunner != nil && s.snapshotRequest != nil {
+			if o.skipTest {
+				o.Debugf(yellow("Skip %q on %s\n"), snapshotRunnerKey, o.stepName(idx))
+				if !run && s.testCond == "" {
+					return errStepSkiped
+				}
+			} else {
+				o.Debugf(cyan("Run %q on %s\n"), snapshotRunnerKey, o.stepName(idx))
+				if err := s.snapshotRunner.Run(ctx, s, !run); err != nil {
+					return fmt.Errorf("snapshot failed on %s: %w", o.stepName(idx), err)
+				}
+				run = true
+			}
+		}
+		// jsonSchema runner
+		if s.jsonSchemaRunner != nil && s.jsonSchemaRequest != nil {
+			if o.skipTest {
+				o.Debugf(yellow("Skip %q on %s\n"), jsonSchemaRunnerKey, o.stepName(idx))
+				if !run && s.testCond == "" {
+					return errStepSkiped
+				}
+			} else {
+				o.Debugf(cyan("Run %q on %s\n"), jsonSchemaRunnerKey, o.stepName(idx))
+				if err := s.jsonSchemaRunner.Run(ctx, s, !run); err != nil {
+					return fmt.Errorf("jsonSchema failed on %s: %w", o.stepName(idx), err)
+				}
+				run = true
+			}
+		}
 		// test runner
 		if s.testRunner != nil && s.testCond != "" {
 			if o.skipTest {
@@ -317,10 +410,13 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 			trs := s.trails()
 			o.capturers.setCurrentTrails(trs)
 			sw := o.sw.Start(trs.toProfileIDs()...)
-			if err := stepFn(o.thisT); err != nil {
+			lctx, lspan := o.startSpan(ctx, trs[len(trs)-1])
+			if err := stepFn(lctx, o.thisT); err != nil {
+				endSpan(lspan, err)
 				sw.Stop()
 				return fmt.Errorf("loop failed: %w", err)
 			}
+			endSpan(lspan, nil)
 			sw.Stop()
 			if s.loop.Until != "" {
 				store := o.store.toMap()
@@ -352,7 +448,7 @@ func (o *operator) runStep(ctx context.Context, idx int, s *step) error {
 			}
 		}
 	} else {
-		if err := stepFn(o.thisT); err != nil {
+		if err := stepFn(ctx, o.thisT); err != nil {
 			return err
 		}
 	}
@@ -413,6 +509,14 @@ func (o *operator) recordToCookie(cookies []*http.Cookie) {
 }
 
 func (o *operator) generateTrail() Trail {
+	if o.branchKey != "" {
+		return Trail{
+			Type:      TrailTypeBranch,
+			Desc:      o.desc,
+			RunbookID: o.id,
+			BranchKey: o.branchKey,
+		}
+	}
 	return Trail{
 		Type:        TrailTypeRunbook,
 		Desc:        o.desc,
@@ -448,13 +552,16 @@ func New(opts ...Option) (*operator, error) {
 		return nil, err
 	}
 	o := &operator{
-		id:             id,
-		httpRunners:    map[string]*httpRunner{},
-		dbRunners:      map[string]*dbRunner{},
-		grpcRunners:    map[string]*grpcRunner{},
-		cdpRunners:     map[string]*cdpRunner{},
-		sshRunners:     map[string]*sshRunner{},
-		includeRunners: map[string]*includeRunner{},
+		id:               id,
+		httpRunners:      map[string]*httpRunner{},
+		dbRunners:        map[string]*dbRunner{},
+		grpcRunners:      map[string]*grpcRunner{},
+		cdpRunners:       map[string]*cdpRunner{},
+		sshRunners:       map[string]*sshRunner{},
+		websocketRunners: map[string]*websocketRunner{},
+		mockRunners:      map[string]*mockRunner{},
+		queueRunners:     map[string]*queueRunner{},
+		includeRunners:   map[string]*includeRunner{},
 		store: store{
 			steps:    []map[string]any{},
 			stepMap:  map[string]map[string]any{},
@@ -487,11 +594,23 @@ func New(opts ...Option) (*operator, error) {
 		beforeFuncs: bk.beforeFuncs,
 		afterFuncs:  bk.afterFuncs,
 		sw:          stopw.New(),
-		capturers:   bk.capturers,
+		tracer:      noopTracer,
 		runResult:   newRunResult(bk.desc, bk.labels, bk.path, bk.included),
 		dbg:         newDBG(bk.attach),
 	}
 
+	o.capturers = bk.capturers.forOperator(o)
+
+	o.otel = bk.otel
+
+	o.updateSnapshots = bk.updateSnapshots
+
+	if bk.replayDir != "" {
+		o.replayer = newCassetteReplayer(bk.replayDir, bk.path)
+	}
+
+	o.responseCoverage = bk.responseCoverage
+
 	if o.debug {
 		o.capturers = append(o.capturers, NewDebugger(o.stderr))
 	}
@@ -530,8 +649,31 @@ func New(opts ...Option) (*operator, error) {
 				break
 			}
 		}
-		if len(hostRules) > 0 {
-			v.client.Transport.(*http.Transport).DialContext = hostRules.dialContextFunc()
+		// The proxy set in the runner settings takes precedence.
+		if v.proxy == nil && v.handler == nil {
+			v.proxy = bk.proxy
+		}
+		if v.handler != nil && v.proxy != nil {
+			return nil, fmt.Errorf("invalid HTTP runner %q: proxy cannot be used with http.Handler", k)
+		}
+		if v.client != nil && (len(hostRules) > 0 || v.proxy != nil) {
+			if v.client.Transport == nil {
+				v.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+			}
+			ts, ok := v.client.Transport.(*http.Transport)
+			if !ok {
+				return nil, fmt.Errorf("invalid HTTP runner %q: host rules and proxy require *http.Transport as the transport of the client, but got %T", k, v.client.Transport)
+			}
+			if len(hostRules) > 0 {
+				ts.DialContext = hostRules.dialContextFunc()
+			}
+			if v.proxy != nil {
+				// The connection to the proxy is established by DialContext ( according to the host rules ).
+				ts.Proxy = http.ProxyURL(v.proxy)
+			}
+		}
+		if v.operatorID == "" {
+			v.operatorID = o.id
 		}
 		o.httpRunners[k] = v
 	}
@@ -559,6 +701,13 @@ func New(opts ...Option) (*operator, error) {
 			}
 			v.protos = append(v.protos, p)
 		}
+		for _, ps := range bk.grpcProtosets {
+			key, p := splitKeyAndPath(ps)
+			if key != "" && key != k {
+				continue
+			}
+			v.protosets = append(v.protosets, p)
+		}
 		for _, ip := range bk.grpcImportPaths {
 			key, p := splitKeyAndPath(ip)
 			if key != "" && key != k {
@@ -570,12 +719,18 @@ func New(opts ...Option) (*operator, error) {
 		v.bufLocks = unique(append(v.bufLocks, bk.grpcBufLocks...))
 		v.bufConfigs = unique(append(v.bufConfigs, bk.grpcBufConfigs...))
 		v.bufModules = unique(append(v.bufModules, bk.grpcBufModules...))
+		if v.protoCacheDir == "" {
+			v.protoCacheDir = bk.grpcProtoCacheDir
+		}
 		if len(hostRules) > 0 {
 			v.hostRules = hostRules
 			if err := v.Renew(); err != nil {
 				return nil, err
 			}
 		}
+		if v.proxy == nil && v.target != "" {
+			v.proxy = bk.proxy
+		}
 		if v.operatorID == "" {
 			v.operatorID = o.id
 		}
@@ -585,6 +740,15 @@ func New(opts ...Option) (*operator, error) {
 		if len(hostRules) > 0 {
 			v.opts = append(v.opts, hostRules.chromedpOpt())
 		}
+		if v.proxy == nil {
+			v.proxy = bk.proxy
+		}
+		if v.proxy != nil {
+			if v.proxy.User != nil && v.proxy.Scheme != proxySchemeHTTP && v.proxy.Scheme != proxySchemeHTTPS {
+				return nil, fmt.Errorf("invalid proxy of CDP runner %q: Chrome does not support authentication of %s proxy", k, v.proxy.Scheme)
+			}
+			v.opts = append(v.opts, chromedpProxyOpt(v.proxy))
+		}
 		if err := v.Renew(); err != nil {
 			return nil, err
 		}
@@ -605,6 +769,33 @@ func New(opts ...Option) (*operator, error) {
 		}
 		o.sshRunners[k] = v
 	}
+	for k, v := range bk.websocketRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+			if err := v.Renew(); err != nil {
+				return nil, err
+			}
+		}
+		if v.operatorID == "" {
+			v.operatorID = o.id
+		}
+		o.websocketRunners[k] = v
+	}
+	for k, v := range bk.mockRunners {
+		o.mockRunners[k] = v
+	}
+	for k, v := range bk.queueRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+			if err := v.Renew(); err != nil {
+				return nil, err
+			}
+		}
+		if v.operatorID == "" {
+			v.operatorID = o.id
+		}
+		o.queueRunners[k] = v
+	}
 	for k, v := range bk.includeRunners {
 		o.includeRunners[k] = v
 	}
@@ -637,6 +828,24 @@ func New(opts ...Option) (*operator, error) {
 		}
 		keys[k] = struct{}{}
 	}
+	for k := range o.websocketRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range o.mockRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range o.queueRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
 	for k := range o.includeRunners {
 		if _, ok := keys[k]; ok {
 			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
@@ -758,6 +967,58 @@ func (o *operator) appendStep(idx int, key string, s map[string]any) error {
 		step.bindCond = cond
 		delete(s, bindRunnerKey)
 	}
+	// snapshot runner
+	if v, ok := s[snapshotRunnerKey]; ok {
+		step.snapshotRunner = newSnapshotRunner()
+		switch vv := v.(type) {
+		case string:
+			step.snapshotRequest = &snapshotRequest{
+				expr: vv,
+			}
+		case map[string]any:
+			expr, ok := vv["expr"]
+			if !ok {
+				return fmt.Errorf("invalid snapshot request: %v", vv)
+			}
+			var ignoreKeys []string
+			if keys, ok := vv["ignoreKeys"]; ok {
+				var err error
+				ignoreKeys, err = cast.ToStringSliceE(keys)
+				if err != nil {
+					return fmt.Errorf("invalid snapshot request: %v", vv)
+				}
+			}
+			step.snapshotRequest = &snapshotRequest{
+				expr:       cast.ToString(expr),
+				file:       cast.ToString(vv["file"]),
+				ignoreKeys: ignoreKeys,
+			}
+		default:
+			return fmt.Errorf("invalid snapshot request: %v", vv)
+		}
+		delete(s, snapshotRunnerKey)
+	}
+	// jsonSchema runner
+	if v, ok := s[jsonSchemaRunnerKey]; ok {
+		step.jsonSchemaRunner = newJSONSchemaRunner()
+		vv, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid jsonSchema request: %v", v)
+		}
+		schema, ok := vv["schema"]
+		if !ok {
+			return fmt.Errorf("invalid jsonSchema request: %v", vv)
+		}
+		expr, ok := vv["expr"]
+		if !ok {
+			return fmt.Errorf("invalid jsonSchema request: %v", vv)
+		}
+		step.jsonSchemaRequest = &jsonSchemaRequest{
+			schema: schema,
+			expr:   cast.ToString(expr),
+		}
+		delete(s, jsonSchemaRunnerKey)
+	}
 
 	k, v, ok := pop(s)
 	if ok {
@@ -775,6 +1036,13 @@ func (o *operator) appendStep(idx int, key string, s map[string]any) error {
 			}
 			c.step = step
 			step.includeConfig = c
+		case k == parallelRunnerKey:
+			step.parallelRunner = newParallelRunner()
+			c, err := parseParallelConfig(v)
+			if err != nil {
+				return err
+			}
+			step.parallelConfig = c
 		case k == execRunnerKey:
 			step.execRunner = newExecRunner()
 			vv, ok := v.(map[string]any)
@@ -842,6 +1110,39 @@ func (o *operator) appendStep(idx int, key string, s map[string]any) error {
 				step.sshCommand = vv
 				detected = true
 			}
+			wc, ok := o.websocketRunners[k]
+			if ok && !detected {
+				step.websocketRunner = wc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid WebSocket request: %v", v)
+				}
+				step.websocketRequest = vv
+				detected = true
+			}
+			mc, ok := o.mockRunners[k]
+			if ok && !detected {
+				step.mockRunner = mc
+				if v == nil {
+					v = map[string]any{}
+				}
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid mock request: %v", v)
+				}
+				step.mockRequest = vv
+				detected = true
+			}
+			qc, ok := o.queueRunners[k]
+			if ok && !detected {
+				step.queueRunner = qc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid Queue request: %v", v)
+				}
+				step.queueRequest = vv
+				detected = true
+			}
 			ic, ok := o.includeRunners[k]
 			if ok && !detected {
 				step.includeRunner = ic
@@ -882,6 +1183,16 @@ func (o *operator) Run(ctx context.Context) (err error) {
 		}
 		err = errors.Join(err, errr)
 	}()
+	tracer, err := o.otel.acquire()
+	if err != nil {
+		return err
+	}
+	o.tracer = tracer
+	defer func() {
+		if errr := o.otel.release(context.WithoutCancel(ctx)); errr != nil {
+			_, _ = fmt.Fprintf(o.stderr, "failed to export spans: %v\n", errr)
+		}
+	}()
 	if o.t != nil {
 		o.t.Helper()
 	}
@@ -935,8 +1246,15 @@ func (o *operator) clearResult() {
 	}
 }
 
-func (o *operator) run(ctx context.Context) error {
+func (o *operator) run(ctx context.Context) (rerr error) {
 	defer o.sw.Start(o.trails().toProfileIDs()...).Stop()
+	ctx, span := o.startSpan(ctx, o.generateTrail())
+	defer func() {
+		if o.Skipped() {
+			span.SetAttributes(otelAttrSkipped.Bool(true))
+		}
+		endSpan(span, rerr)
+	}()
 	if o.newOnly {
 		return errors.New("this runbook is not allowed to run")
 	}
@@ -1035,7 +1353,9 @@ func (o *operator) runLoop(ctx context.Context) error {
 		trs := o.trails()
 		o.capturers.setCurrentTrails(trs)
 		sw := o.sw.Start(trs.toProfileIDs()...)
-		err = o.runInternal(ctx)
+		lctx, lspan := o.startSpan(ctx, trs[len(trs)-1])
+		err = o.runInternal(lctx)
+		endSpan(lspan, err)
 		if err != nil {
 			sw.Stop()
 			looperr = errors.Join(looperr, fmt.Errorf("loop[%d]: %w", j, err))
@@ -1162,6 +1482,13 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 		o.sw.Stop(trsi...)
 	}
 
+	// Start mock servers before running steps so that steps can call them.
+	for _, r := range o.mockRunners {
+		if err := r.start(ctx); err != nil {
+			return err
+		}
+	}
+
 	// steps
 	failed := false
 	force := o.force
@@ -1294,6 +1621,7 @@ type operators struct {
 	t           *testing.T
 	sw          *stopw.Span
 	profile     bool
+	otel        *otelTracer
 	shuffle     bool
 	shuffleSeed int64
 	shardN      int
@@ -1328,6 +1656,7 @@ func Load(pathp string, opts ...Option) (*operators, error) {
 		t:           bk.t,
 		sw:          sw,
 		profile:     bk.profile,
+		otel:        bk.otel,
 		shuffle:     bk.runShuffle,
 		shuffleSeed: bk.runShuffleSeed,
 		shardN:      bk.runShardN,
@@ -1569,6 +1898,12 @@ func (ops *operators) CollectCoverage(ctx context.Context) (*Coverage, error) {
 			for k, v := range sc.Coverages {
 				spec.Coverages[k] += v
 			}
+			for k, v := range sc.Responses {
+				for kk, vv := range v {
+					spec.addResponses(k, kk)
+					spec.Responses[k][kk] += vv
+				}
+			}
 		}
 	}
 	sort.SliceStable(cov.Specs, func(i, j int) bool {
@@ -1607,6 +1942,15 @@ func (ops *operators) runN(ctx context.Context) (*runNResult, error) {
 	}
 	defer ops.sw.Start().Stop()
 	defer ops.Close()
+	tracer, err := ops.otel.acquire()
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err := ops.otel.release(context.WithoutCancel(ctx)); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to export spans: %v\n", err)
+		}
+	}()
 	cg, cctx := concgroup.WithContext(ctx)
 	cg.SetLimit(ops.concmax)
 	selected, err := ops.SelectedOperators()
@@ -1616,6 +1960,7 @@ func (ops *operators) runN(ctx context.Context) (*runNResult, error) {
 	result.Total.Add(int64(len(selected)))
 	for _, o := range selected {
 		o := o
+		o.tracer = tracer
 		cg.GoMulti(o.concurrency, func() error {
 			select {
 			case <-cctx.Done():
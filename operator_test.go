@@ -670,7 +670,7 @@ func TestShard(t *testing.T) {
 			}
 			sortOperators(got)
 			allow := []any{
-				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, includeRunner{},
+				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, queueRunner{}, mockRunner{}, includeRunner{},
 			}
 			ignore := []any{
 				step{}, store{}, sql.DB{}, os.File{}, stopw.Span{}, debugger{}, nest.DB{}, Loop{}, hostRule{},
@@ -682,8 +682,11 @@ func TestShard(t *testing.T) {
 				cmpopts.IgnoreFields(operator{}, "id", "concurrency", "mu", "dbg"),
 				cmpopts.IgnoreFields(cdpRunner{}, "ctx", "cancel", "opts", "mu", "operatorID"),
 				cmpopts.IgnoreFields(sshRunner{}, "client", "sess", "stdin", "stdout", "stderr", "operatorID"),
-				cmpopts.IgnoreFields(grpcRunner{}, "mu", "operatorID"),
+				cmpopts.IgnoreFields(httpRunner{}, "mu", "operatorID"),
+				cmpopts.IgnoreFields(grpcRunner{}, "mu", "operatorID", "protoMu"),
 				cmpopts.IgnoreFields(dbRunner{}, "operatorID"),
+				cmpopts.IgnoreFields(queueRunner{}, "client", "mu", "operatorID"),
+				cmpopts.IgnoreFields(mockRunner{}, "mu"),
 				cmpopts.IgnoreFields(RunResult{}, "included"),
 				cmpopts.IgnoreFields(http.Client{}, "Transport"),
 			}
@@ -1358,3 +1361,56 @@ func TestRunUsingHTTPOpenAPI3(t *testing.T) {
 		})
 	}
 }
+
+func TestRunJSONSchema(t *testing.T) {
+	tests := []struct {
+		user    string
+		wantErr string
+	}{
+		{`{ id: 1, name: alice, address: { city: Tokyo } }`, ""},
+		{`{ id: 0, name: alice, address: { zip: "1000001" } }`, "(errors) => /address/zip: does not match pattern"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			// The schema is loaded relative to the root of the runbook.
+			f, err := os.CreateTemp("testdata", "jsonschema*.yml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = os.Remove(f.Name())
+			})
+			book := fmt.Sprintf(`desc: Validate using JSON Schema
+vars:
+  user: %s
+steps:
+  -
+    test: len(jsonschema("jsonschema/user.json", vars.user)) == 0
+`, tt.user)
+			if _, err := f.WriteString(book); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+			o, err := New(Book(f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant contains %q", err, tt.wantErr)
+			}
+		})
+	}
+}
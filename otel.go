@@ -0,0 +1,281 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/k1LoW/runn/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/metadata"
+)
+
+const otelTracerName = "github.com/k1LoW/runn"
+
+const (
+	otelAttrRunbookID      = attribute.Key("runn.runbook.id")
+	otelAttrRunbookPath    = attribute.Key("runn.runbook.path")
+	otelAttrRunbookDesc    = attribute.Key("runn.runbook.desc")
+	otelAttrStepIndex      = attribute.Key("runn.step.index")
+	otelAttrStepKey        = attribute.Key("runn.step.key")
+	otelAttrStepDesc       = attribute.Key("runn.step.desc")
+	otelAttrStepRunnerType = attribute.Key("runn.step.runner_type")
+	otelAttrStepRunnerKey  = attribute.Key("runn.step.runner_key")
+	otelAttrLoopIndex      = attribute.Key("runn.loop.index")
+	otelAttrRetryIndex     = attribute.Key("runn.retry.index")
+	otelAttrSkipped        = attribute.Key("runn.skipped")
+)
+
+var otelPropagator = propagation.TraceContext{}
+
+var noopTracer = noop.NewTracerProvider().Tracer(otelTracerName)
+
+// otelTracer - Tracer provider that is created lazily on the first run and shut down when all runs are finished.
+type otelTracer struct {
+	newProvider func() (*sdktrace.TracerProvider, func() error, error)
+	tp          *sdktrace.TracerProvider
+	closeFn     func() error
+	refs        int
+	mu          sync.Mutex
+}
+
+func newOTelTracer(endpoint string) (*otelTracer, error) {
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		switch u.Scheme {
+		case "http", "https", "grpc", "file":
+		default:
+			return nil, fmt.Errorf("unsupported OpenTelemetry endpoint: %s", endpoint)
+		}
+	}
+	return &otelTracer{
+		newProvider: func() (*sdktrace.TracerProvider, func() error, error) {
+			return newOTelTracerProvider(endpoint)
+		},
+	}, nil
+}
+
+func newOTelTracerWithExporter(exp sdktrace.SpanExporter) *otelTracer {
+	return &otelTracer{
+		newProvider: func() (*sdktrace.TracerProvider, func() error, error) {
+			// The exporter is owned by the caller, so it is not shut down with the tracer provider.
+			return newOTelTracerProviderWithExporter(&unownedSpanExporter{SpanExporter: exp}, true), nil, nil
+		},
+	}
+}
+
+// acquire returns the tracer. The tracer provider is created if it does not exist.
+func (t *otelTracer) acquire() (oteltrace.Tracer, error) {
+	if t == nil {
+		return noopTracer, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tp == nil {
+		tp, closeFn, err := t.newProvider()
+		if err != nil {
+			return nil, err
+		}
+		t.tp = tp
+		t.closeFn = closeFn
+	}
+	t.refs++
+	return t.tp.Tracer(otelTracerName), nil
+}
+
+// release shuts down the tracer provider ( exports the remaining spans ) when all acquired tracers are released.
+func (t *otelTracer) release(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tp == nil {
+		return nil
+	}
+	t.refs--
+	if t.refs > 0 {
+		return nil
+	}
+	err := t.tp.Shutdown(ctx)
+	if t.closeFn != nil {
+		err = errors.Join(err, t.closeFn())
+	}
+	t.tp = nil
+	t.closeFn = nil
+	return err
+}
+
+// unownedSpanExporter - sdktrace.SpanExporter that does not shut down the exporter.
+type unownedSpanExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e *unownedSpanExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// newOTelTracerProvider returns a tracer provider that exports spans to the endpoint, and the function to close the resources of it.
+// The endpoint is one of the following.
+//   - http://host:port , https://host:port ( OTLP/HTTP )
+//   - grpc://host:port ( OTLP/gRPC without TLS )
+//   - file:///path/to/spans.json , /path/to/spans.json ( JSON lines file )
+func newOTelTracerProvider(endpoint string) (*sdktrace.TracerProvider, func() error, error) {
+	ctx := context.Background()
+	if !strings.Contains(endpoint, "://") {
+		return newOTelFileTracerProvider(endpoint)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	var exp sdktrace.SpanExporter
+	switch u.Scheme {
+	case "http", "https":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	case "grpc":
+		exp, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(u.Host), otlptracegrpc.WithInsecure())
+	case "file":
+		return newOTelFileTracerProvider(strings.TrimPrefix(endpoint, "file://"))
+	default:
+		return nil, nil, fmt.Errorf("unsupported OpenTelemetry endpoint: %s", endpoint)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return newOTelTracerProviderWithExporter(exp, false), nil, nil
+}
+
+func newOTelFileTracerProvider(p string) (*sdktrace.TracerProvider, func() error, error) {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		return nil, nil, errors.Join(err, f.Close())
+	}
+	return newOTelTracerProviderWithExporter(exp, true), f.Close, nil
+}
+
+func newOTelTracerProviderWithExporter(exp sdktrace.SpanExporter, sync bool) *sdktrace.TracerProvider {
+	var sp sdktrace.TracerProviderOption
+	if sync {
+		sp = sdktrace.WithSyncer(exp)
+	} else {
+		sp = sdktrace.WithBatcher(exp)
+	}
+	return sdktrace.NewTracerProvider(
+		sp,
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("runn"),
+			semconv.ServiceVersion(version.Version),
+		)),
+	)
+}
+
+// startSpan starts the span of the trail.
+func (o *operator) startSpan(ctx context.Context, tr Trail) (context.Context, oteltrace.Span) {
+	var attrs []attribute.KeyValue
+	switch tr.Type {
+	case TrailTypeRunbook:
+		attrs = append(attrs,
+			otelAttrRunbookID.String(tr.RunbookID),
+			otelAttrRunbookPath.String(normalizePath(tr.RunbookPath)),
+		)
+		if tr.Desc != "" {
+			attrs = append(attrs, otelAttrRunbookDesc.String(tr.Desc))
+		}
+	case TrailTypeStep:
+		attrs = append(attrs, otelAttrStepKey.String(tr.StepKey))
+		if tr.StepIndex != nil {
+			attrs = append(attrs, otelAttrStepIndex.Int(*tr.StepIndex))
+		}
+		if tr.Desc != "" {
+			attrs = append(attrs, otelAttrStepDesc.String(tr.Desc))
+		}
+		if tr.StepRunnerType != "" {
+			attrs = append(attrs, otelAttrStepRunnerType.String(string(tr.StepRunnerType)))
+		}
+		if tr.StepRunnerKey != "" {
+			attrs = append(attrs, otelAttrStepRunnerKey.String(tr.StepRunnerKey))
+		}
+	case TrailTypeLoop:
+		attrs = append(attrs, otelAttrLoopIndex.Int(*tr.LoopIndex))
+	case TrailTypeRetry:
+		attrs = append(attrs, otelAttrRetryIndex.Int(*tr.RetryIndex))
+	}
+	return o.tracer.Start(ctx, tr.String(), oteltrace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span with the result.
+func endSpan(span oteltrace.Span, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, errStepSkiped):
+		span.SetAttributes(otelAttrSkipped.Bool(true))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext sets W3C Trace Context headers of the current span.
+func injectTraceContext(ctx context.Context, h http.Header) {
+	otelPropagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// injectTraceContextToMetadata sets W3C Trace Context metadata of the current span.
+func injectTraceContextToMetadata(ctx context.Context, md metadata.MD) {
+	otelPropagator.Inject(ctx, metadataCarrier(md))
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier(nil)
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	v := metadata.MD(c).Get(key)
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
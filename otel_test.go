@@ -0,0 +1,159 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestOTelSpans(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	exp := tracetest.NewInMemoryExporter()
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/otel.yml"), OTelSpanExporter(exp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	spans := exp.GetSpans()
+	names := map[string]tracetest.SpanStub{}
+	var got []string
+	for _, s := range spans {
+		got = append(got, s.Name)
+		names[s.Name] = s
+	}
+	want := []string{
+		"steps[getUsers]",
+		"loop[0]",
+		"loop[1]",
+		"steps[retry]",
+		"steps[0]",
+		"steps[1]",
+		"steps[2]",
+		"runbook[testdata/book/always_success.yml]",
+		"steps[include]",
+		"runbook[testdata/book/otel.yml]",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+
+	t.Run("Hierarchy", func(t *testing.T) {
+		root := names["runbook[testdata/book/otel.yml]"]
+		for _, s := range spans {
+			if s.SpanContext.TraceID() != root.SpanContext.TraceID() {
+				t.Errorf("%s is not in the same trace", s.Name)
+			}
+		}
+		parents := map[string]string{
+			"steps[getUsers]": "runbook[testdata/book/otel.yml]",
+			"loop[0]":         "steps[retry]",
+			"steps[include]":  "runbook[testdata/book/otel.yml]",
+			"runbook[testdata/book/always_success.yml]": "steps[include]",
+			"steps[0]": "runbook[testdata/book/always_success.yml]",
+		}
+		for c, p := range parents {
+			if names[c].Parent.SpanID() != names[p].SpanContext.SpanID() {
+				t.Errorf("parent of %s should be %s", c, p)
+			}
+		}
+	})
+
+	t.Run("Propagation", func(t *testing.T) {
+		s := names["steps[getUsers]"]
+		want := "00-" + s.SpanContext.TraceID().String() + "-" + s.SpanContext.SpanID().String() + "-01"
+		if traceparent != want {
+			t.Errorf("got %q\nwant %q", traceparent, want)
+		}
+	})
+}
+
+func TestOTelSpansWithFailure(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	o, err := New(Book("testdata/book/always_failure.yml"), OTelSpanExporter(exp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = o.Run(context.Background())
+	for _, s := range exp.GetSpans() {
+		switch s.Name {
+		case "runbook[testdata/book/always_failure.yml]":
+			if s.Status.Code != codes.Error {
+				t.Errorf("got %v want %v", s.Status.Code, codes.Error)
+			}
+			if len(s.Events) == 0 {
+				t.Error("error event should be recorded")
+			}
+		}
+	}
+}
+
+func TestOTelTraceFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "spans.json")
+	opt := OTelTrace(p)
+	for i := 0; i < 2; i++ {
+		ops, err := Load("testdata/book/always_success.yml", opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ops.RunN(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		// The tracer provider is shut down after the runs, and created again in the next runs.
+		if ops.otel.tp != nil {
+			t.Error("the tracer provider should be shut down")
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(b), `"Name":"runbook[testdata/book/always_success.yml]"`); got != i+1 {
+			t.Errorf("got %v\nwant %v", got, i+1)
+		}
+	}
+}
+
+func TestOTelTraceLazy(t *testing.T) {
+	// The tracer provider is not created until the runbook runs.
+	o, err := New(Book("testdata/book/always_success.yml"), OTelTrace(filepath.Join(t.TempDir(), "notexist", "spans.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err == nil {
+		t.Error("want error")
+	}
+
+	if _, err := New(OTelTrace("unknown://localhost:4317")); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestMetadataCarrier(t *testing.T) {
+	md := metadata.MD{}
+	c := propagation.TextMapCarrier(metadataCarrier(md))
+	c.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if got := md.Get("traceparent"); len(got) != 1 {
+		t.Errorf("got %v", got)
+	}
+	if diff := cmp.Diff(c.Keys(), []string{"traceparent"}); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,182 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/stopw"
+)
+
+const parallelRunnerKey = "parallel"
+
+type parallelRunner struct{}
+
+type parallelConfig struct {
+	branches []*parallelBranch
+}
+
+// parallelBranch - A step that runs concurrently with other branches.
+type parallelBranch struct {
+	key     string
+	rawStep map[string]any
+}
+
+func newParallelRunner() *parallelRunner {
+	return &parallelRunner{}
+}
+
+func (rnr *parallelRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	c := s.parallelConfig
+	if o.thisT != nil {
+		o.thisT.Helper()
+	}
+	var (
+		ops  []*operator
+		bufs []*captureBuffer
+	)
+	for _, b := range c.branches {
+		oo, buf, err := o.newBranchOperator(s, b)
+		if err != nil {
+			return fmt.Errorf("invalid branch %q: %w", b.key, err)
+		}
+		ops = append(ops, oo)
+		bufs = append(bufs, buf)
+	}
+
+	errs := make([]error, len(ops))
+	wg := &sync.WaitGroup{}
+	for i, oo := range ops {
+		i, oo := i, oo
+		// donegroup cannot derive contexts from the same context concurrently, so derive the context of the branch in advance.
+		bctx, cancel := donegroup.WithCancel(ctx)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				cancel()
+				errs[i] = errors.Join(errs[i], donegroup.Wait(bctx))
+			}()
+			if err := oo.run(bctx); err != nil {
+				errs[i] = fmt.Errorf("branch %q failed: %w", oo.branchKey, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// join
+	v := map[string]any{}
+	for i, oo := range ops {
+		v[oo.branchKey] = oo.store.stepMap[oo.branchKey]
+		o.mergeBranchProfile(oo)
+		bufs[i].replay(o.capturers)
+	}
+	o.capturers.setCurrentTrails(s.trails())
+	o.record(v)
+	return errors.Join(errs...)
+}
+
+// newBranchOperator creates nested operator that runs the branch.
+// The events captured by the branch are buffered and have to be replayed after the branch finishes.
+func (o *operator) newBranchOperator(parent *step, b *parallelBranch) (*operator, *captureBuffer, error) {
+	oo, err := o.newNestedOperator(parent, parallelBranchBook(o.bookPath, o.desc, b))
+	if err != nil {
+		return nil, nil, err
+	}
+	oo.branchKey = b.key
+	// Capturers are not safe for concurrent use, so the branch captures into its own buffer.
+	var buf *captureBuffer
+	oo.capturers, buf = o.capturers.forBranch(oo)
+	// stopw.Span is not safe for concurrent use, so each branch measures with its own stopwatch.
+	oo.sw = stopw.New()
+	if o.sw.Result() == nil {
+		oo.sw.Disable()
+	}
+	// Branches share vars of the parent runbook. Bound variables and cookies are copied so that branches do not interfere with each other.
+	oo.store.vars = o.store.vars
+	for k, v := range o.store.bindVars {
+		oo.store.bindVars[k] = v
+	}
+	if o.store.cookies != nil {
+		oo.store.cookies = make(map[string]map[string]*http.Cookie, len(o.store.cookies))
+		for domain, cookies := range o.store.cookies {
+			oo.store.cookies[domain] = maps.Clone(cookies)
+		}
+	}
+	return oo, buf, nil
+}
+
+// mergeBranchProfile merges the profile of the branch into the profile of the operator.
+func (o *operator) mergeBranchProfile(oo *operator) {
+	if o.sw.Result() == nil || oo.sw.Result() == nil {
+		return
+	}
+	ids := oo.trails().toProfileIDs()
+	src := oo.sw.New(ids...)
+	dst := o.sw.New(ids...)
+	dst.StartedAt = src.StartedAt
+	dst.StoppedAt = src.StoppedAt
+	dst.Breakdown = src.Breakdown
+	dst.Repair()
+}
+
+// parallelBranchBook - Load the branch as a runbook with a single step.
+func parallelBranchBook(path, desc string, b *parallelBranch) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if err := validateStepKeys(b.rawStep); err != nil {
+			return err
+		}
+		bk.path = path
+		bk.desc = desc
+		bk.useMap = true
+		s, ok := dcopy(b.rawStep).(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid branch: %v", b.rawStep)
+		}
+		bk.rawSteps = []map[string]any{s}
+		bk.stepKeys = []string{b.key}
+		return nil
+	}
+}
+
+func parseParallelConfig(v any) (*parallelConfig, error) {
+	c := &parallelConfig{}
+	switch vv := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s, ok := vv[k].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid parallel branch %q: %v", k, vv[k])
+			}
+			c.branches = append(c.branches, &parallelBranch{key: k, rawStep: s})
+		}
+	case []any:
+		for i, vvv := range vv {
+			s, ok := vvv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid parallel branch %d: %v", i, vvv)
+			}
+			c.branches = append(c.branches, &parallelBranch{key: fmt.Sprintf("%d", i), rawStep: s})
+		}
+	default:
+		return nil, fmt.Errorf("invalid parallel config: %v", v)
+	}
+	if len(c.branches) == 0 {
+		return nil, fmt.Errorf("invalid parallel config: no branches: %v", v)
+	}
+	return c, nil
+}
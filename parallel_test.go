@@ -0,0 +1,143 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParallelRunner(t *testing.T) {
+	// The handler blocks until both requests arrive, so the runbook can only pass when the branches run concurrently.
+	barrier := &sync.WaitGroup{}
+	barrier.Add(2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		barrier.Done()
+		done := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+		if r.URL.Path == "/users" && (r.URL.Query().Get("name") != "alice" || r.Header.Get("Authorization") != "Bearer secret") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/parallel.yml"), Profile(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Store", func(t *testing.T) {
+		warm, ok := o.store.stepMap["warm"]
+		if !ok {
+			t.Fatal("steps.warm is not recorded")
+		}
+		var got []string
+		for k := range warm {
+			got = append(got, k)
+		}
+		want := []string{"count", "outcome", "projects", "users"}
+		sort.Strings(got)
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Trails", func(t *testing.T) {
+		r := o.sw.Result()
+		var got []string
+		for _, rr := range r.Breakdown[0].Breakdown {
+			tr, ok := rr.ID.(Trail)
+			if !ok || tr.StepKey != "warm" {
+				continue
+			}
+			for _, b := range rr.Breakdown {
+				got = append(got, b.ID.(Trail).String())
+			}
+		}
+		sort.Strings(got)
+		want := []string{"branch[count]", "branch[projects]", "branch[users]"}
+		sort.Strings(got)
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestParallelRunnerFailure(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/parallel_failure.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Run(ctx)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{`branch "1" failed`, `branch "2" failed`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v\nwant to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), `branch "0" failed`) {
+		t.Errorf("got %v", err)
+	}
+	got := o.store.steps[0]["0"].(map[string]any)[storeStepKeyOutcome]
+	if got != resultSuccess {
+		t.Errorf("got %v want %v", got, resultSuccess)
+	}
+}
+
+func TestParseParallelConfig(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    []string
+		wantErr bool
+	}{
+		{map[string]any{"b": map[string]any{"test": true}, "a": map[string]any{"test": true}}, []string{"a", "b"}, false},
+		{[]any{map[string]any{"test": true}, map[string]any{"test": true}}, []string{"0", "1"}, false},
+		{map[string]any{"a": "test"}, nil, true},
+		{[]any{}, nil, true},
+		{"invalid", nil, true},
+	}
+	for _, tt := range tests {
+		c, err := parseParallelConfig(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+			continue
+		}
+		var got []string
+		for _, b := range c.branches {
+			got = append(got, b.key)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
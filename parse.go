@@ -1,12 +1,14 @@
 package runn
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/k1LoW/duration"
 	"github.com/k1LoW/runn/tmpmod/github.com/goccy/go-yaml"
 	"google.golang.org/grpc/metadata"
@@ -58,6 +60,28 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			gm, ok := vvvvv["graphql"]
+			if ok && gm != nil {
+				if _, ok := vvvvv["body"]; ok {
+					return nil, fmt.Errorf("invalid request: body and graphql cannot be used at the same time: %s", string(part))
+				}
+				gr, err := parseGraphQLRequest(gm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+				req.graphql = gr
+				if req.method == http.MethodGet {
+					// GraphQL over HTTP GET sends the request as query parameters
+					p, err := gr.appendQuery(req.path)
+					if err != nil {
+						return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+					}
+					req.path = p
+				} else {
+					req.mediaType = MediaTypeApplicationJSON
+					req.body = gr.body()
+				}
+			}
 			bm, ok := vvvvv["body"]
 			if ok {
 				switch v := bm.(type) {
@@ -98,6 +122,25 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			rm, ok := vvvvv["responseMessageType"]
+			if ok {
+				v, ok := rm.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.responseMessageType = v
+			}
+			sm, ok := vvvvv["sse"]
+			if ok {
+				c, err := parseSSEConfig(sm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+				req.sse = c
+				if req.headers.Get("Accept") == "" {
+					req.headers.Set("Accept", MediaTypeTextEventStream)
+				}
+			}
 		}
 
 		break
@@ -108,6 +151,34 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 	return req, nil
 }
 
+func parseGraphQLRequest(v any) (*graphqlRequest, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid graphql request: %v", v)
+	}
+	req := &graphqlRequest{}
+	q, ok := m[graphqlQueryKey].(string)
+	if !ok || strings.TrimSpace(q) == "" {
+		return nil, errors.New("graphql query is required")
+	}
+	req.query = q
+	if vars, ok := m[graphqlVariablesKey]; ok && vars != nil {
+		vm, ok := vars.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid graphql variables: %v", vars)
+		}
+		req.variables = vm
+	}
+	if on, ok := m[graphqlOperationNameKey]; ok && on != nil {
+		ons, ok := on.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid graphql operationName: %v", on)
+		}
+		req.operationName = ons
+	}
+	return req, nil
+}
+
 func parseDBQuery(v map[string]any) (*dbQuery, error) {
 	q := &dbQuery{}
 	part, err := yaml.Marshal(v)
@@ -283,6 +354,151 @@ func parseGrpcRequest(v map[string]any, expand func(any) (any, error)) (*grpcReq
 	return req, nil
 }
 
+func parseWebSocketRequest(v map[string]any, expand func(any) (any, error)) (*websocketRequest, error) {
+	v = trimDelimiter(v)
+	req := &websocketRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tm, ok := v["timeout"]
+	if ok {
+		tme, err := expand(tm)
+		if err != nil {
+			return nil, err
+		}
+		tms, ok := tme.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		req.timeout, err = duration.Parse(tms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+		}
+	}
+	mm, ok := v["messages"]
+	if !ok {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	mms, ok := mm.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	// `send:` expands at run time so not here
+	for _, mm := range mms {
+		switch v := mm.(type) {
+		case string:
+			op := WebSocketOp(v)
+			if op != WebSocketOpClose && op != WebSocketOpReceive {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.messages = append(req.messages, &websocketMessage{
+				op: op,
+			})
+		case map[string]any:
+			if len(v) != 1 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			s, ok := v[string(WebSocketOpSend)]
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.messages = append(req.messages, &websocketMessage{
+				op:     WebSocketOpSend,
+				params: s,
+			})
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
+func parseQueueRequest(v map[string]any) (*queueRequest, error) {
+	req := &queueRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != 1 {
+		return nil, fmt.Errorf("invalid queue request: %s", string(part))
+	}
+	for k, vv := range v {
+		m, ok := vv.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid queue request: %s", string(part))
+		}
+		t, ok := m["topic"].(string)
+		if !ok || t == "" {
+			return nil, fmt.Errorf("invalid queue request: topic is required: %s", string(part))
+		}
+		switch queueOp(k) {
+		case queueOpProduce:
+			req.op = queueOpProduce
+			qm := &QueueMessage{
+				Topic:   t,
+				Headers: map[string]string{},
+			}
+			if key, ok := m["key"]; ok {
+				qm.Key = []byte(fmt.Sprintf("%v", key))
+			}
+			switch val := m["value"].(type) {
+			case nil:
+			case string:
+				qm.Value = []byte(val)
+			default:
+				b, err := json.Marshal(val)
+				if err != nil {
+					return nil, fmt.Errorf("invalid queue request: %s: %w", string(part), err)
+				}
+				qm.Value = b
+			}
+			if hs, ok := m["headers"]; ok {
+				hm, ok := hs.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("invalid queue request: %s", string(part))
+				}
+				for hk, hv := range hm {
+					qm.Headers[hk] = fmt.Sprintf("%v", hv)
+				}
+			}
+			req.message = qm
+		case queueOpConsume:
+			req.op = queueOpConsume
+			req.topic = t
+			req.count = 1
+			if c, ok := m["count"]; ok {
+				switch cv := c.(type) {
+				case int:
+					req.count = cv
+				case uint64:
+					req.count = int(cv)
+				case float64:
+					req.count = int(cv)
+				default:
+					return nil, fmt.Errorf("invalid queue request: %s", string(part))
+				}
+				if req.count < 1 {
+					return nil, fmt.Errorf("invalid queue request: count should be greater than 0: %s", string(part))
+				}
+			}
+			if tm, ok := m["timeout"]; ok {
+				tms, ok := tm.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid queue request: %s", string(part))
+				}
+				req.timeout, err = duration.Parse(tms)
+				if err != nil {
+					return nil, fmt.Errorf("invalid queue request: %s: %w", string(part), err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid queue request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
 func parseCDPActions(v map[string]any, expand func(any) (any, error)) (CDPActions, error) {
 	v = trimDelimiter(v)
 	cas := CDPActions{}
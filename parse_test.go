@@ -156,6 +156,64 @@ func TestParseHTTPRequest(t *testing.T) {
     body: null
     useCookie: true
 	trace: "true"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      query: 'query GetUser($id: ID!) { user(id: $id) { name } }'
+      variables:
+        id: 1
+      operationName: GetUser
+`,
+			&httpRequest{
+				path:      "/graphql",
+				method:    http.MethodPost,
+				mediaType: MediaTypeApplicationJSON,
+				headers:   http.Header{},
+				body: map[string]any{
+					"query":         "query GetUser($id: ID!) { user(id: $id) { name } }",
+					"variables":     map[string]any{"id": uint64(1)},
+					"operationName": "GetUser",
+				},
+				graphql: &graphqlRequest{
+					query:         "query GetUser($id: ID!) { user(id: $id) { name } }",
+					variables:     map[string]any{"id": uint64(1)},
+					operationName: "GetUser",
+				},
+			},
+			false,
+		},
+		{
+			`
+/graphql:
+  get:
+    graphql:
+      query: '{ users { name } }'
+`,
+			&httpRequest{
+				path:    "/graphql?query=%7B+users+%7B+name+%7D+%7D",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				graphql: &graphqlRequest{
+					query: "{ users { name } }",
+				},
+			},
+			false,
+		},
+		{
+			`
+/graphql:
+  post:
+    body:
+      application/json:
+        key: value
+    graphql:
+      query: '{ users { name } }'
 `,
 			nil,
 			true,
@@ -177,7 +235,7 @@ func TestParseHTTPRequest(t *testing.T) {
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(httpRequest{})
+		opts := cmp.AllowUnexported(httpRequest{}, graphqlRequest{})
 		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
 			t.Error(diff)
 		}
@@ -461,6 +519,84 @@ stdin: |
 	}
 }
 
+func TestParseWebSocketRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *websocketRequest
+		wantErr bool
+	}{
+		{
+			`
+messages:
+  - send: hello
+  - receive
+  - close
+`,
+			&websocketRequest{
+				messages: []*websocketMessage{
+					{op: WebSocketOpSend, params: "hello"},
+					{op: WebSocketOpReceive},
+					{op: WebSocketOpClose},
+				},
+			},
+			false,
+		},
+		{
+			`
+timeout: 3sec
+messages:
+  - send:
+      greeting: hello
+  - receive
+`,
+			&websocketRequest{
+				messages: []*websocketMessage{
+					{op: WebSocketOpSend, params: map[string]any{"greeting": "hello"}},
+					{op: WebSocketOpReceive},
+				},
+				timeout: 3 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+messages:
+  - open
+`,
+			nil,
+			true,
+		},
+		{
+			`
+timeout: 3sec
+`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseWebSocketRequest(v, func(in any) (any, error) { return in, nil })
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(websocketRequest{}, websocketMessage{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
 func TestTrimDelimiter(t *testing.T) {
 	tests := []struct {
 		in   map[string]any
@@ -0,0 +1,219 @@
+package runn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const protosetExt = ".protoset"
+
+// protoDescriptorCacheTTL - Cached descriptors not used for this duration are removed.
+const protoDescriptorCacheTTL = 7 * 24 * time.Hour
+
+// loadProtosets loads the protoset ( FileDescriptorSet ) files, and registers them to protoregistry.GlobalFiles.
+func loadProtosets(protosetPaths []string) (linker.Files, error) {
+	paths, err := fetchPaths(strings.Join(protosetPaths, string(os.PathListSeparator)))
+	if err != nil {
+		return nil, err
+	}
+	var fds linker.Files
+	for _, p := range paths {
+		b, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(b, set); err != nil {
+			return nil, fmt.Errorf("invalid protoset %s: %w", p, err)
+		}
+		files, err := filesFromDescriptorSet(set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protoset %s: %w", p, err)
+		}
+		if err := registerFiles(files); err != nil {
+			return nil, err
+		}
+		fds = append(fds, files...)
+	}
+	return fds, nil
+}
+
+// filesFromDescriptorSet converts the FileDescriptorSet to linker.Files.
+// The dependencies that are not contained in the set are resolved using protoregistry.GlobalFiles.
+func filesFromDescriptorSet(set *descriptorpb.FileDescriptorSet) (linker.Files, error) {
+	fdps := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, fdp := range set.GetFile() {
+		fdps[fdp.GetName()] = fdp
+	}
+	r := &protosetResolver{files: new(protoregistry.Files)}
+	var add func(path string) error
+	add = func(path string) error {
+		if _, err := r.files.FindFileByPath(path); err == nil {
+			return nil
+		}
+		fdp, ok := fdps[path]
+		if !ok {
+			// Resolve using protoregistry.GlobalFiles
+			return nil
+		}
+		for _, dep := range fdp.GetDependency() {
+			if err := add(dep); err != nil {
+				return err
+			}
+		}
+		fd, err := protodesc.NewFile(fdp, r)
+		if err != nil {
+			return err
+		}
+		return r.files.RegisterFile(fd)
+	}
+	var files linker.Files
+	for _, fdp := range set.GetFile() {
+		if err := add(fdp.GetName()); err != nil {
+			return nil, err
+		}
+		fd, err := r.files.FindFileByPath(fdp.GetName())
+		if err != nil {
+			return nil, err
+		}
+		f, err := linker.NewFileRecursive(fd)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// descriptorSetFromFiles converts the files and their dependencies to the FileDescriptorSet.
+func descriptorSetFromFiles(fds linker.Files) *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]struct{}{}
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+		// Dependencies first
+		for i := 0; i < fd.Imports().Len(); i++ {
+			add(fd.Imports().Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, fd := range fds {
+		add(fd)
+	}
+	return set
+}
+
+// protosetResolver - protodesc.Resolver that resolves descriptors in the protoset and protoregistry.GlobalFiles.
+type protosetResolver struct {
+	files *protoregistry.Files
+}
+
+func (r *protosetResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := r.files.FindFileByPath(path)
+	if err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *protosetResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	d, err := r.files.FindDescriptorByName(name)
+	if err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// readProtoDescriptorCache reads the compiled descriptors of the protos from the on-disk cache in dir.
+func readProtoDescriptorCache(dir, hash string, protos []string) (linker.Files, error) {
+	p := filepath.Join(dir, hash+protosetExt)
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the used cache from being pruned
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, err
+	}
+	files, err := filesFromDescriptorSet(set)
+	if err != nil {
+		return nil, err
+	}
+	// Return only the files of the protos in the same way as compiling
+	var fds linker.Files
+	for _, p := range protos {
+		f := files.FindFileByPath(p)
+		if f == nil {
+			return nil, fmt.Errorf("the cache does not contain %s", p)
+		}
+		fds = append(fds, f)
+	}
+	return fds, nil
+}
+
+// writeProtoDescriptorCache writes the compiled descriptors to the on-disk cache in dir.
+func writeProtoDescriptorCache(dir, hash string, fds linker.Files) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(descriptorSetFromFiles(fds))
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it so that concurrent runs do not read a partially written cache.
+	f, err := os.CreateTemp(dir, hash+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), filepath.Join(dir, hash+protosetExt)); err != nil {
+		return err
+	}
+	return pruneProtoDescriptorCache(dir, time.Now().Add(-protoDescriptorCacheTTL))
+}
+
+// pruneProtoDescriptorCache removes the cached descriptors ( and the leftover temporary files ) not used since before.
+func pruneProtoDescriptorCache(dir string, before time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || (filepath.Ext(e.Name()) != protosetExt && filepath.Ext(e.Name()) != ".tmp") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().Before(before) {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+	return nil
+}
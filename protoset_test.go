@@ -0,0 +1,170 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGRPCRunnerProtosets(t *testing.T) {
+	o, err := New(Book("testdata/grpc_protoset.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadProtosets(t *testing.T) {
+	fds, err := loadProtosets([]string{"testdata/mock/greeter.protoset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fds) != 1 {
+		t.Fatalf("got %v\nwant %v", len(fds), 1)
+	}
+	svc := fds[0].Services().ByName("Greeter")
+	if svc == nil {
+		t.Fatal("mock.Greeter not found")
+	}
+	if got := svc.Methods().Len(); got != 2 {
+		t.Errorf("got %v\nwant %v", got, 2)
+	}
+
+	if _, err := loadProtosets([]string{"testdata/mock/greeter.proto"}); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestProtoDescriptorCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	src := `syntax = "proto3";
+
+package cachetest;
+
+import "google/protobuf/timestamp.proto";
+
+service CacheTest {
+  rpc Now(NowRequest) returns (NowResponse);
+}
+
+message NowRequest {}
+
+message NowResponse {
+  google.protobuf.Timestamp now = 1;
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "cachetest.proto"), []byte(src), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compileProtos(context.Background(), cacheDir, []string{dir}, nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %v\nwant %v", len(entries), 1)
+	}
+	hash := strings.TrimSuffix(entries[0].Name(), protosetExt)
+	fds, err := readProtoDescriptorCache(cacheDir, hash, []string{"cachetest.proto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := fds[0].Services().ByName("CacheTest").Methods().ByName("Now")
+	if got := string(md.Output().Fields().ByName("now").Message().FullName()); got != "google.protobuf.Timestamp" {
+		t.Errorf("got %v\nwant %v", got, "google.protobuf.Timestamp")
+	}
+	if _, err := readProtoDescriptorCache(cacheDir, hash, []string{"notexist.proto"}); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestProtoDescriptorCacheWithChangedImports(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	src := `syntax = "proto3";
+
+package cacheimporttest;
+
+import "user.proto";
+
+service CacheImportTest {
+  rpc Get(GetRequest) returns (User);
+}
+
+message GetRequest {}
+`
+	if err := os.WriteFile(filepath.Join(dir, "service.proto"), []byte(src), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for i, fields := range []string{"string name = 1;", "string name = 1;\n  int32 age = 2;"} {
+		// Only the imported file is changed
+		user := "syntax = \"proto3\";\n\npackage cacheimporttest;\n\nmessage User {\n  " + fields + "\n}\n"
+		if err := os.WriteFile(filepath.Join(dir, "user.proto"), []byte(user), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		fds, err := compileProtos(ctx, cacheDir, []string{dir}, nil, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md := fds.FindFileByPath("service.proto").Services().ByName("CacheImportTest").Methods().ByName("Get")
+		if got, want := md.Output().Fields().Len(), i+1; got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+		entries, err := os.ReadDir(cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(entries), i+1; got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	}
+}
+
+func TestPruneProtoDescriptorCache(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * protoDescriptorCacheTTL)
+	for _, tt := range []struct {
+		name string
+		old  bool
+	}{
+		{"old" + protosetExt, true},
+		{"old-123.tmp", true},
+		{"old.txt", true},
+		{"new" + protosetExt, false},
+	} {
+		p := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(p, nil, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if tt.old {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := pruneProtoDescriptorCache(dir, time.Now().Add(-protoDescriptorCacheTTL)); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	// Files other than the cache are not removed
+	want := []string{"new" + protosetExt, "old.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
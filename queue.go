@@ -0,0 +1,264 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+)
+
+const (
+	queueStoreMessageKey  = "message"
+	queueStoreMessagesKey = "messages"
+	queueStoreResponseKey = "res"
+)
+
+const queueDefaultConsumeTimeout = 10 * time.Second
+
+// QueueClient is the interface that a client of a message queue ( Kafka, NATS, and so on ) must implement.
+type QueueClient interface {
+	// Produce publishes a message.
+	Produce(ctx context.Context, m *QueueMessage) error
+	// Consume receives a message from the topic. It blocks until a message is received or ctx is done.
+	Consume(ctx context.Context, topic string) (*QueueMessage, error)
+	Close() error
+}
+
+// queueSubscriber is the interface that a client that receives only messages published after subscribing must implement.
+type queueSubscriber interface {
+	subscribe(topic string) error
+}
+
+type QueueMessage struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Partition int
+	Offset    int64
+}
+
+type queueRunner struct {
+	name      string
+	dsn       string
+	client    QueueClient
+	hostRules hostRules
+	mu        sync.Mutex
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type queueOp string
+
+const (
+	queueOpProduce queueOp = "produce"
+	queueOpConsume queueOp = "consume"
+)
+
+type queueRequest struct {
+	op      queueOp
+	message *QueueMessage
+	topic   string
+	count   int
+	timeout time.Duration
+}
+
+func newQueueRunner(name, dsn string) (*queueRunner, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "kafka", "nats":
+	default:
+		return nil, fmt.Errorf("invalid queue DSN: %s", dsn)
+	}
+	return &queueRunner{
+		name: name,
+		dsn:  dsn,
+	}, nil
+}
+
+func (rnr *queueRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.queueRequest)
+	if err != nil {
+		return err
+	}
+	r, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid queue request: %v", e)
+	}
+	req, err := parseQueueRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *queueRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.client == nil {
+		return nil
+	}
+	c := rnr.client
+	rnr.client = nil
+	return c.Close()
+}
+
+func (rnr *queueRunner) Renew() error {
+	if rnr.client != nil && rnr.dsn == "" {
+		return errors.New("queue runners created with the runn.QueueRunner option cannot be renewed") //nostyle:errorstrings
+	}
+	return rnr.Close()
+}
+
+func (rnr *queueRunner) run(ctx context.Context, r *queueRequest, s *step) error {
+	o := s.parent
+	if err := rnr.connect(ctx, o); err != nil {
+		return err
+	}
+	switch r.op {
+	case queueOpProduce:
+		o.capturers.captureQueueProduce(rnr.name, r.message)
+		if err := rnr.client.Produce(ctx, r.message); err != nil {
+			return fmt.Errorf("failed to produce message to %s: %w", r.message.Topic, err)
+		}
+		o.record(map[string]any{})
+	case queueOpConsume:
+		timeout := queueDefaultConsumeTimeout
+		if r.timeout > 0 {
+			timeout = r.timeout
+		}
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		var (
+			message  map[string]any
+			messages []any
+		)
+		for i := 0; i < r.count; i++ {
+			m, err := rnr.client.Consume(cctx, r.topic)
+			if err != nil {
+				return fmt.Errorf("failed to consume message from %s: %w", r.topic, err)
+			}
+			o.capturers.captureQueueConsume(rnr.name, m)
+			message = queueMessageToMap(m)
+			messages = append(messages, message)
+		}
+		o.record(map[string]any{
+			queueStoreResponseKey: map[string]any{
+				queueStoreMessageKey:  message,
+				queueStoreMessagesKey: messages,
+			},
+		})
+	default:
+		return fmt.Errorf("invalid op: %v", r.op)
+	}
+	return nil
+}
+
+func (rnr *queueRunner) connect(ctx context.Context, o *operator) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.client != nil {
+		return nil
+	}
+	u, err := url.Parse(rnr.dsn)
+	if err != nil {
+		return err
+	}
+	var c QueueClient
+	switch u.Scheme {
+	case "kafka":
+		c, err = newKafkaClient(u, rnr.hostRules)
+	case "nats":
+		c, err = newNATSClient(u, rnr.hostRules)
+	default:
+		err = fmt.Errorf("invalid queue DSN: %s", rnr.dsn)
+	}
+	if err != nil {
+		return err
+	}
+	rnr.client = c
+	if sc, ok := c.(queueSubscriber); ok {
+		// Subscribe to the topics referenced by the steps before any publish so that no message is lost
+		for _, t := range queueTopics(o, rnr) {
+			if err := sc.subscribe(t); err != nil {
+				return err
+			}
+		}
+	}
+	if err := donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Close()
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func queueMessageToMap(m *QueueMessage) map[string]any {
+	var value any
+	if err := json.Unmarshal(m.Value, &value); err != nil {
+		value = string(m.Value)
+	}
+	headers := map[string]any{}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	return map[string]any{
+		"topic":     m.Topic,
+		"key":       string(m.Key),
+		"value":     value,
+		"headers":   headers,
+		"partition": m.Partition,
+		"offset":    m.Offset,
+	}
+}
+
+func queueBrokers(u *url.URL) []string {
+	var brokers []string
+	for _, b := range strings.Split(u.Host, ",") {
+		if b == "" {
+			continue
+		}
+		brokers = append(brokers, b)
+	}
+	return brokers
+}
+
+// queueTopics returns the topics referenced by the steps using the runner.
+// Topics containing templates are excluded because they are expanded when the steps are run.
+func queueTopics(o *operator, rnr *queueRunner) []string {
+	var topics []string
+	for _, s := range o.steps {
+		if s.queueRunner != rnr {
+			continue
+		}
+		for _, v := range s.queueRequest {
+			m, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			t, ok := m["topic"].(string)
+			if !ok || t == "" || strings.Contains(t, "{{") {
+				continue
+			}
+			topics = append(topics, t)
+		}
+	}
+	return unique(topics)
+}
@@ -0,0 +1,145 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var _ QueueClient = (*kafkaClient)(nil)
+
+type kafkaClient struct {
+	brokers     []string
+	group       string
+	partition   int
+	startOffset int64
+	dialer      *kafka.Dialer
+	writer      *kafka.Writer
+	readers     map[string]*kafka.Reader
+	mu          sync.Mutex
+}
+
+// newKafkaClient returns a Kafka client from DSN ( kafka://host1:9092,host2:9092?group=mygroup&offset=first ).
+func newKafkaClient(u *url.URL, rules hostRules) (*kafkaClient, error) {
+	brokers := queueBrokers(u)
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("invalid Kafka DSN: no brokers: %s", u.String())
+	}
+	q := u.Query()
+	c := &kafkaClient{
+		brokers:     brokers,
+		group:       q.Get("group"),
+		startOffset: kafka.FirstOffset,
+		dialer:      &kafka.Dialer{},
+		readers:     map[string]*kafka.Reader{},
+	}
+	switch q.Get("offset") {
+	case "", "first":
+	case "last":
+		c.startOffset = kafka.LastOffset
+	default:
+		return nil, fmt.Errorf("invalid Kafka DSN: invalid offset: %s", q.Get("offset"))
+	}
+	if p := q.Get("partition"); p != "" {
+		if c.group != "" {
+			return nil, fmt.Errorf("invalid Kafka DSN: group and partition cannot be used at the same time: %s", u.String())
+		}
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kafka DSN: invalid partition: %w", err)
+		}
+		c.partition = v
+	}
+	transport := &kafka.Transport{}
+	if len(rules) > 0 {
+		c.dialer.DialFunc = rules.dialContextFunc()
+		transport.Dial = rules.dialContextFunc()
+	}
+	c.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		Transport:              transport,
+		AllowAutoTopicCreation: true,
+	}
+	return c, nil
+}
+
+func (c *kafkaClient) Produce(ctx context.Context, m *QueueMessage) error {
+	km := kafka.Message{
+		Topic: m.Topic,
+		Key:   m.Key,
+		Value: m.Value,
+	}
+	for k, v := range m.Headers {
+		km.Headers = append(km.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return c.writer.WriteMessages(ctx, km)
+}
+
+func (c *kafkaClient) Consume(ctx context.Context, topic string) (*QueueMessage, error) {
+	r, err := c.reader(topic)
+	if err != nil {
+		return nil, err
+	}
+	km, err := r.ReadMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := &QueueMessage{
+		Topic:     km.Topic,
+		Key:       km.Key,
+		Value:     km.Value,
+		Headers:   map[string]string{},
+		Partition: km.Partition,
+		Offset:    km.Offset,
+	}
+	for _, h := range km.Headers {
+		m.Headers[h.Key] = string(h.Value)
+	}
+	return m, nil
+}
+
+func (c *kafkaClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs error
+	for t, r := range c.readers {
+		errs = errors.Join(errs, r.Close())
+		delete(c.readers, t)
+	}
+	errs = errors.Join(errs, c.writer.Close())
+	return errs
+}
+
+// reader returns the reader for the topic. Readers are kept so that the offset advances across steps.
+func (c *kafkaClient) reader(topic string) (*kafka.Reader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.readers[topic]; ok {
+		return r, nil
+	}
+	cfg := kafka.ReaderConfig{
+		Brokers:     c.brokers,
+		Topic:       topic,
+		GroupID:     c.group,
+		StartOffset: c.startOffset,
+		Dialer:      c.dialer,
+	}
+	if c.group == "" {
+		cfg.Partition = c.partition
+	}
+	r := kafka.NewReader(cfg)
+	if c.group == "" && c.startOffset == kafka.LastOffset {
+		if err := r.SetOffset(kafka.LastOffset); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+	}
+	c.readers[topic] = r
+	return r, nil
+}
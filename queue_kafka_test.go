@@ -0,0 +1,65 @@
+package runn
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestNewKafkaClient(t *testing.T) {
+	tests := []struct {
+		dsn             string
+		wantBrokers     []string
+		wantGroup       string
+		wantPartition   int
+		wantStartOffset int64
+		wantErr         bool
+	}{
+		{"kafka://localhost:9092", []string{"localhost:9092"}, "", 0, kafka.FirstOffset, false},
+		{"kafka://broker1:9092,broker2:9092?group=runn&offset=last", []string{"broker1:9092", "broker2:9092"}, "runn", 0, kafka.LastOffset, false},
+		{"kafka://localhost:9092?partition=2", []string{"localhost:9092"}, "", 2, kafka.FirstOffset, false},
+		{"kafka://", nil, "", 0, 0, true},
+		{"kafka://localhost:9092?offset=middle", nil, "", 0, 0, true},
+		{"kafka://localhost:9092?partition=x", nil, "", 0, 0, true},
+		{"kafka://localhost:9092?group=runn&partition=1", nil, "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			u, err := url.Parse(tt.dsn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := newKafkaClient(u, nil)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			t.Cleanup(func() {
+				_ = c.Close()
+			})
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			if len(c.brokers) != len(tt.wantBrokers) {
+				t.Fatalf("got %v\nwant %v", c.brokers, tt.wantBrokers)
+			}
+			for i := range c.brokers {
+				if c.brokers[i] != tt.wantBrokers[i] {
+					t.Errorf("got %v\nwant %v", c.brokers, tt.wantBrokers)
+				}
+			}
+			if c.group != tt.wantGroup {
+				t.Errorf("got %v\nwant %v", c.group, tt.wantGroup)
+			}
+			if c.partition != tt.wantPartition {
+				t.Errorf("got %v\nwant %v", c.partition, tt.wantPartition)
+			}
+			if c.startOffset != tt.wantStartOffset {
+				t.Errorf("got %v\nwant %v", c.startOffset, tt.wantStartOffset)
+			}
+		})
+	}
+}
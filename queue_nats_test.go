@@ -0,0 +1,98 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func TestNATSQueueRunner(t *testing.T) {
+	ns := natsTestServer(t)
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := nc.Publish("notifications", []byte("hello")); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := nc.Flush(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	t.Setenv("TEST_NATS_ADDR", ns.Addr().String())
+	t.Setenv("TEST_HTTP_URL", ts.URL)
+	o, err := New(Book("testdata/queue_nats.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+	r := o.queueRunners["q"]
+	if r.client != nil {
+		t.Error("the queue runner should be closed")
+	}
+}
+
+func TestNewNATSClient(t *testing.T) {
+	ns := natsTestServer(t)
+	r, err := newQueueRunner("q", "nats://"+ns.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.connect(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := r.client.(*natsClient)
+	if !ok {
+		t.Fatalf("invalid client: %T", r.client)
+	}
+	if err := c.Produce(ctx, &QueueMessage{Topic: "events", Value: []byte("v")}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.Consume(ctx, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m.Value); got != "v" {
+		t.Errorf("got %v\nwant %v", got, "v")
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	if !c.conn.IsClosed() {
+		t.Error("the connection should be closed")
+	}
+}
+
+func natsTestServer(t *testing.T) *server.Server {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("the NATS server is not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+	return ns
+}
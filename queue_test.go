@@ -0,0 +1,183 @@
+package runn
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+)
+
+func TestNewQueueRunner(t *testing.T) {
+	tests := []struct {
+		dsn     string
+		wantErr bool
+	}{
+		{"kafka://localhost:9092", false},
+		{"kafka://broker1:9092,broker2:9092?group=runn", false},
+		{"nats://localhost:4222", false},
+		{"amqp://localhost:5672", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			_, err := newQueueRunner("q", tt.dsn)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestQueueRunner(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &queueRunner{
+		name:   "q",
+		client: newMemQueue(),
+	}
+	s := newStep(0, "stepKey", o, nil)
+	for i := 0; i < 2; i++ {
+		req, err := parseQueueRequest(map[string]any{
+			"produce": map[string]any{
+				"topic":   "events",
+				"key":     "user-1",
+				"value":   map[string]any{"id": i},
+				"headers": map[string]any{"X-Source": "runn"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.run(ctx, req, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := parseQueueRequest(map[string]any{
+		"consume": map[string]any{
+			"topic":   "events",
+			"count":   2,
+			"timeout": "1sec",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = newStep(1, "stepKey", o, nil)
+	if err := r.run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	got := o.store.steps[2]
+	m0 := map[string]any{
+		"topic":     "events",
+		"key":       "user-1",
+		"value":     map[string]any{"id": float64(0)},
+		"headers":   map[string]any{"X-Source": "runn"},
+		"partition": 0,
+		"offset":    int64(0),
+	}
+	m1 := map[string]any{
+		"topic":     "events",
+		"key":       "user-1",
+		"value":     map[string]any{"id": float64(1)},
+		"headers":   map[string]any{"X-Source": "runn"},
+		"partition": 0,
+		"offset":    int64(1),
+	}
+	want := map[string]any{
+		"res": map[string]any{
+			"message":  m1,
+			"messages": []any{m0, m1},
+		},
+	}
+	if diff := cmp.Diff(got, want, nil); diff != "" {
+		t.Error(diff)
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		req, err := parseQueueRequest(map[string]any{
+			"consume": map[string]any{
+				"topic":   "events",
+				"timeout": "10ms",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newStep(2, "stepKey", o, nil)
+		if err := r.run(ctx, req, s); err == nil {
+			t.Error("want error")
+		}
+	})
+}
+
+func TestQueueRunbook(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/queue.yml"), QueueRunner("q", newMemQueue()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+// memQueue is an in-memory stand-in for a message broker.
+type memQueue struct {
+	topics map[string][]*QueueMessage
+	offset map[string]int
+	notify chan struct{}
+	mu     sync.Mutex
+}
+
+func newMemQueue() *memQueue {
+	return &memQueue{
+		topics: map[string][]*QueueMessage{},
+		offset: map[string]int{},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (q *memQueue) Produce(ctx context.Context, m *QueueMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	m.Offset = int64(len(q.topics[m.Topic]))
+	q.topics[m.Topic] = append(q.topics[m.Topic], m)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *memQueue) Consume(ctx context.Context, topic string) (*QueueMessage, error) {
+	for {
+		q.mu.Lock()
+		if q.offset[topic] < len(q.topics[topic]) {
+			m := q.topics[topic][q.offset[topic]]
+			q.offset[topic]++
+			q.mu.Unlock()
+			return m, nil
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *memQueue) Close() error {
+	return nil
+}
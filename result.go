@@ -2,6 +2,7 @@ package runn
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -155,6 +156,243 @@ func (r *runNResult) OutJSON(out io.Writer) error {
 	return nil
 }
 
+// OutJUnit outputs the result in JUnit XML format.
+// Each runbook becomes a testsuite and each step becomes a testcase.
+// Runbooks loaded by include runner are nested as testsuites.
+func (r *runNResult) OutJUnit(out io.Writer) error {
+	s := junitTestsuites{
+		Name: "runn",
+	}
+	for _, rr := range r.RunResults {
+		ts := junitTestsuiteFromRunResult(rr)
+		s.Tests += ts.Tests
+		s.Failures += ts.Failures
+		s.Skipped += ts.Skipped
+		s.Time += junitTime(rr.Elapsed.Seconds())
+		s.Testsuites = append(s.Testsuites, ts)
+	}
+	if _, err := fmt.Fprint(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(out, "\n"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// OutTAP outputs the result in TAP (Test Anything Protocol) version 14 format.
+// Each runbook becomes a test point and each step becomes a subtest.
+func (r *runNResult) OutTAP(out io.Writer) error {
+	if _, err := fmt.Fprintln(out, "TAP version 14"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "1..%d\n", len(r.RunResults)); err != nil {
+		return err
+	}
+	for i, rr := range r.RunResults {
+		if err := rr.outTAP(out, i+1, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type junitTestsuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       junitTime         `xml:"time,attr"`
+	Testsuites []*junitTestsuite `xml:"testsuite"`
+}
+
+type junitTestsuite struct {
+	XMLName    xml.Name          `xml:"testsuite"`
+	Name       string            `xml:"name,attr"`
+	ID         string            `xml:"id,attr,omitempty"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       junitTime         `xml:"time,attr"`
+	Properties *junitProperties  `xml:"properties,omitempty"`
+	Testcases  []*junitTestcase  `xml:"testcase"`
+	Testsuites []*junitTestsuite `xml:"testsuite"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestcase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      junitTime     `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// junitTime is elapsed seconds.
+type junitTime float64
+
+func (t junitTime) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: fmt.Sprintf("%.3f", float64(t))}, nil
+}
+
+func junitTestsuiteFromRunResult(rr *RunResult) *junitTestsuite {
+	np := normalizePath(rr.Path)
+	ts := &junitTestsuite{
+		Name: np,
+		ID:   rr.ID,
+		Time: junitTime(rr.Elapsed.Seconds()),
+	}
+	if rr.Desc != "" {
+		ts.Properties = &junitProperties{
+			Properties: []junitProperty{{Name: "desc", Value: rr.Desc}},
+		}
+	}
+	for _, sr := range rr.StepResults {
+		tc := &junitTestcase{
+			Name:      stepResultName(sr),
+			Classname: np,
+			Time:      junitTime(sr.Elapsed.Seconds()),
+		}
+		switch {
+		case sr.Err != nil:
+			msg := strings.TrimRight(sr.Err.Error(), "\n")
+			tc.Failure = &junitFailure{
+				Message:  strings.SplitN(msg, "\n", 2)[0],
+				Type:     string(resultFailure),
+				Contents: msg,
+			}
+			ts.Failures++
+		case sr.Skipped:
+			tc.Skipped = &junitSkipped{}
+			ts.Skipped++
+		}
+		ts.Tests++
+		ts.Testcases = append(ts.Testcases, tc)
+		if sr.IncludedRunResult != nil {
+			ts.Testsuites = append(ts.Testsuites, junitTestsuiteFromRunResult(sr.IncludedRunResult))
+		}
+	}
+	switch {
+	case len(rr.StepResults) == 0 && rr.Err != nil:
+		// The runbook failed before running steps (e.g. failed to load runbook).
+		msg := strings.TrimRight(rr.Err.Error(), "\n")
+		ts.Testcases = append(ts.Testcases, &junitTestcase{
+			Name:      np,
+			Classname: np,
+			Time:      junitTime(rr.Elapsed.Seconds()),
+			Failure: &junitFailure{
+				Message:  strings.SplitN(msg, "\n", 2)[0],
+				Type:     string(resultFailure),
+				Contents: msg,
+			},
+		})
+		ts.Tests++
+		ts.Failures++
+	case len(rr.StepResults) == 0 && rr.Skipped:
+		ts.Testcases = append(ts.Testcases, &junitTestcase{
+			Name:      np,
+			Classname: np,
+			Skipped:   &junitSkipped{},
+		})
+		ts.Tests++
+		ts.Skipped++
+	}
+	return ts
+}
+
+func (rr *RunResult) outTAP(out io.Writer, num int, indent string) error {
+	np := normalizePath(rr.Path)
+	if len(rr.StepResults) > 0 {
+		if _, err := fmt.Fprintf(out, "%s    # Subtest: %s\n", indent, np); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s    1..%d\n", indent, len(rr.StepResults)); err != nil {
+			return err
+		}
+		for i, sr := range rr.StepResults {
+			if sr.IncludedRunResult != nil {
+				if err := sr.IncludedRunResult.outTAP(out, i+1, indent+"    "); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := outTAPTestPoint(out, indent+"    ", i+1, stepResultName(sr), sr.Skipped, sr.Err, sr.Elapsed); err != nil {
+				return err
+			}
+		}
+	}
+	return outTAPTestPoint(out, indent, num, np, rr.Skipped, rr.Err, rr.Elapsed)
+}
+
+func outTAPTestPoint(out io.Writer, indent string, num int, desc string, skipped bool, failure error, elapsed time.Duration) error {
+	switch {
+	case failure != nil:
+		if _, err := fmt.Fprintf(out, "%snot ok %d - %s\n", indent, num, tapEscape(desc)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s  ---\n", indent); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s  message: |-\n", indent); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(out, SprintMultilinef(indent+"    %s\n", "%s", strings.TrimRight(failure.Error(), "\n"))); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s  duration_ms: %d\n", indent, elapsed.Milliseconds()); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s  ...\n", indent); err != nil {
+			return err
+		}
+	case skipped:
+		if _, err := fmt.Fprintf(out, "%sok %d - %s # SKIP\n", indent, num, tapEscape(desc)); err != nil {
+			return err
+		}
+	default:
+		if _, err := fmt.Fprintf(out, "%sok %d - %s\n", indent, num, tapEscape(desc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stepResultName(sr *StepResult) string {
+	if sr.Desc != "" {
+		return fmt.Sprintf("%s: %s", sr.Key, sr.Desc)
+	}
+	return sr.Key
+}
+
+// tapEscape escapes characters that have special meaning in the description of TAP test point.
+func tapEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "#", "\\#", "\n", " ").Replace(s)
+}
+
 func (rr *RunResult) OutFailure(out io.Writer) error {
 	_, err := rr.outFailure(out, 1)
 	return err
@@ -224,6 +224,236 @@ func TestResultOutJSON(t *testing.T) {
 	}
 }
 
+func TestResultOutJUnit(t *testing.T) {
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 4, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_2_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+		})},
+		{newRunNResult(t, 5, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_2_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/always_failure.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy, IncludedRunResult: &RunResult{
+					ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0",
+					Path:        "testdata/book/runn_included_0_fail.yml",
+					Err:         ErrDummy,
+					StepResults: []*StepResult{{Key: "0", Err: ErrDummy}},
+				}}},
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_junit_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutJUnit(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestResultOutTAP(t *testing.T) {
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 4, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_2_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+		})},
+		{newRunNResult(t, 5, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_2_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/always_failure.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  ErrDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: ErrDummy, IncludedRunResult: &RunResult{
+					ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0",
+					Path:        "testdata/book/runn_included_0_fail.yml",
+					Err:         ErrDummy,
+					StepResults: []*StepResult{{Key: "0", Err: ErrDummy}},
+				}}},
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_tap_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutTAP(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
 func TestResultElasped(t *testing.T) {
 	tests := []struct {
 		book string
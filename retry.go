@@ -0,0 +1,237 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/backoff/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	defaultRetryCount       = 3
+	defaultRetryMinInterval = "500ms"
+	defaultRetryMaxInterval = "10s"
+	defaultRetryJitter      = float64(0.1)
+	defaultRetryMultiplier  = float64(2.0)
+	// defaultRetryStatuses - HTTP statuses of transient failures
+	defaultRetryStatuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	// defaultRetryCodes - gRPC status codes of transient failures
+	defaultRetryCodes = []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"}
+)
+
+// retryConfig - Settings of the automatic retry of the runner on transient failures.
+type retryConfig struct {
+	Count       *int     `yaml:"count,omitempty"`
+	Statuses    []int    `yaml:"statuses,omitempty"`
+	Codes       []string `yaml:"codes,omitempty"`
+	Interval    string   `yaml:"interval,omitempty"`
+	MinInterval string   `yaml:"minInterval,omitempty"`
+	MaxInterval string   `yaml:"maxInterval,omitempty"`
+	Jitter      *float64 `yaml:"jitter,omitempty"`
+	Multiplier  *float64 `yaml:"multiplier,omitempty"`
+}
+
+type retrier struct {
+	count    int
+	statuses []int
+	codes    []codes.Code
+	policy   backoff.Policy
+}
+
+// retryAttempts - State of the retries of a request.
+type retryAttempts struct {
+	rt     *retrier
+	ctrl   backoff.Controller
+	cancel context.CancelFunc
+	n      int
+}
+
+func newRetrier(c *retryConfig) (*retrier, error) {
+	rt := &retrier{
+		count:    defaultRetryCount,
+		statuses: defaultRetryStatuses,
+	}
+	if c.Count != nil {
+		if *c.Count < 0 {
+			return nil, fmt.Errorf("invalid retry count: %d", *c.Count)
+		}
+		rt.count = *c.Count
+	}
+	if len(c.Statuses) > 0 {
+		rt.statuses = c.Statuses
+	}
+	cs := defaultRetryCodes
+	if len(c.Codes) > 0 {
+		cs = c.Codes
+	}
+	for _, s := range cs {
+		code, err := parseGRPCCode(s)
+		if err != nil {
+			return nil, err
+		}
+		rt.codes = append(rt.codes, code)
+	}
+	jitter := defaultRetryJitter
+	if c.Jitter != nil {
+		jitter = *c.Jitter
+	}
+	if c.Interval != "" {
+		i, err := parseDuration(c.Interval)
+		if err != nil {
+			return nil, err
+		}
+		rt.policy = backoff.Constant(
+			backoff.WithMaxRetries(0),
+			backoff.WithInterval(i),
+			backoff.WithJitterFactor(jitter),
+		)
+		return rt, nil
+	}
+	minInterval := defaultRetryMinInterval
+	if c.MinInterval != "" {
+		minInterval = c.MinInterval
+	}
+	maxInterval := defaultRetryMaxInterval
+	if c.MaxInterval != "" {
+		maxInterval = c.MaxInterval
+	}
+	multiplier := defaultRetryMultiplier
+	if c.Multiplier != nil {
+		multiplier = *c.Multiplier
+	}
+	imin, err := parseDuration(minInterval)
+	if err != nil {
+		return nil, err
+	}
+	imax, err := parseDuration(maxInterval)
+	if err != nil {
+		return nil, err
+	}
+	rt.policy = backoff.Exponential(
+		backoff.WithMaxRetries(0),
+		backoff.WithMinInterval(imin),
+		backoff.WithMaxInterval(imax),
+		backoff.WithMultiplier(multiplier),
+		backoff.WithJitterFactor(jitter),
+	)
+	return rt, nil
+}
+
+func (rt *retrier) retryableStatus(status int) bool {
+	return slices.Contains(rt.statuses, status)
+}
+
+func (rt *retrier) retryableCode(code codes.Code) bool {
+	return slices.Contains(rt.codes, code)
+}
+
+// start starts the retries of a request.
+func (rt *retrier) start(ctx context.Context) *retryAttempts {
+	ctx, cancel := context.WithCancel(ctx)
+	ctrl := rt.policy.Start(ctx)
+	// The first tick is for the first attempt
+	_ = backoff.Continue(ctrl)
+	return &retryAttempts{rt: rt, ctrl: ctrl, cancel: cancel}
+}
+
+// remaining returns whether retries remain.
+func (ra *retryAttempts) remaining() bool {
+	return ra.n < ra.rt.count
+}
+
+// wait waits for the next attempt. retryAfter is the minimum wait time requested by the server ( e.g. Retry-After header ).
+func (ra *retryAttempts) wait(ctx context.Context, retryAfter time.Duration) error {
+	start := time.Now()
+	if !backoff.Continue(ra.ctrl) {
+		return ctx.Err()
+	}
+	if d := retryAfter - time.Since(start); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	ra.n++
+	return nil
+}
+
+// index returns the index of the current retry ( 0 is the first retry ).
+func (ra *retryAttempts) index() int {
+	return ra.n - 1
+}
+
+// stop stops the backoff controller.
+func (ra *retryAttempts) stop() {
+	ra.cancel()
+}
+
+// retryTrails returns the trails of the retry of the step.
+func retryTrails(s *step, idx int) Trails {
+	trs := s.trails()
+	i := idx
+	return append(trs, Trail{
+		Type:          TrailTypeRetry,
+		RetryIndex:    &i,
+		StepIndex:     &s.idx,
+		StepKey:       s.key,
+		StepRunnerKey: s.runnerKey,
+	})
+}
+
+// parseRetryAfter parses the value of Retry-After header ( delay-seconds or HTTP-date ).
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0
+	}
+	d := time.Until(t)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// parseGRPCRetryPushback parses the retry pushback of the server ( grpc-retry-pushback-ms trailer ).
+func parseGRPCRetryPushback(md metadata.MD) time.Duration {
+	vs := md.Get("grpc-retry-pushback-ms")
+	if len(vs) == 0 {
+		return 0
+	}
+	ms, err := strconv.Atoi(vs[0])
+	if err != nil || ms < 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// parseGRPCCode parses the gRPC status code name ( e.g. UNAVAILABLE, Unavailable ) or number.
+func parseGRPCCode(s string) (codes.Code, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return codes.Code(n), nil //nolint:gosec
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(s)))); err != nil {
+		return 0, fmt.Errorf("invalid gRPC status code: %s", s)
+	}
+	return code, nil
+}
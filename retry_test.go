@@ -0,0 +1,338 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// retryCapturer records the current trail of the captured requests and responses.
+type retryCapturer struct {
+	Capturer
+	current Trails
+	got     []string
+}
+
+func (c *retryCapturer) SetCurrentTrails(trs Trails) {
+	c.current = trs
+}
+
+func (c *retryCapturer) CaptureHTTPRequest(name string, req *http.Request) {
+	b, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	c.got = append(c.got, "req "+c.current[len(c.current)-1].String()+" "+string(b))
+}
+
+func (c *retryCapturer) CaptureHTTPResponse(name string, res *http.Response) {
+	c.got = append(c.got, "res "+c.current[len(c.current)-1].String()+" "+res.Status)
+}
+
+func (c *retryCapturer) CaptureGRPCRequestMessage(m map[string]any) {
+	c.got = append(c.got, "req "+c.current[len(c.current)-1].String())
+}
+
+func (c *retryCapturer) CaptureGRPCResponseStatus(s *status.Status) {
+	c.got = append(c.got, "res "+c.current[len(c.current)-1].String()+" "+s.Code().String())
+}
+
+func TestHTTPRunnerRetry(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		name       string
+		failures   int
+		c          *retryConfig
+		wantStatus int
+		want       []string
+	}{
+		{
+			"retry until success",
+			2,
+			&retryConfig{Interval: "1ms"},
+			http.StatusOK,
+			[]string{
+				"req steps[stepKey] {\"name\":\"alice\"}",
+				"res steps[stepKey] 503 Service Unavailable",
+				"req retry[0] {\"name\":\"alice\"}",
+				"res retry[0] 503 Service Unavailable",
+				"req retry[1] {\"name\":\"alice\"}",
+				"res retry[1] 200 OK",
+			},
+		},
+		{
+			"retries exhausted",
+			3,
+			&retryConfig{Count: &zero},
+			http.StatusServiceUnavailable,
+			[]string{
+				"req steps[stepKey] {\"name\":\"alice\"}",
+				"res steps[stepKey] 503 Service Unavailable",
+			},
+		},
+		{
+			"not retryable status",
+			3,
+			&retryConfig{Interval: "1ms", Statuses: []int{http.StatusBadGateway}},
+			http.StatusServiceUnavailable,
+			[]string{
+				"req steps[stepKey] {\"name\":\"alice\"}",
+				"res steps[stepKey] 503 Service Unavailable",
+			},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				if string(b) != `{"name":"alice"}` {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if int(count.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(ts.Close)
+			c := &retryCapturer{Capturer: NewDebugger(io.Discard)}
+			o, err := New(Capture(c))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.retry, err = newRetrier(tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &httpRequest{
+				path:      "/users",
+				method:    http.MethodPost,
+				headers:   http.Header{},
+				mediaType: MediaTypeApplicationJSON,
+				body:      map[string]any{"name": "alice"},
+			}
+			s := newStep(0, "stepKey", o, nil)
+			o.capturers.setCurrentTrails(s.trails())
+			if err := r.run(ctx, req, s); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if got := res["status"]; got != tt.wantStatus {
+				t.Errorf("got %v\nwant %v", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(c.got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.retry, err = newRetrier(&retryConfig{Interval: "1ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:    "/",
+		method:  http.MethodGet,
+		headers: http.Header{},
+	}
+	s := newStep(0, "stepKey", o, nil)
+	start := time.Now()
+	if err := r.run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("the retry should wait for Retry-After: %v", elapsed)
+	}
+	if got := count.Load(); got != 2 {
+		t.Errorf("got %v\nwant %v", got, 2)
+	}
+}
+
+type retryHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	failures int32
+	count    atomic.Int32
+}
+
+func (s *retryHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s.count.Add(1) <= s.failures {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs("grpc-retry-pushback-ms", "1"))
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *retryHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	if s.count.Add(1) <= s.failures {
+		stream.SetTrailer(metadata.Pairs("grpc-retry-pushback-ms", "1"))
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+func TestGRPCRunnerRetry(t *testing.T) {
+	tests := []struct {
+		method     string
+		wantStatus any
+	}{
+		{"Check", int(codes.OK)},
+		// Server streaming RPC is retried until the first message arrives
+		{"Watch", int64(codes.OK)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			hs := &retryHealthServer{failures: 2}
+			srv := grpc.NewServer()
+			grpc_health_v1.RegisterHealthServer(srv, hs)
+			reflection.Register(srv)
+			go func() {
+				_ = srv.Serve(l)
+			}()
+			t.Cleanup(srv.Stop)
+
+			c := &retryCapturer{Capturer: NewDebugger(io.Discard)}
+			o, err := New(Capture(c))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			useTLS := false
+			r.tls = &useTLS
+			r.retry, err = newRetrier(&retryConfig{Interval: "1ms"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			req := &grpcRequest{
+				service: "grpc.health.v1.Health",
+				method:  tt.method,
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{}},
+				},
+			}
+			s := newStep(0, "stepKey", o, nil)
+			o.capturers.setCurrentTrails(s.trails())
+			if err := r.run(ctx, req, s); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if got := res["status"]; got != tt.wantStatus {
+				t.Errorf("got %v\nwant %v", got, tt.wantStatus)
+			}
+			want := []string{
+				"req steps[stepKey]",
+				"res steps[stepKey] Unavailable",
+				"req retry[0]",
+				"res retry[0] Unavailable",
+				"req retry[1]",
+				"res retry[1] OK",
+			}
+			if diff := cmp.Diff(c.got, want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"invalid", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in); got != tt.want {
+			t.Errorf("%q: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+	d := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("got %v", d)
+	}
+}
+
+func TestNewRetrierInvalid(t *testing.T) {
+	minus := -1
+	tests := []*retryConfig{
+		{Count: &minus},
+		{Codes: []string{"NOT_A_CODE"}},
+		{Interval: "invalid"},
+		{MinInterval: "invalid"},
+	}
+	for _, tt := range tests {
+		if _, err := newRetrier(tt); err == nil {
+			t.Errorf("want error: %#v", tt)
+		}
+	}
+	rt, err := newRetrier(&retryConfig{Codes: []string{"unavailable", "14", "DEADLINE_EXCEEDED"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []codes.Code{codes.Unavailable, codes.Unavailable, codes.DeadlineExceeded}; !cmp.Equal(rt.codes, want) {
+		t.Errorf("got %v\nwant %v", rt.codes, want)
+	}
+}
@@ -0,0 +1,138 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// harLog is the part of the HAR ( HTTP Archive ) required to generate steps.
+// ref: http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request harRequest `json:"request"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *harPostData   `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harIgnoreHeaders are the request headers that are set by the HTTP runner or the browser itself.
+var harIgnoreHeaders = []string{"Content-Length", "Connection", "Accept-Encoding"}
+
+// AppendStepsFromHAR appends the steps generated from the entries of the HAR file.
+// If hosts ( glob patterns ) are specified, only the entries of the matched hosts are converted.
+func (rb *runbook) AppendStepsFromHAR(r io.Reader, hosts ...string) error {
+	h := harLog{}
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return fmt.Errorf("failed to decode HAR: %w", err)
+	}
+	for i, e := range h.Log.Entries {
+		req, err := e.Request.toRequest()
+		if err != nil {
+			return fmt.Errorf("failed to convert entry %d: %w", i, err)
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			continue
+		}
+		matched, err := matchHARHost(req.URL, hosts)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+		key := rb.setRunner(dsn)
+		step, err := CreateHTTPStepMapSlice(key, req)
+		if err != nil {
+			return fmt.Errorf("failed to generate step of entry %d: %w", i, err)
+		}
+		if rb.useMap {
+			rb.stepKeys = append(rb.stepKeys, fmt.Sprintf("har%d", len(rb.stepKeys)))
+		}
+		rb.Steps = append(rb.Steps, step)
+	}
+	return nil
+}
+
+func (hr *harRequest) toRequest() (*http.Request, error) {
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if hr.PostData != nil {
+		contentType = hr.PostData.MimeType
+		switch {
+		case hr.PostData.Text != "":
+			body = strings.NewReader(hr.PostData.Text)
+		case len(hr.PostData.Params) > 0:
+			vs := url.Values{}
+			for _, p := range hr.PostData.Params {
+				vs.Add(p.Name, p.Value)
+			}
+			body = strings.NewReader(vs.Encode())
+		}
+	}
+	req, err := http.NewRequest(hr.Method, hr.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hr.Headers {
+		// Skip HTTP/2 pseudo-headers ( e.g. :authority )
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		k := http.CanonicalHeaderKey(h.Name)
+		if slices.Contains(harIgnoreHeaders, k) {
+			continue
+		}
+		req.Header.Add(k, h.Value)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func matchHARHost(u *url.URL, patterns []string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	for _, p := range patterns {
+		for _, h := range []string{u.Host, u.Hostname()} {
+			matched, err := path.Match(p, h)
+			if err != nil {
+				return false, fmt.Errorf("invalid host filter %q: %w", p, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
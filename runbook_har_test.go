@@ -0,0 +1,50 @@
+package runn
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/tenntenn/golden"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAppendStepsFromHAR(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+	}{
+		{"all", nil},
+		{"filter", []string{"example.com", "*.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open("testdata/browser.har")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = f.Close()
+			})
+			rb := NewRunbook("")
+			if err := rb.AppendStepsFromHAR(f, tt.hosts...); err != nil {
+				t.Fatal(err)
+			}
+			got := new(bytes.Buffer)
+			enc := yaml.NewEncoder(got)
+			if err := enc.Encode(rb); err != nil {
+				t.Fatal(err)
+			}
+
+			g := fmt.Sprintf("browser.har.%s.from_har", tt.name)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", g, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", g, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
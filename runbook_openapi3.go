@@ -0,0 +1,443 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const defaultOpenAPI3Endpoint = "http://localhost:8080"
+
+// maxFakeDepth - Max depth of nested schemas to fake ( to stop recursive schemas ).
+const maxFakeDepth = 8
+
+// AppendStepsFromOpenAPI3 appends one step per operation of the OpenAPI v3 document.
+// l is the location to load the document, and ref is the location of the document written to the runner ( relative to the runbook ).
+func (rb *runbook) AppendStepsFromOpenAPI3(l, ref string) error {
+	ov, err := newOpenAPI3Validator(&httpRunnerConfig{
+		OpenAPI3DocLocation: l,
+		SkipValidateRequest: true,
+	})
+	if err != nil {
+		return err
+	}
+	v3m, errs := ov.doc.BuildV3Model()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if ref == "" {
+		ref = l
+	}
+	key := rb.setOpenAPI3Runner(openAPI3Endpoint(v3m.Model.Servers), ref)
+	if v3m.Model.Paths == nil {
+		return nil
+	}
+	for p := v3m.Model.Paths.PathItems.First(); p != nil; p = p.Next() {
+		for op := p.Value().GetOperations().First(); op != nil; op = op.Next() {
+			step, err := openAPI3OperationToStep(key, p.Key(), op.Key(), p.Value(), op.Value())
+			if err != nil {
+				return fmt.Errorf("failed to generate step of %s %s: %w", strings.ToUpper(op.Key()), p.Key(), err)
+			}
+			if rb.useMap {
+				rb.stepKeys = append(rb.stepKeys, rb.openAPI3StepKey(op.Key(), op.Value()))
+			}
+			rb.Steps = append(rb.Steps, step)
+		}
+	}
+	return nil
+}
+
+// setOpenAPI3Runner sets the HTTP runner with the OpenAPI v3 document and returns the key of the runner.
+func (rb *runbook) setOpenAPI3Runner(endpoint, ref string) string {
+	const httpRunnerKeyPrefix = "req"
+	for k, v := range rb.Runners {
+		vv, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if vv["endpoint"] == endpoint && vv["openapi3"] == ref {
+			return k
+		}
+	}
+	key := httpRunnerKeyPrefix
+	for i := 2; ; i++ {
+		if _, ok := rb.Runners[key]; !ok {
+			break
+		}
+		key = fmt.Sprintf("%s%d", httpRunnerKeyPrefix, i)
+	}
+	rb.Runners[key] = map[string]any{
+		"endpoint": endpoint,
+		"openapi3": ref,
+	}
+	return key
+}
+
+func (rb *runbook) openAPI3StepKey(method string, op *v3.Operation) string {
+	base := op.OperationId
+	if base == "" {
+		base = method
+	}
+	key := base
+	for i := 2; slices.Contains(rb.stepKeys, key); i++ {
+		key = fmt.Sprintf("%s%d", base, i)
+	}
+	return key
+}
+
+// openAPI3Endpoint returns the URL of the first server with the default values of the variables.
+// A relative URL of the server is resolved against defaultOpenAPI3Endpoint.
+func openAPI3Endpoint(servers []*v3.Server) string {
+	if len(servers) == 0 {
+		return defaultOpenAPI3Endpoint
+	}
+	s := servers[0]
+	u := s.URL
+	for v := s.Variables.First(); v != nil; v = v.Next() {
+		u = strings.ReplaceAll(u, fmt.Sprintf("{%s}", v.Key()), v.Value().Default)
+	}
+	if !strings.Contains(u, "://") {
+		u = defaultOpenAPI3Endpoint + "/" + strings.TrimPrefix(u, "/")
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+func openAPI3OperationToStep(key, path, method string, pi *v3.PathItem, op *v3.Operation) (yaml.MapSlice, error) {
+	q := url.Values{}
+	headers := yaml.MapSlice{}
+	for _, p := range operationParameters(pi, op) {
+		if p.In != "path" && (p.Required == nil || !*p.Required) {
+			continue
+		}
+		v, err := parameterExample(p)
+		if err != nil {
+			return nil, err
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", p.Name), url.PathEscape(fmt.Sprint(v)))
+		case "query":
+			q.Add(p.Name, fmt.Sprint(v))
+		case "header":
+			headers = append(headers, yaml.MapItem{Key: p.Name, Value: fmt.Sprint(v)})
+		}
+	}
+	if len(q) > 0 {
+		path = fmt.Sprintf("%s?%s", path, q.Encode())
+	}
+
+	req := yaml.MapSlice{}
+	if len(headers) > 0 {
+		req = append(req, yaml.MapItem{Key: "headers", Value: headers})
+	}
+	body, err := requestBodyExample(method, op.RequestBody)
+	if err != nil {
+		return nil, err
+	}
+	req = append(req, yaml.MapItem{Key: "body", Value: body})
+
+	step := yaml.MapSlice{}
+	desc := op.Summary
+	if desc == "" {
+		desc = op.OperationId
+	}
+	if desc != "" {
+		step = append(step, yaml.MapItem{Key: "desc", Value: desc})
+	}
+	step = append(step, yaml.MapItem{Key: key, Value: yaml.MapSlice{
+		{Key: path, Value: yaml.MapSlice{
+			{Key: strings.ToLower(method), Value: req},
+		}},
+	}})
+	if cond := successStatusCond(op.Responses); cond != "" {
+		step = append(step, yaml.MapItem{Key: "test", Value: cond})
+	}
+	return step, nil
+}
+
+// operationParameters returns the parameters of the operation. Parameters of the operation override the ones of the path item.
+func operationParameters(pi *v3.PathItem, op *v3.Operation) []*v3.Parameter {
+	params := map[string]*v3.Parameter{}
+	var names []string
+	for _, p := range append(pi.Parameters, op.Parameters...) {
+		k := fmt.Sprintf("%s:%s", p.In, p.Name)
+		if _, ok := params[k]; !ok {
+			names = append(names, k)
+		}
+		params[k] = p
+	}
+	ps := make([]*v3.Parameter, 0, len(names))
+	for _, k := range names {
+		ps = append(ps, params[k])
+	}
+	return ps
+}
+
+// successStatusCond returns the condition of the documented success ( 2xx ) status.
+func successStatusCond(res *v3.Responses) string {
+	if res == nil {
+		return ""
+	}
+	var codes []string
+	for c := res.Codes.First(); c != nil; c = c.Next() {
+		if strings.HasPrefix(c.Key(), "2") {
+			codes = append(codes, c.Key())
+		}
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	sort.Strings(codes)
+	c := codes[0]
+	if _, err := strconv.Atoi(c); err != nil {
+		// 2XX
+		return "current.res.status >= 200 && current.res.status < 300"
+	}
+	return fmt.Sprintf("current.res.status == %s", c)
+}
+
+func parameterExample(p *v3.Parameter) (any, error) {
+	if p.Example != nil {
+		return decodeNode(p.Example)
+	}
+	if e := p.Examples.First(); e != nil && e.Value().Value != nil {
+		return decodeNode(e.Value().Value)
+	}
+	if p.Schema == nil {
+		return "", nil
+	}
+	return fakeFromSchema(p.Schema, 0)
+}
+
+// requestBodyExample returns the body of the request. The media type application/json takes precedence.
+func requestBodyExample(method string, rb *v3.RequestBody) (any, error) {
+	if rb == nil || rb.Content == nil || rb.Content.Len() == 0 {
+		switch strings.ToUpper(method) {
+		case "POST", "PATCH":
+			// The HTTP runner requires the body for POST and PATCH
+			return yaml.MapSlice{{Key: MediaTypeApplicationJSON, Value: map[string]any{}}}, nil
+		default:
+			return nil, nil
+		}
+	}
+	mt, ok := rb.Content.Get(MediaTypeApplicationJSON)
+	var contentType string
+	if ok {
+		contentType = MediaTypeApplicationJSON
+	} else {
+		first := rb.Content.First()
+		contentType, mt = first.Key(), first.Value()
+	}
+	v, err := mediaTypeExample(mt)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.MapSlice{{Key: contentType, Value: v}}, nil
+}
+
+func mediaTypeExample(mt *v3.MediaType) (any, error) {
+	if mt.Example != nil {
+		return decodeNode(mt.Example)
+	}
+	if e := mt.Examples.First(); e != nil && e.Value().Value != nil {
+		return decodeNode(e.Value().Value)
+	}
+	if mt.Schema == nil {
+		return map[string]any{}, nil
+	}
+	return fakeFromSchema(mt.Schema, 0)
+}
+
+// fakeFromSchema returns the value of the schema. The example, default, const or enum of the schema takes precedence over the faked value.
+func fakeFromSchema(sp *base.SchemaProxy, depth int) (any, error) {
+	s, err := sp.BuildSchema()
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || depth > maxFakeDepth {
+		return nil, nil
+	}
+	for _, n := range []*yamlv3.Node{s.Example, s.Default, s.Const} {
+		if n != nil {
+			return decodeNode(n)
+		}
+	}
+	if len(s.Examples) > 0 {
+		return decodeNode(s.Examples[0])
+	}
+	if len(s.Enum) > 0 {
+		return decodeNode(s.Enum[0])
+	}
+	if len(s.AllOf) > 0 {
+		merged := yaml.MapSlice{}
+		for _, a := range s.AllOf {
+			v, err := fakeFromSchema(a, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			ms, ok := v.(yaml.MapSlice)
+			if !ok {
+				return v, nil
+			}
+			merged = mergeMapSlice(merged, ms)
+		}
+		if s.Properties != nil {
+			ms, err := fakeObject(s, depth)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeMapSlice(merged, ms)
+		}
+		return merged, nil
+	}
+	if len(s.OneOf) > 0 {
+		return fakeFromSchema(s.OneOf[0], depth+1)
+	}
+	if len(s.AnyOf) > 0 {
+		return fakeFromSchema(s.AnyOf[0], depth+1)
+	}
+	switch schemaType(s) {
+	case "object":
+		return fakeObject(s, depth)
+	case "array":
+		if s.Items == nil || !s.Items.IsA() {
+			return []any{}, nil
+		}
+		n := int64(1)
+		if s.MinItems != nil && *s.MinItems > n {
+			n = *s.MinItems
+		}
+		var items []any
+		for i := int64(0); i < n; i++ {
+			v, err := fakeFromSchema(s.Items.A, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case "integer":
+		return int64(fakeNumber(s)), nil
+	case "number":
+		return fakeNumber(s), nil
+	case "boolean":
+		return true, nil
+	case "null":
+		return nil, nil
+	default:
+		return fakeString(s), nil
+	}
+}
+
+func fakeObject(s *base.Schema, depth int) (yaml.MapSlice, error) {
+	ms := yaml.MapSlice{}
+	for p := s.Properties.First(); p != nil; p = p.Next() {
+		ps, err := p.Value().BuildSchema()
+		if err != nil {
+			return nil, err
+		}
+		if ps != nil && ps.ReadOnly != nil && *ps.ReadOnly {
+			continue
+		}
+		v, err := fakeFromSchema(p.Value(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, yaml.MapItem{Key: p.Key(), Value: v})
+	}
+	return ms, nil
+}
+
+// schemaType returns the type of the schema. If the type is omitted, it is inferred from the keywords.
+func schemaType(s *base.Schema) string {
+	for _, t := range s.Type {
+		if t != "null" {
+			return t
+		}
+	}
+	switch {
+	case s.Properties != nil:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	case len(s.Type) > 0:
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+func fakeNumber(s *base.Schema) float64 {
+	v := 1.0
+	if s.Minimum != nil && *s.Minimum > v {
+		v = *s.Minimum
+	}
+	if s.Maximum != nil && *s.Maximum < v {
+		v = *s.Maximum
+	}
+	return v
+}
+
+func fakeString(s *base.Schema) string {
+	var v string
+	switch s.Format {
+	case "date-time":
+		v = "2006-01-02T15:04:05Z"
+	case "date":
+		v = "2006-01-02"
+	case "time":
+		v = "15:04:05"
+	case "email":
+		v = "alice@example.com"
+	case "uuid":
+		v = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	case "uri", "url":
+		v = "https://example.com"
+	case "hostname":
+		v = "example.com"
+	case "ipv4":
+		v = "192.0.2.1"
+	case "ipv6":
+		v = "2001:db8::1"
+	default:
+		v = "string"
+	}
+	if s.MinLength != nil && int64(len(v)) < *s.MinLength {
+		v += strings.Repeat("x", int(*s.MinLength)-len(v))
+	}
+	if s.MaxLength != nil && int64(len(v)) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+// mergeMapSlice appends the items of src whose keys are not in dst.
+func mergeMapSlice(dst, src yaml.MapSlice) yaml.MapSlice {
+L:
+	for _, i := range src {
+		for _, j := range dst {
+			if j.Key == i.Key {
+				continue L
+			}
+		}
+		dst = append(dst, i)
+	}
+	return dst
+}
+
+func decodeNode(n *yamlv3.Node) (any, error) {
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,129 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/tenntenn/golden"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAppendStepsFromOpenAPI3(t *testing.T) {
+	tests := []struct {
+		spec string
+	}{
+		{"testdata/openapi3.yml"},
+		{"testdata/openapi3_new.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			rb := NewRunbook("")
+			if err := rb.AppendStepsFromOpenAPI3(tt.spec, filepath.Base(tt.spec)); err != nil {
+				t.Fatal(err)
+			}
+			got := new(bytes.Buffer)
+			enc := yaml.NewEncoder(got)
+			if err := enc.Encode(rb); err != nil {
+				t.Fatal(err)
+			}
+
+			f := fmt.Sprintf("%s.from_openapi", filepath.Base(tt.spec))
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", f, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", f, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAppendStepsFromOpenAPI3Coverage(t *testing.T) {
+	ctx := context.Background()
+	rb := NewRunbook("")
+	if err := rb.AppendStepsFromOpenAPI3("testdata/openapi3.yml", "openapi3.yml"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := yaml.Marshal(rb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write the runbook next to the document
+	f, err := os.CreateTemp("testdata", "from_openapi*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := f.Name()
+	t.Cleanup(func() {
+		_ = os.Remove(p)
+	})
+	if _, err := f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cov, err := o.collectCoverage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cov.Specs) != 1 {
+		t.Fatalf("got %d specs want 1", len(cov.Specs))
+	}
+	for k, v := range cov.Specs[0].Coverages {
+		if v != 1 {
+			t.Errorf("%s: got %d want 1", k, v)
+		}
+	}
+}
+
+func TestAppendStepsFromOpenAPI3WithMap(t *testing.T) {
+	rb := NewRunbook("")
+	rb.useMap = true
+	if err := rb.AppendStepsFromOpenAPI3("testdata/openapi3_new.yml", ""); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"listPets", "createPet", "getPet", "updatePet", "deletePet"}
+	if len(rb.stepKeys) != len(want) {
+		t.Fatalf("got %v want %v", rb.stepKeys, want)
+	}
+	for i := range want {
+		if rb.stepKeys[i] != want[i] {
+			t.Errorf("got %v want %v", rb.stepKeys[i], want[i])
+		}
+	}
+	if got := rb.Runners["req"].(map[string]any)["openapi3"]; got != "testdata/openapi3_new.yml" {
+		t.Errorf("got %v want %v", got, "testdata/openapi3_new.yml")
+	}
+}
+
+func TestOpenAPI3StepKey(t *testing.T) {
+	rb := NewRunbook("")
+	rb.stepKeys = []string{"get", "get3", "post"}
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"get", "get2"},
+		{"get", "get4"},
+		{"put", "put"},
+		{"post", "post2"},
+	}
+	for _, tt := range tests {
+		got := rb.openAPI3StepKey(tt.method, &v3.Operation{})
+		if got != tt.want {
+			t.Errorf("got %v want %v", got, tt.want)
+		}
+		rb.stepKeys = append(rb.stepKeys, got)
+	}
+}
@@ -0,0 +1,726 @@
+package runn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PostmanRunbook is the runbook converted from a folder of the Postman collection.
+type PostmanRunbook struct {
+	// Name - Name of the runbook file ( without extension ) generated from the folder path
+	Name    string
+	Runbook *runbook
+}
+
+// Postman Collection Format v2.1
+// ref: https://schema.postman.com/collection/json/v2.1.0/draft-07/docs/index.html
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []*postmanItem     `json:"item"`
+	Variable []*postmanVariable `json:"variable"`
+	Auth     *postmanAuth       `json:"auth"`
+	Event    []*postmanEvent    `json:"event"`
+}
+
+type postmanItem struct {
+	Name     string          `json:"name"`
+	Item     []*postmanItem  `json:"item"`
+	Request  *postmanRequest `json:"request"`
+	Event    []*postmanEvent `json:"event"`
+	Auth     *postmanAuth    `json:"auth"`
+	Disabled bool            `json:"disabled"`
+}
+
+type postmanRequest struct {
+	Method string             `json:"method"`
+	Header []*postmanKeyValue `json:"header"`
+	URL    postmanURL         `json:"url"`
+	Body   *postmanBody       `json:"body"`
+	Auth   *postmanAuth       `json:"auth"`
+}
+
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+type postmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []*postmanKeyValue `json:"urlencoded"`
+	FormData   []*postmanKeyValue `json:"formdata"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+	Disabled bool `json:"disabled"`
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanAuth struct {
+	Type   string                  `json:"type"`
+	Bearer []*postmanAuthAttribute `json:"bearer"`
+}
+
+type postmanAuthAttribute struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanScriptExec `json:"exec"`
+	} `json:"script"`
+	Disabled bool `json:"disabled"`
+}
+
+type postmanScriptExec []string
+
+// UnmarshalJSON unmarshals the request that is the object or the URL string.
+func (r *postmanRequest) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		r.Method = http.MethodGet
+		r.URL.Raw = s
+		return nil
+	}
+	type request postmanRequest
+	rr := request{}
+	if err := json.Unmarshal(b, &rr); err != nil {
+		return err
+	}
+	*r = postmanRequest(rr)
+	return nil
+}
+
+// UnmarshalJSON unmarshals the URL that is the object or the string.
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		u.Raw = s
+		return nil
+	}
+	type pURL postmanURL
+	uu := pURL{}
+	if err := json.Unmarshal(b, &uu); err != nil {
+		return err
+	}
+	*u = postmanURL(uu)
+	return nil
+}
+
+// UnmarshalJSON unmarshals the script that is the lines or the string.
+func (e *postmanScriptExec) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*e = strings.Split(s, "\n")
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*e = ss
+	return nil
+}
+
+var (
+	postmanVarRe = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+	// The placeholder of the Postman variable survives the conversion to the step ( e.g. URL encoding ).
+	postmanPlaceholderRe = regexp.MustCompile(`RUNNPOSTMANVAR(\d+)X`)
+	postmanNumberRe      = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+	postmanOriginRe      = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*://[^/?#]*)`)
+	postmanSlugSepRe     = regexp.MustCompile(`_+`)
+	postmanCamelRe       = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	postmanVarOriginRe   = regexp.MustCompile(`^\{\{\s*([^{}\s]+)\s*\}\}`)
+	postmanTestFuncRe    = regexp.MustCompile(`pm\.test\(\s*(?:"[^"]*"|'[^']*'|` + "`[^`]*`" + `)\s*,\s*(?:function\s*\(\s*\)|\(\s*\)\s*=>)\s*\{`)
+	postmanTestEndRe     = regexp.MustCompile(`^\}\s*\)\s*;?$`)
+	postmanJSONAliasRe   = regexp.MustCompile(`^(?:var|let|const)\s+([A-Za-z_$][\w$]*)\s*=\s*pm\.response\.json\(\)$`)
+	postmanStatusRe      = regexp.MustCompile(`^pm\.response\.to\.have\.status\(\s*(\d{3})\s*\)$`)
+	postmanCodeRe        = regexp.MustCompile(`^pm\.expect\(\s*pm\.response\.code\s*\)\.to\.(?:eql|equal|be\.equal|deep\.equal)\(\s*(\d{3})\s*\)$`)
+	postmanCodeOneOfRe   = regexp.MustCompile(`^pm\.expect\(\s*pm\.response\.code\s*\)\.to\.be\.oneOf\(\s*\[([\d\s,]+)\]\s*\)$`)
+	postmanExpectEqRe    = regexp.MustCompile(`^pm\.expect\(\s*(pm\.response\.json\(\)|[A-Za-z_$][\w$]*)((?:\.[A-Za-z_$][\w$]*|\[\d+\]|\["[^"]*"\]|\['[^']*'\])*)\s*\)\.to\.(?:eql|equal|be\.equal|deep\.equal|deep\.eql)\((.+)\)$`)
+)
+
+// NewRunbooksFromPostman converts the Postman collection ( v2.1 ) into runbooks.
+// Each folder becomes a runbook, and each request becomes an HTTP step.
+// It also returns the parts of the collection that could not be translated ( e.g. pre-request scripts ).
+func NewRunbooksFromPostman(r io.Reader) ([]*PostmanRunbook, []string, error) {
+	c := &postmanCollection{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode Postman collection: %w", err)
+	}
+	vars := map[string]any{}
+	for _, v := range c.Variable {
+		if v.Disabled {
+			continue
+		}
+		vars[v.Key] = v.Value
+	}
+	pc := &postmanConverter{
+		vars:      vars,
+		collected: map[string]struct{}{},
+	}
+	pc.walk(c.Info.Name, nil, c.Item, c.Auth, c.Event)
+	if len(pc.runbooks) == 0 {
+		return nil, nil, errors.New("no requests found in the Postman collection")
+	}
+	return pc.runbooks, pc.unsupported, nil
+}
+
+type postmanConverter struct {
+	// vars - Variables of the collection
+	vars      map[string]any
+	runbooks  []*PostmanRunbook
+	collected map[string]struct{}
+	// unsupported - Parts of the collection that could not be translated
+	unsupported []string
+}
+
+// postmanStep is the context of converting a request into a step.
+type postmanStep struct {
+	pc           *postmanConverter
+	rb           *PostmanRunbook
+	name         string
+	placeholders []string
+}
+
+// walk converts the items of the folder into a runbook and walks the subfolders.
+func (pc *postmanConverter) walk(name string, parents []string, items []*postmanItem, auth *postmanAuth, events []*postmanEvent) {
+	path := append(slices.Clone(parents), name)
+	for _, e := range events {
+		if !e.Disabled && strings.TrimSpace(strings.Join(e.Script.Exec, "")) != "" {
+			pc.unsupported = append(pc.unsupported, fmt.Sprintf("%s: %s script of the folder is not supported", strings.Join(path, " / "), e.Listen))
+		}
+	}
+	prb := &PostmanRunbook{
+		Name:    pc.runbookName(path),
+		Runbook: NewRunbook(strings.Join(path, " / ")),
+	}
+	var folders []*postmanItem
+	for _, it := range items {
+		if it.Disabled {
+			continue
+		}
+		if it.Request == nil {
+			folders = append(folders, it)
+			continue
+		}
+		s := &postmanStep{pc: pc, rb: prb, name: strings.Join(append(slices.Clone(path), it.Name), " / ")}
+		a := auth
+		if it.Request.Auth != nil {
+			a = it.Request.Auth
+		}
+		step, err := pc.toStep(s, it, a)
+		if err != nil {
+			s.unsupported("%s", err)
+			continue
+		}
+		prb.Runbook.Steps = append(prb.Runbook.Steps, step)
+	}
+	if len(prb.Runbook.Steps) > 0 {
+		pc.runbooks = append(pc.runbooks, prb)
+	}
+	for _, f := range folders {
+		a := auth
+		if f.Auth != nil {
+			a = f.Auth
+		}
+		pc.walk(f.Name, path, f.Item, a, f.Event)
+	}
+}
+
+func (pc *postmanConverter) toStep(s *postmanStep, it *postmanItem, auth *postmanAuth) (yaml.MapSlice, error) {
+	pr := it.Request
+	method := strings.ToUpper(pr.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	origin, rest, err := pc.splitURL(s, pr.URL.Raw)
+	if err != nil {
+		return nil, err
+	}
+	rest = s.replaceVars(rest)
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	u, err := url.Parse(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", pr.URL.Raw, err)
+	}
+	body, contentType := pc.requestBody(s, pr.Body)
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range pr.Header {
+		if h.Disabled {
+			continue
+		}
+		req.Header.Add(http.CanonicalHeaderKey(h.Key), s.replaceVars(h.Value))
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if auth != nil {
+		switch auth.Type {
+		case "noauth", "":
+		case "bearer":
+			for _, a := range auth.Bearer {
+				if a.Key == "token" {
+					req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.replaceVars(fmt.Sprintf("%v", a.Value))))
+				}
+			}
+		default:
+			s.unsupported("%s auth is not supported", auth.Type)
+		}
+	}
+	if strings.Contains(req.Header.Get("Content-Type"), "json") && body != nil {
+		// Unquoted variables ( e.g. {"id": {{id}}} ) are quoted to be decoded as JSON
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		quoted, unquoted := quotePostmanPlaceholders(string(b))
+		if !json.Valid([]byte(quoted)) {
+			s.unsupported("the body is not valid JSON, so it is converted as a string")
+			req.Header.Set("Content-Type", MediaTypeTextPlain)
+		} else {
+			for _, i := range unquoted {
+				s.typeVar(s.placeholders[i])
+			}
+		}
+		req.Body = io.NopCloser(strings.NewReader(quoted))
+	}
+
+	key := s.rb.setHTTPRunner(origin)
+	step, err := CreateHTTPStepMapSlice(key, req)
+	if err != nil {
+		return nil, err
+	}
+	step = append(yaml.MapSlice{{Key: "desc", Value: it.Name}}, step...)
+	if cond := pc.testCond(s, it.Event); cond != "" {
+		step = append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", cond)})
+	}
+	replaced, ok := s.restoreVars(step).(yaml.MapSlice)
+	if !ok {
+		return nil, fmt.Errorf("failed to restore variables of the step: %v", step)
+	}
+	return replaced, nil
+}
+
+// splitURL splits the raw URL into the origin ( endpoint of the runner ) and the rest ( path and query ).
+func (pc *postmanConverter) splitURL(s *postmanStep, raw string) (string, string, error) {
+	raw = strings.TrimSpace(raw)
+	if m := postmanVarOriginRe.FindStringSubmatch(raw); m != nil {
+		// e.g. {{baseUrl}}/users
+		rest := raw[len(m[0]):]
+		v, ok := pc.vars[m[1]]
+		if !ok || fmt.Sprintf("%v", v) == "" {
+			s.unsupported("variable %q of the endpoint is not defined in the collection, so the environment variable %s is used", m[1], postmanEnvName(m[1]))
+			return fmt.Sprintf("${%s}", postmanEnvName(m[1])), rest, nil
+		}
+		origin, path, err := pc.splitURL(s, fmt.Sprintf("%v", v))
+		if err != nil {
+			return "", "", err
+		}
+		return origin, strings.TrimSuffix(path, "/") + rest, nil
+	}
+	if !strings.Contains(raw, "://") {
+		// Postman uses http:// when the scheme is omitted
+		raw = "http://" + raw
+	}
+	m := postmanOriginRe.FindStringSubmatch(raw)
+	if m == nil {
+		return "", "", fmt.Errorf("invalid URL %q", raw)
+	}
+	origin := postmanVarRe.ReplaceAllStringFunc(m[1], func(in string) string {
+		k := postmanVarRe.FindStringSubmatch(in)[1]
+		v, ok := pc.vars[k]
+		if !ok {
+			s.unsupported("variable %q of the endpoint is not defined in the collection, so the environment variable %s is used", k, postmanEnvName(k))
+			return fmt.Sprintf("${%s}", postmanEnvName(k))
+		}
+		return fmt.Sprintf("%v", v)
+	})
+	return origin, raw[len(m[1]):], nil
+}
+
+func (pc *postmanConverter) requestBody(s *postmanStep, b *postmanBody) (io.Reader, string) {
+	if b == nil || b.Disabled {
+		return nil, ""
+	}
+	switch b.Mode {
+	case "", "none":
+		return nil, ""
+	case "raw":
+		if b.Raw == "" {
+			return nil, ""
+		}
+		var contentType string
+		switch b.Options.Raw.Language {
+		case "json":
+			contentType = MediaTypeApplicationJSON
+		case "xml":
+			contentType = MediaTypeApplicationXML
+		default:
+			contentType = MediaTypeTextPlain
+		}
+		return strings.NewReader(s.replaceVars(b.Raw)), contentType
+	case "urlencoded":
+		vs := url.Values{}
+		for _, kv := range b.URLEncoded {
+			if kv.Disabled {
+				continue
+			}
+			vs.Add(s.replaceVars(kv.Key), s.replaceVars(kv.Value))
+		}
+		return strings.NewReader(vs.Encode()), MediaTypeApplicationFormUrlencoded
+	case "formdata":
+		vs := url.Values{}
+		for _, kv := range b.FormData {
+			if kv.Disabled {
+				continue
+			}
+			if kv.Type == "file" {
+				s.unsupported("file field %q of the form data is not supported", kv.Key)
+				continue
+			}
+			vs.Add(s.replaceVars(kv.Key), s.replaceVars(kv.Value))
+		}
+		// The text fields of the form data are sent as urlencoded form
+		return strings.NewReader(vs.Encode()), MediaTypeApplicationFormUrlencoded
+	default:
+		s.unsupported("%s body is not supported", b.Mode)
+		return nil, ""
+	}
+}
+
+// testCond translates the simple assertions of the test scripts into the test condition.
+func (pc *postmanConverter) testCond(s *postmanStep, events []*postmanEvent) string {
+	var conds []string
+	for _, e := range events {
+		if e.Disabled {
+			continue
+		}
+		switch e.Listen {
+		case "test":
+		default:
+			if strings.TrimSpace(strings.Join(e.Script.Exec, "")) != "" {
+				s.unsupported("%s script is not supported", e.Listen)
+			}
+			continue
+		}
+		aliases := map[string]struct{}{}
+		for _, stmt := range postmanStatements(e.Script.Exec) {
+			if m := postmanJSONAliasRe.FindStringSubmatch(stmt); m != nil {
+				aliases[m[1]] = struct{}{}
+				continue
+			}
+			cond, ok := postmanAssertionToCond(stmt, aliases)
+			if !ok {
+				s.unsupported("test script %q is not supported", stmt)
+				continue
+			}
+			conds = append(conds, cond)
+		}
+	}
+	return strings.Join(conds, "\n&& ")
+}
+
+func (pc *postmanConverter) runbookName(path []string) string {
+	var parts []string
+	for _, p := range path {
+		if n := postmanSlug(p); n != "" {
+			parts = append(parts, n)
+		}
+	}
+	name := strings.Join(parts, "-")
+	if name == "" {
+		name = "postman"
+	}
+	// Names must be unique
+	n := name
+	for i := 2; ; i++ {
+		if _, ok := pc.collected[n]; !ok {
+			break
+		}
+		n = fmt.Sprintf("%s_%d", name, i)
+	}
+	pc.collected[n] = struct{}{}
+	return n
+}
+
+// replaceVars replaces the Postman variables with the placeholders.
+func (s *postmanStep) replaceVars(in string) string {
+	return postmanVarRe.ReplaceAllStringFunc(in, func(v string) string {
+		k := postmanVarRe.FindStringSubmatch(v)[1]
+		if strings.HasPrefix(k, "$") {
+			// Dynamic variables ( e.g. {{$guid}} ) are replaced with the placeholder variables
+			dk := postmanDynamicVarName(k)
+			if _, ok := s.rb.Runbook.Vars[dk]; !ok {
+				s.unsupported("dynamic variable %q is not supported, so the variable %q is set to the placeholder %q", k, dk, k)
+				s.rb.Runbook.Vars[dk] = k
+			}
+			k = dk
+		} else if _, ok := s.rb.Runbook.Vars[k]; !ok {
+			v, ok := s.pc.vars[k]
+			if !ok {
+				s.unsupported("variable %q is not defined in the collection, so it is set to an empty string", k)
+				v = ""
+			}
+			s.rb.Runbook.Vars[k] = v
+		}
+		s.placeholders = append(s.placeholders, k)
+		return fmt.Sprintf("RUNNPOSTMANVAR%dX", len(s.placeholders)-1)
+	})
+}
+
+// typeVar converts the variable used as a JSON value ( e.g. {"id": {{id}}} ) into the number or the boolean.
+// Postman inserts the variable as is, but the values of the collection variables are strings.
+func (s *postmanStep) typeVar(k string) {
+	v, ok := s.rb.Runbook.Vars[k].(string)
+	if !ok {
+		return
+	}
+	tv, ok := postmanTypedValue(v)
+	if !ok {
+		s.unsupported("variable %q is used as a JSON value, but %q is not a number or a boolean, so it is sent as a string", k, v)
+		return
+	}
+	s.rb.Runbook.Vars[k] = tv
+}
+
+// restoreVars replaces the placeholders in the step with the references to vars.
+func (s *postmanStep) restoreVars(in any) any {
+	switch v := in.(type) {
+	case string:
+		return postmanPlaceholderRe.ReplaceAllStringFunc(v, func(p string) string {
+			i, _ := strconv.Atoi(postmanPlaceholderRe.FindStringSubmatch(p)[1])
+			return fmt.Sprintf("{{ vars.%s }}", s.placeholders[i])
+		})
+	case yaml.MapSlice:
+		out := yaml.MapSlice{}
+		for _, item := range v {
+			out = append(out, yaml.MapItem{Key: s.restoreVars(item.Key), Value: s.restoreVars(item.Value)})
+		}
+		return out
+	case map[string]string:
+		out := map[string]string{}
+		for k, vv := range v {
+			out[s.restoreVars(k).(string)] = s.restoreVars(vv).(string)
+		}
+		return out
+	case map[string]any:
+		out := map[string]any{}
+		for k, vv := range v {
+			out[s.restoreVars(k).(string)] = s.restoreVars(vv)
+		}
+		return out
+	case []any:
+		out := []any{}
+		for _, vv := range v {
+			out = append(out, s.restoreVars(vv))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (s *postmanStep) unsupported(format string, a ...any) {
+	s.pc.unsupported = append(s.pc.unsupported, fmt.Sprintf("%s: %s", s.name, fmt.Sprintf(format, a...)))
+}
+
+// setHTTPRunner sets the HTTP runner of the endpoint and returns the key of the runner.
+func (rb *PostmanRunbook) setHTTPRunner(endpoint string) string {
+	const httpRunnerKeyPrefix = "req"
+	for k, v := range rb.Runbook.Runners {
+		if v == endpoint {
+			return k
+		}
+	}
+	key := httpRunnerKeyPrefix
+	for i := 2; ; i++ {
+		if _, ok := rb.Runbook.Runners[key]; !ok {
+			break
+		}
+		key = fmt.Sprintf("%s%d", httpRunnerKeyPrefix, i)
+	}
+	rb.Runbook.Runners[key] = endpoint
+	return key
+}
+
+// postmanStatements splits the script into the statements excluding pm.test() wrappers and comments.
+func postmanStatements(lines []string) []string {
+	var stmts []string
+	for _, l := range lines {
+		l = postmanTestFuncRe.ReplaceAllString(l, "")
+		for _, stmt := range strings.Split(l, ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || strings.HasPrefix(stmt, "//") || postmanTestEndRe.MatchString(stmt) || stmt == "}" {
+				continue
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func postmanAssertionToCond(stmt string, aliases map[string]struct{}) (string, bool) {
+	if m := postmanStatusRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("current.res.status == %s", m[1]), true
+	}
+	if m := postmanCodeRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("current.res.status == %s", m[1]), true
+	}
+	if m := postmanCodeOneOfRe.FindStringSubmatch(stmt); m != nil {
+		var codes []string
+		for _, c := range strings.Split(m[1], ",") {
+			codes = append(codes, strings.TrimSpace(c))
+		}
+		return fmt.Sprintf("current.res.status in [%s]", strings.Join(codes, ", ")), true
+	}
+	if m := postmanExpectEqRe.FindStringSubmatch(stmt); m != nil {
+		if m[1] != "pm.response.json()" {
+			if _, ok := aliases[m[1]]; !ok {
+				return "", false
+			}
+		}
+		path := strings.NewReplacer("['", `["`, "']", `"]`).Replace(m[2])
+		v, ok := postmanLiteral(strings.TrimSpace(m[3]))
+		if !ok {
+			return "", false
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		switch v.(type) {
+		case map[string]any, []any:
+			return fmt.Sprintf("compare(current.res.body%s, %s)", path, string(b)), true
+		default:
+			return fmt.Sprintf("current.res.body%s == %s", path, string(b)), true
+		}
+	}
+	return "", false
+}
+
+// postmanLiteral parses the JavaScript literal ( JSON or single-quoted string ).
+func postmanLiteral(in string) (any, bool) {
+	if len(in) >= 2 && strings.HasPrefix(in, "'") && strings.HasSuffix(in, "'") {
+		s := in[1 : len(in)-1]
+		if strings.Contains(s, "'") {
+			return nil, false
+		}
+		return s, true
+	}
+	var v any
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// quotePostmanPlaceholders quotes the placeholders outside of JSON strings, and returns the indexes of the quoted placeholders.
+func quotePostmanPlaceholders(in string) (string, []int) {
+	var (
+		out      strings.Builder
+		inString bool
+		escaped  bool
+		quoted   []int
+	)
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString:
+			if loc := postmanPlaceholderRe.FindStringSubmatchIndex(in[i:]); loc != nil && loc[0] == 0 {
+				n, _ := strconv.Atoi(in[i+loc[2] : i+loc[3]])
+				quoted = append(quoted, n)
+				out.WriteString(`"` + in[i:i+loc[1]] + `"`)
+				i += loc[1] - 1
+				continue
+			}
+		}
+		out.WriteByte(c)
+	}
+	return out.String(), quoted
+}
+
+// postmanTypedValue returns the number or the boolean that the value of the variable looks like.
+func postmanTypedValue(v string) (any, bool) {
+	if postmanNumberRe.MatchString(v) {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+		return nil, false
+	}
+	switch v {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return nil, false
+}
+
+// postmanDynamicVarName returns the name of the placeholder variable for the dynamic variable ( e.g. $guid -> dynamicGuid ).
+func postmanDynamicVarName(k string) string {
+	k = strings.TrimPrefix(k, "$")
+	if k == "" {
+		return "dynamic"
+	}
+	return "dynamic" + strings.ToUpper(k[:1]) + k[1:]
+}
+
+func postmanSlug(in string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(in) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return strings.Trim(postmanSlugSepRe.ReplaceAllString(b.String(), "_"), "_")
+}
+
+func postmanEnvName(k string) string {
+	// e.g. baseUrl -> BASE_URL
+	return strings.ToUpper(postmanSlug(postmanCamelRe.ReplaceAllString(k, "${1}_${2}")))
+}
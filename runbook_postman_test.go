@@ -0,0 +1,139 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tenntenn/golden"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNewRunbooksFromPostman(t *testing.T) {
+	f, err := os.Open("testdata/postman_collection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+	rbs, unsupported, err := NewRunbooksFromPostman(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	for _, rb := range rbs {
+		_, _ = fmt.Fprintf(got, "# %s\n", rb.Name)
+		enc := yaml.NewEncoder(got)
+		if err := enc.Encode(rb.Runbook); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = fmt.Fprintf(got, "# unsupported\n%s\n", strings.Join(unsupported, "\n"))
+
+	g := "postman_collection.json.from_postman"
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		golden.Update(t, "testdata", g, got)
+		return
+	}
+	if diff := golden.Diff(t, "testdata", g, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPostmanAssertionToCond(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"pm.response.to.have.status(201)", "current.res.status == 201", true},
+		{"pm.expect(pm.response.code).to.equal(404)", "current.res.status == 404", true},
+		{"pm.expect(pm.response.code).to.be.oneOf([200,204])", "current.res.status in [200, 204]", true},
+		{`pm.expect(pm.response.json().data[0]["user-name"]).to.eql("bob")`, `current.res.body.data[0]["user-name"] == "bob"`, true},
+		{"pm.expect(pm.response.json().items).to.eql([])", "compare(current.res.body.items, [])", true},
+		{"pm.expect(res.count).to.eql(3)", "current.res.body.count == 3", true},
+		{"pm.expect(other.count).to.eql(3)", "", false},
+		{"pm.expect(pm.response.json().name).to.include('al')", "", false},
+		{"pm.expect(pm.response.json().name).to.eql(name)", "", false},
+	}
+	aliases := map[string]struct{}{"res": {}}
+	for _, tt := range tests {
+		got, ok := postmanAssertionToCond(tt.in, aliases)
+		if ok != tt.wantOK {
+			t.Errorf("%s: got %v want %v", tt.in, ok, tt.wantOK)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestPostmanVarsInJSONBody(t *testing.T) {
+	var got map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(ts.Close)
+	collection := fmt.Sprintf(`{
+  "info": {"name": "Typed vars"},
+  "item": [
+    {
+      "name": "Create user",
+      "request": {
+        "method": "POST",
+        "url": {"raw": "{{baseUrl}}/users"},
+        "body": {
+          "mode": "raw",
+          "raw": "{\"id\": {{id}}, \"admin\": {{admin}}, \"name\": \"{{name}}\", \"requestId\": \"{{$guid}}\"}",
+          "options": {"raw": {"language": "json"}}
+        }
+      }
+    }
+  ],
+  "variable": [
+    {"key": "baseUrl", "value": %q},
+    {"key": "id", "value": "1"},
+    {"key": "admin", "value": "true"},
+    {"key": "name", "value": "2"}
+  ]
+}`, ts.URL)
+	rbs, _, err := NewRunbooksFromPostman(strings.NewReader(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbs) != 1 {
+		t.Fatalf("got %d runbooks want 1", len(rbs))
+	}
+	p := filepath.Join(t.TempDir(), "postman.yml")
+	b, err := yaml.Marshal(rbs[0].Runbook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book(p), Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"id": float64(1), "admin": true, "name": "2", "requestId": "$guid"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -41,7 +41,7 @@ func (rnr *runnerRunner) Run(ctx context.Context, s *step) error {
 	return nil
 }
 
-func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error {
+func (rnr *runnerRunner) run(ctx context.Context, d map[string]any, s *step) error {
 	o := s.parent
 	bk := newBook()
 	bk.runners = d
@@ -78,6 +78,27 @@ func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error
 		}
 		o.sshRunners[k] = r
 	}
+	for k, r := range bk.websocketRunners {
+		if _, ok := o.websocketRunners[k]; ok {
+			return fmt.Errorf("websocket runner key %s is already exists", k)
+		}
+		o.websocketRunners[k] = r
+	}
+	for k, r := range bk.mockRunners {
+		if _, ok := o.mockRunners[k]; ok {
+			return fmt.Errorf("mock runner key %s is already exists", k)
+		}
+		if err := r.start(ctx); err != nil {
+			return err
+		}
+		o.mockRunners[k] = r
+	}
+	for k, r := range bk.queueRunners {
+		if _, ok := o.queueRunners[k]; ok {
+			return fmt.Errorf("queue runner key %s is already exists", k)
+		}
+		o.queueRunners[k] = r
+	}
 	o.record(map[string]any{})
 	return nil
 }
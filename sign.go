@@ -0,0 +1,121 @@
+package runn
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //#nosec G505
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	signTypeAWSV4 = "awsv4"
+	signTypeHMAC  = "hmac"
+)
+
+const (
+	defaultHMACAlgorithm = "sha256"
+	defaultHMACHeader    = "X-Signature"
+	defaultHMACEncoding  = "hex"
+)
+
+// HTTPSigner signs the HTTP request right before it is sent.
+// body is the encoded request body ( nil if the request has no body ).
+type HTTPSigner func(req *http.Request, body []byte) error
+
+// signNow - Clock of the built-in signers.
+var signNow = time.Now
+
+func newHTTPSigner(c *signConfig) (HTTPSigner, error) {
+	switch c.Type {
+	case signTypeAWSV4:
+		return newAWSV4Signer(c)
+	case signTypeHMAC:
+		return newHMACSigner(c)
+	default:
+		return nil, fmt.Errorf("invalid sign: unsupported type: %q", c.Type)
+	}
+}
+
+// newAWSV4Signer returns the signer of AWS Signature Version 4.
+func newAWSV4Signer(c *signConfig) (HTTPSigner, error) {
+	if c.Service == "" || c.Region == "" {
+		return nil, errors.New("invalid sign: awsv4 requires service and region")
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errors.New("invalid sign: awsv4 requires accessKeyID and secretAccessKey")
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+	}
+	s := v4.NewSigner()
+	return func(req *http.Request, body []byte) error {
+		h := sha256.Sum256(body)
+		return s.SignHTTP(req.Context(), creds, req, hex.EncodeToString(h[:]), c.Service, c.Region, signNow())
+	}, nil
+}
+
+// newHMACSigner returns the signer that sets HMAC of the request to the header.
+// The signed message is the method, the request URI, the timestamp ( empty if timestampHeader is not set ) and the body joined by "\n".
+func newHMACSigner(c *signConfig) (HTTPSigner, error) {
+	if c.Secret == "" {
+		return nil, errors.New("invalid sign: hmac requires secret")
+	}
+	algo := c.Algorithm
+	if algo == "" {
+		algo = defaultHMACAlgorithm
+	}
+	var fn func() hash.Hash
+	switch algo {
+	case "sha1":
+		fn = sha1.New
+	case "sha256":
+		fn = sha256.New
+	case "sha512":
+		fn = sha512.New
+	default:
+		return nil, fmt.Errorf("invalid sign: unsupported hmac algorithm: %q", algo)
+	}
+	enc := c.Encoding
+	if enc == "" {
+		enc = defaultHMACEncoding
+	}
+	var encode func([]byte) string
+	switch enc {
+	case "hex":
+		encode = hex.EncodeToString
+	case "base64":
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return nil, fmt.Errorf("invalid sign: unsupported hmac encoding: %q", enc)
+	}
+	header := c.Header
+	if header == "" {
+		header = defaultHMACHeader
+	}
+	return func(req *http.Request, body []byte) error {
+		var ts string
+		if c.TimestampHeader != "" {
+			ts = strconv.FormatInt(signNow().Unix(), 10)
+			req.Header.Set(c.TimestampHeader, ts)
+		}
+		msg := strings.Join([]string{req.Method, req.URL.RequestURI(), ts, string(body)}, "\n")
+		mac := hmac.New(fn, []byte(c.Secret))
+		_, _ = mac.Write([]byte(msg))
+		req.Header.Set(header, c.Prefix+encode(mac.Sum(nil)))
+		return nil
+	}, nil
+}
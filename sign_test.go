@@ -0,0 +1,175 @@
+package runn
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSV4Signer(t *testing.T) {
+	// ref: https://docs.aws.amazon.com/general/latest/gr/signature-v4-test-suite.html ( get-vanilla )
+	now := signNow
+	t.Cleanup(func() {
+		signNow = now
+	})
+	signNow = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	s, err := newHTTPSigner(&signConfig{
+		Type:            signTypeAWSV4,
+		Service:         "service",
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("got %v\nwant %v", got, "20150830T123600Z")
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	now := signNow
+	t.Cleanup(func() {
+		signNow = now
+	})
+	signNow = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+	tests := []struct {
+		c             *signConfig
+		header        string
+		want          string
+		wantTimestamp string
+	}{
+		{
+			&signConfig{Type: signTypeHMAC, Secret: "key"},
+			"X-Signature",
+			"39c059bdb74739d481d2b9b728488eb779bb19cb05a85db085815511d6be51c0",
+			"",
+		},
+		{
+			&signConfig{Type: signTypeHMAC, Secret: "key", Algorithm: "sha1", Encoding: "base64", Header: "X-Sig", Prefix: "sha1=", TimestampHeader: "X-Timestamp"},
+			"X-Sig",
+			"sha1=U6KuHZcByQMapD349KB7AJ230Kg=",
+			"1700000000",
+		},
+	}
+	for _, tt := range tests {
+		s, err := newHTTPSigner(tt.c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "http://example.com/users?page=1", strings.NewReader("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s(req, []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get(tt.header); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+		if got := req.Header.Get("X-Timestamp"); got != tt.wantTimestamp {
+			t.Errorf("got %v\nwant %v", got, tt.wantTimestamp)
+		}
+	}
+}
+
+func TestNewHTTPSignerInvalid(t *testing.T) {
+	tests := []*signConfig{
+		{Type: "unknown"},
+		{Type: signTypeAWSV4, Service: "execute-api", Region: "us-east-1"},
+		{Type: signTypeAWSV4, AccessKeyID: "id", SecretAccessKey: "secret"},
+		{Type: signTypeHMAC},
+		{Type: signTypeHMAC, Secret: "key", Algorithm: "md5"},
+		{Type: signTypeHMAC, Secret: "key", Encoding: "base32"},
+	}
+	for _, tt := range tests {
+		if _, err := newHTTPSigner(tt); err == nil {
+			t.Errorf("want error: %#v", tt)
+		}
+	}
+}
+
+func TestHTTPRunnerSign(t *testing.T) {
+	ctx := context.Background()
+	verify := func(t *testing.T) *httptest.Server {
+		t.Helper()
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			msg := strings.Join([]string{r.Method, r.URL.RequestURI(), r.Header.Get("X-Runn-Timestamp"), string(b)}, "\n")
+			mac := hmac.New(sha256.New, []byte("s3cr3t"))
+			_, _ = mac.Write([]byte(msg))
+			if r.Header.Get("X-Runn-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+
+	t.Run("Built-in signer", func(t *testing.T) {
+		ts := verify(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+		o, err := New(Book("testdata/book/http_sign.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Custom signer", func(t *testing.T) {
+		ts := verify(t)
+		t.Setenv("TEST_HTTP_ENDPOINT", "http://127.0.0.1:1")
+		var signed []byte
+		signer := func(req *http.Request, body []byte) error {
+			signed = body
+			ts := "1700000000"
+			msg := strings.Join([]string{req.Method, req.URL.RequestURI(), ts, string(body)}, "\n")
+			mac := hmac.New(sha256.New, []byte("s3cr3t"))
+			_, _ = mac.Write([]byte(msg))
+			req.Header.Set("X-Runn-Timestamp", ts)
+			req.Header.Set("X-Runn-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			return nil
+		}
+		o, err := New(Book("testdata/book/http_sign.yml"), HTTPRunner("req", ts.URL, ts.Client(), HTTPSign(signer)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"username":"alice"}`; string(signed) != want {
+			t.Errorf("got %s\nwant %s", signed, want)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/builtin"
+)
+
+const snapshotRunnerKey = "snapshot"
+
+const (
+	snapshotDir = "__snapshots__"
+	snapshotExt = ".json"
+)
+
+type snapshotRunner struct{}
+
+type snapshotRequest struct {
+	expr       string
+	file       string
+	ignoreKeys []string
+}
+
+type snapshotMismatchError struct {
+	path string
+	diff string
+}
+
+func newSnapshotMismatchError(path, diff string) *snapshotMismatchError {
+	return &snapshotMismatchError{
+		path: path,
+		diff: diff,
+	}
+}
+
+func (se *snapshotMismatchError) Error() string {
+	diff := SprintMultilinef("  %s\n", "%s", strings.TrimSuffix(se.diff, "\n"))
+	return fmt.Sprintf("snapshot does not match\n\nSnapshot:\n  %s\n\nDiff (-snapshot +current):\n%s", se.path, diff)
+}
+
+func newSnapshotRunner() *snapshotRunner {
+	return &snapshotRunner{}
+}
+
+func (rnr *snapshotRunner) Run(ctx context.Context, s *step, first bool) error {
+	r := s.snapshotRequest
+	o := s.parent
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	if first {
+		store[storeRootKeyPrevious] = o.store.latest()
+	} else {
+		store[storeRootKeyPrevious] = o.store.previous()
+		store[storeRootKeyCurrent] = o.store.latest()
+	}
+	p, err := rnr.snapshotPath(r, s, store)
+	if err != nil {
+		return err
+	}
+	v, err := Eval(r.expr, store)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, p, v, r.ignoreKeys, s, first); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *snapshotRunner) run(_ context.Context, p string, v any, ignoreKeys []string, s *step, first bool) error {
+	o := s.parent
+	b, err := os.ReadFile(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || (err == nil && o.updateSnapshots):
+		// The first run or update writes the snapshot.
+		if err := writeSnapshot(p, v); err != nil {
+			return err
+		}
+		o.Debugf(yellow("Write snapshot %s\n"), p)
+	case err != nil:
+		return err
+	default:
+		var stored any
+		if err := json.Unmarshal(b, &stored); err != nil {
+			return fmt.Errorf("invalid snapshot %s: %w", p, err)
+		}
+		d, err := snapshotDiff(stored, v, ignoreKeys)
+		if err != nil {
+			return err
+		}
+		if d != "" {
+			return newSnapshotMismatchError(p, d)
+		}
+	}
+	if first {
+		o.record(nil)
+	}
+	return nil
+}
+
+// snapshotPath returns the path of the snapshot file.
+// The default is __snapshots__/<runbook name>/<step key>.json next to the runbook.
+// In loops, the loop index is added to the file name ( __snapshots__/<runbook name>/<step key>.<loop index>.json ).
+func (rnr *snapshotRunner) snapshotPath(r *snapshotRequest, s *step, store map[string]any) (string, error) {
+	o := s.parent
+	if r.file == "" {
+		if o.bookPath == "" {
+			return "", errors.New("snapshot file is required for the runbook without path")
+		}
+		name := strings.TrimSuffix(filepath.Base(o.bookPath), filepath.Ext(o.bookPath))
+		file := s.key
+		if s.loopIndex != nil {
+			file = fmt.Sprintf("%s.%d", file, *s.loopIndex)
+		}
+		return filepath.Join(filepath.Dir(o.bookPath), snapshotDir, name, file+snapshotExt), nil
+	}
+	e, err := EvalExpand(r.file, store)
+	if err != nil {
+		return "", err
+	}
+	p, ok := e.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid snapshot file: %v", e)
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(filepath.Dir(o.bookPath), p)
+	}
+	return p, nil
+}
+
+func writeSnapshot(p string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(p, append(b, '\n'), 0o600)
+}
+
+func snapshotDiff(stored, current any, ignoreKeys []string) (d string, err error) {
+	// builtin.Diff panics on invalid ignore keys.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to compare snapshot: %v", r)
+		}
+	}()
+	return builtin.Diff(stored, current, ignoreKeys...), nil
+}
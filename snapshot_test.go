@@ -0,0 +1,129 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b, err := os.ReadFile("testdata/book/snapshot.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := filepath.Join(dir, "snapshot.yml")
+	if err := os.WriteFile(book, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(dir, "__snapshots__", "snapshot", "user.json")
+	if err := setScopes(ScopeAllowReadParent); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+		want    any
+	}{
+		{"first run writes snapshots", nil, false, map[string]any{"name": "alice", "meta": map[string]any{"requestID": "xxx"}}},
+		{"same values", nil, false, map[string]any{"name": "alice", "meta": map[string]any{"requestID": "xxx"}}},
+		{"ignored key changed", []Option{Var("requestID", "yyy")}, false, map[string]any{"name": "alice", "meta": map[string]any{"requestID": "xxx"}}},
+		{"value changed", []Option{Var("name", "bob")}, true, map[string]any{"name": "alice", "meta": map[string]any{"requestID": "xxx"}}},
+		{"update snapshots", []Option{Var("name", "bob"), UpdateSnapshots(true)}, false, map[string]any{"name": "bob", "meta": map[string]any{"requestID": "xxx"}}},
+		{"updated values", []Option{Var("name", "bob")}, false, map[string]any{"name": "bob", "meta": map[string]any{"requestID": "xxx"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New(append([]Option{Book(book)}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr {
+				var se *snapshotMismatchError
+				if !errors.As(err, &se) {
+					t.Errorf("got %v\nwant snapshot mismatch", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(snapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSnapshotInLoop(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b, err := os.ReadFile("testdata/snapshot_loop.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := filepath.Join(dir, "snapshot_loop.yml")
+	if err := os.WriteFile(book, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setScopes(ScopeAllowReadParent); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for i := 0; i < 2; i++ {
+		o, err := New(Book(book))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		b, err := os.ReadFile(filepath.Join(dir, "__snapshots__", "snapshot_loop", fmt.Sprintf("user.%d.json", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got any
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{"index": float64(i)}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestSnapshotMismatchError(t *testing.T) {
+	err := newSnapshotMismatchError("path/to/snapshot.json", "-a\n+b\n")
+	want := "snapshot does not match\n\nSnapshot:\n  path/to/snapshot.json\n\nDiff (-snapshot +current):\n  -a\n  +b\n"
+	if got := err.Error(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
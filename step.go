@@ -13,29 +13,41 @@ type step struct {
 	ifCond    string
 	loop      *Loop
 	// loopIndex - Index of the loop is dynamically recorded at runtime
-	loopIndex        *int
-	httpRunner       *httpRunner
-	httpRequest      map[string]any
-	dbRunner         *dbRunner
-	dbQuery          map[string]any
-	grpcRunner       *grpcRunner
-	grpcRequest      map[string]any
-	cdpRunner        *cdpRunner
-	cdpActions       map[string]any
-	sshRunner        *sshRunner
-	sshCommand       map[string]any
-	execRunner       *execRunner
-	execCommand      map[string]any
-	testRunner       *testRunner
-	testCond         string
-	dumpRunner       *dumpRunner
-	dumpRequest      *dumpRequest
-	bindRunner       *bindRunner
-	bindCond         map[string]any
-	includeRunner    *includeRunner
-	includeConfig    *includeConfig
-	runnerRunner     *runnerRunner
-	runnerDefinition map[string]any
+	loopIndex         *int
+	httpRunner        *httpRunner
+	httpRequest       map[string]any
+	dbRunner          *dbRunner
+	dbQuery           map[string]any
+	grpcRunner        *grpcRunner
+	grpcRequest       map[string]any
+	cdpRunner         *cdpRunner
+	cdpActions        map[string]any
+	sshRunner         *sshRunner
+	sshCommand        map[string]any
+	websocketRunner   *websocketRunner
+	websocketRequest  map[string]any
+	mockRunner        *mockRunner
+	mockRequest       map[string]any
+	queueRunner       *queueRunner
+	queueRequest      map[string]any
+	execRunner        *execRunner
+	execCommand       map[string]any
+	testRunner        *testRunner
+	testCond          string
+	dumpRunner        *dumpRunner
+	dumpRequest       *dumpRequest
+	bindRunner        *bindRunner
+	bindCond          map[string]any
+	snapshotRunner    *snapshotRunner
+	snapshotRequest   *snapshotRequest
+	jsonSchemaRunner  *jsonSchemaRunner
+	jsonSchemaRequest *jsonSchemaRequest
+	includeRunner     *includeRunner
+	includeConfig     *includeConfig
+	parallelRunner    *parallelRunner
+	parallelConfig    *parallelConfig
+	runnerRunner      *runnerRunner
+	runnerDefinition  map[string]any
 
 	// runner values not yet detected.
 	runnerValues map[string]any
@@ -90,14 +102,26 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeCDP
 	case s.sshRunner != nil && s.sshCommand != nil:
 		tr.StepRunnerType = RunnerTypeSSH
+	case s.websocketRunner != nil && s.websocketRequest != nil:
+		tr.StepRunnerType = RunnerTypeWebSocket
+	case s.mockRunner != nil && s.mockRequest != nil:
+		tr.StepRunnerType = RunnerTypeMock
+	case s.queueRunner != nil && s.queueRequest != nil:
+		tr.StepRunnerType = RunnerTypeQueue
 	case s.execRunner != nil && s.execCommand != nil:
 		tr.StepRunnerType = RunnerTypeExec
 	case s.includeRunner != nil && s.includeConfig != nil:
 		tr.StepRunnerType = RunnerTypeInclude
+	case s.parallelRunner != nil && s.parallelConfig != nil:
+		tr.StepRunnerType = RunnerTypeParallel
 	case s.dumpRunner != nil && s.dumpRequest != nil:
 		tr.StepRunnerType = RunnerTypeDump
 	case s.bindRunner != nil && s.bindCond != nil:
 		tr.StepRunnerType = RunnerTypeBind
+	case s.snapshotRunner != nil && s.snapshotRequest != nil:
+		tr.StepRunnerType = RunnerTypeSnapshot
+	case s.jsonSchemaRunner != nil && s.jsonSchemaRequest != nil:
+		tr.StepRunnerType = RunnerTypeJSONSchema
 	case s.testRunner != nil && s.testCond != "":
 		tr.StepRunnerType = RunnerTypeTest
 	}
@@ -154,6 +178,9 @@ func (s *step) notYetDetectedRunner() bool {
 		s.grpcRunner == nil &&
 		s.cdpRunner == nil &&
 		s.sshRunner == nil &&
+		s.websocketRunner == nil &&
+		s.mockRunner == nil &&
+		s.queueRunner == nil &&
 		s.execRunner == nil &&
 		len(s.runnerValues) > 0
 }
@@ -13,6 +13,8 @@ import (
 
 	"github.com/k1LoW/sshc/v4"
 	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/segmentio/kafka-go"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -21,6 +23,7 @@ const (
 	HTTPBinHostname = "myhttpbin"
 	MySQLHostname   = "mydb"
 	SSHdHostname    = "mysshd"
+	KafkaHostname   = "mykafka"
 )
 
 func CreateHTTPBinContainer(t *testing.T) string {
@@ -187,6 +190,62 @@ func CreateSSHdContainer(t *testing.T) (*ssh.Client, string, string, string, int
 	return client, host, "127.0.0.1", "testuser", port
 }
 
+func CreateKafkaContainer(t *testing.T) string {
+	t.Helper()
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatalf("Could not connect to docker: %s", err)
+	}
+	// Kafka clients connect to the advertised listener, so bind the fixed port.
+	const port = "9092"
+	opt := &dockertest.RunOptions{
+		Hostname:   KafkaHostname,
+		Repository: "apache/kafka",
+		Tag:        "3.7.0",
+		Env: []string{
+			"KAFKA_NODE_ID=1",
+			"KAFKA_PROCESS_ROLES=broker,controller",
+			"KAFKA_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
+			fmt.Sprintf("KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://localhost:%s", port),
+			"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CONTROLLER_QUORUM_VOTERS=1@localhost:9093",
+			"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR=1",
+			"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR=1",
+			"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR=1",
+			"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS=0",
+		},
+		Networks: []*dockertest.Network{runnTestNetwork(t)},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"9092/tcp": {{HostIP: "127.0.0.1", HostPort: port}},
+		},
+	}
+	k, err := pool.RunWithOptions(opt)
+	if err != nil {
+		t.Fatalf("Could not start resource: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := pool.Purge(k); err != nil {
+			t.Fatalf("Could not purge resource: %s", err)
+		}
+	})
+
+	addr := fmt.Sprintf("localhost:%s", port)
+	if err := pool.Retry(func() error {
+		conn, err := kafka.Dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Brokers()
+		return err
+	}); err != nil {
+		t.Fatalf("Could not connect to kafka: %s", err)
+	}
+
+	return fmt.Sprintf("kafka://%s", addr)
+}
+
 func runnTestNetwork(t *testing.T) *dockertest.Network {
 	pool, err := dockertest.NewPool("")
 	if err != nil {
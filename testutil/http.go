@@ -121,6 +121,20 @@ func setRoutes(r *httpstub.Router) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(fmt.Sprintf(`{"sleep": %d}`, i)))
 	})
+	r.Method(http.MethodGet).Path("/events").Header("Content-Type", "text/event-stream").Handler(func(w http.ResponseWriter, r *http.Request) {
+		// Send events until the client disconnects
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(": comment\n\ndata: hello\ndata: world\n\n"))
+		for i := 1; ; i++ {
+			_, _ = w.Write([]byte(fmt.Sprintf("event: tick\nid: %d\ndata: {\"count\": %d}\n\n", i, i)))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
 	r.Method(http.MethodGet).Path("/hello").Header("Content-Type", "text/html; charset=utf-8").ResponseString(http.StatusOK, "<h1>Hello</h1>")
 	r.Method(http.MethodPost).Path("/upload").Header("Content-Type", "text/html; charset=utf-8").ResponseString(http.StatusCreated, "<h1>Posted</h1>")
 	r.Method(http.MethodGet).Path("/ping").Header("Content-Type", "application/json").
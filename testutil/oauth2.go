@@ -0,0 +1,135 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	OAuth2ClientID     = "runn"
+	OAuth2ClientSecret = "secret"
+	OAuth2Username     = "alice"
+	OAuth2Password     = "passw0rd"
+	// OAuth2RefreshToken is the refresh token accepted in addition to issued ones.
+	OAuth2RefreshToken = "initial-refresh-token"
+)
+
+// OAuth2Stub is a stub of the OAuth2 authorization server with the protected resource `/me`.
+type OAuth2Stub struct {
+	*httptest.Server
+	mu            sync.Mutex
+	accessTokens  map[string]struct{}
+	refreshTokens map[string]struct{}
+	grants        []string
+	expiresIn     int
+}
+
+func OAuth2Server(t testing.TB) *OAuth2Stub {
+	s := &OAuth2Stub{
+		accessTokens:  map[string]struct{}{},
+		refreshTokens: map[string]struct{}{OAuth2RefreshToken: {}},
+		expiresIn:     3600,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":         s.URL,
+			"token_endpoint": s.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		_, valid := s.accessTokens[tok]
+		s.mu.Unlock()
+		if !ok || !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"token": tok})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+// Grants returns the grant types requested to the token endpoint.
+func (s *OAuth2Stub) Grants() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.grants...)
+}
+
+// Revoke revokes all issued access tokens.
+func (s *OAuth2Stub) Revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = map[string]struct{}{}
+}
+
+// SetExpiresIn sets the lifetime in seconds of access tokens issued after this.
+func (s *OAuth2Stub) SetExpiresIn(sec int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresIn = sec
+}
+
+func (s *OAuth2Stub) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != OAuth2ClientID || secret != OAuth2ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_client"})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grant := r.PostForm.Get("grant_type")
+	s.grants = append(s.grants, grant)
+	switch grant {
+	case "client_credentials":
+	case "password":
+		if r.PostForm.Get("username") != OAuth2Username || r.PostForm.Get("password") != OAuth2Password {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+			return
+		}
+	case "refresh_token":
+		if _, ok := s.refreshTokens[r.PostForm.Get("refresh_token")]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+			return
+		}
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+	n := len(s.grants)
+	at := fmt.Sprintf("access-token-%d", n)
+	rt := fmt.Sprintf("refresh-token-%d", n)
+	s.accessTokens[at] = struct{}{}
+	s.refreshTokens[rt] = struct{}{}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  at,
+		"token_type":    "Bearer",
+		"expires_in":    s.expiresIn,
+		"refresh_token": rt,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,243 @@
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const (
+	ProxyUsername = "proxyuser"
+	ProxyPassword = "proxypass"
+)
+
+// ProxyStub is a stub of the forward proxy that supports plain HTTP requests and the CONNECT method.
+type ProxyStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+// ProxyServer returns the forward proxy. If withAuth is true, the proxy requires Basic authentication with ProxyUsername and ProxyPassword.
+func ProxyServer(t testing.TB, withAuth bool) *ProxyStub {
+	s := &ProxyStub{}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(ProxyUsername+":"+ProxyPassword))
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if withAuth && r.Header.Get("Proxy-Authorization") != want {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="runn"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, fmt.Sprintf("%s %s", r.Method, r.Host))
+		s.mu.Unlock()
+		if r.Method == http.MethodConnect {
+			s.tunnel(w, r)
+			return
+		}
+		s.forward(w, r)
+	}))
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+// Requests returns the requests passed through the proxy in the form of "METHOD host".
+func (s *ProxyStub) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *ProxyStub) tunnel(w http.ResponseWriter, r *http.Request) {
+	dst, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		_ = dst.Close()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	src, brw, err := hj.Hijack()
+	if err != nil {
+		_ = dst.Close()
+		return
+	}
+	if _, err := src.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		_ = src.Close()
+		_ = dst.Close()
+		return
+	}
+	if n := brw.Reader.Buffered(); n > 0 {
+		b, _ := brw.Reader.Peek(n)
+		_, _ = dst.Write(b)
+	}
+	pipe(src, dst)
+}
+
+func (s *ProxyStub) forward(w http.ResponseWriter, r *http.Request) {
+	req := r.Clone(r.Context())
+	req.RequestURI = ""
+	req.Header.Del("Proxy-Authorization")
+	req.Header.Del("Proxy-Connection")
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	res, err := tr.RoundTrip(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+// SOCKS5Stub is a stub of the SOCKS5 proxy that supports the CONNECT command without authentication.
+type SOCKS5Stub struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	requests []string
+}
+
+func SOCKS5Server(t testing.TB) *SOCKS5Stub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &SOCKS5Stub{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	return s
+}
+
+func (s *SOCKS5Stub) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Requests returns the destination addresses requested to the proxy.
+func (s *SOCKS5Stub) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *SOCKS5Stub) handle(conn net.Conn) {
+	addr, err := s.handshake(conn)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	dst, err := net.Dial("tcp", addr)
+	if err != nil {
+		// host unreachable
+		_, _ = conn.Write([]byte{0x05, 0x04, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		_ = conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, addr)
+	s.mu.Unlock()
+	if _, err := conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		_ = conn.Close()
+		_ = dst.Close()
+		return
+	}
+	pipe(conn, dst)
+}
+
+// handshake negotiates no authentication and reads the destination address of the CONNECT command ( RFC 1928 ).
+func (s *SOCKS5Stub) handshake(conn net.Conn) (string, error) {
+	h := make([]byte, 2)
+	if _, err := io.ReadFull(conn, h); err != nil {
+		return "", err
+	}
+	if h[0] != 0x05 {
+		return "", errors.New("unsupported version")
+	}
+	if _, err := io.ReadFull(conn, make([]byte, h[1])); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return "", err
+	}
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return "", err
+	}
+	if req[1] != 0x01 {
+		return "", errors.New("unsupported command")
+	}
+	var host string
+	switch req[3] {
+	case 0x01:
+		b := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return "", err
+		}
+		host = net.IP(b).String()
+	case 0x03:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(conn, l); err != nil {
+			return "", err
+		}
+		b := make([]byte, l[0])
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return "", err
+		}
+		host = string(b)
+	case 0x04:
+		b := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return "", err
+		}
+		host = net.IP(b).String()
+	default:
+		return "", errors.New("unsupported address type")
+	}
+	p := make([]byte, 2)
+	if _, err := io.ReadFull(conn, p); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(p)))), nil
+}
+
+func pipe(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(b, a)
+		_ = b.Close()
+	}()
+	wg.Wait()
+}
@@ -13,21 +13,30 @@ const (
 	TrailTypeBeforeFunc TrailType = "beforeFunc"
 	TrailTypeAfterFunc  TrailType = "afterFunc"
 	TrailTypeLoop       TrailType = "loop"
+	TrailTypeBranch     TrailType = "branch"
+	TrailTypeHTTPTiming TrailType = "httpTiming"
+	TrailTypeRetry      TrailType = "retry"
 )
 
 type RunnerType string
 
 const (
-	RunnerTypeHTTP    RunnerType = "http"
-	RunnerTypeDB      RunnerType = "db"
-	RunnerTypeGRPC    RunnerType = "grpc"
-	RunnerTypeCDP     RunnerType = "cdp"
-	RunnerTypeSSH     RunnerType = "ssh"
-	RunnerTypeExec    RunnerType = "exec"
-	RunnerTypeTest    RunnerType = "test"
-	RunnerTypeDump    RunnerType = "dump"
-	RunnerTypeInclude RunnerType = "include"
-	RunnerTypeBind    RunnerType = "bind"
+	RunnerTypeHTTP       RunnerType = "http"
+	RunnerTypeDB         RunnerType = "db"
+	RunnerTypeGRPC       RunnerType = "grpc"
+	RunnerTypeCDP        RunnerType = "cdp"
+	RunnerTypeSSH        RunnerType = "ssh"
+	RunnerTypeWebSocket  RunnerType = "websocket"
+	RunnerTypeMock       RunnerType = "mock"
+	RunnerTypeQueue      RunnerType = "queue"
+	RunnerTypeExec       RunnerType = "exec"
+	RunnerTypeTest       RunnerType = "test"
+	RunnerTypeDump       RunnerType = "dump"
+	RunnerTypeInclude    RunnerType = "include"
+	RunnerTypeParallel   RunnerType = "parallel"
+	RunnerTypeBind       RunnerType = "bind"
+	RunnerTypeSnapshot   RunnerType = "snapshot"
+	RunnerTypeJSONSchema RunnerType = "jsonSchema"
 )
 
 // Trail - The trail of elements in the runbook at runtime.
@@ -43,6 +52,9 @@ type Trail struct {
 	StepRunnerKey  string     `json:"step_runner_key,omitempty"`
 	FuncIndex      *int       `json:"func_index,omitempty"`
 	LoopIndex      *int       `json:"loop_index,omitempty"`
+	BranchKey      string     `json:"branch_key,omitempty"`
+	TimingKey      string     `json:"timing_key,omitempty"`
+	RetryIndex     *int       `json:"retry_index,omitempty"`
 }
 
 type Trails []Trail
@@ -59,6 +71,12 @@ func (tr Trail) String() string { //nostyle:recvtype
 		return fmt.Sprintf("afterFunc[%d]", *tr.FuncIndex)
 	case TrailTypeLoop:
 		return fmt.Sprintf("loop[%d]", *tr.LoopIndex)
+	case TrailTypeBranch:
+		return fmt.Sprintf("branch[%s]", tr.BranchKey)
+	case TrailTypeHTTPTiming:
+		return fmt.Sprintf("timing[%s]", tr.TimingKey)
+	case TrailTypeRetry:
+		return fmt.Sprintf("retry[%d]", *tr.RetryIndex)
 	default:
 		return "invalid"
 	}
@@ -0,0 +1,409 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fsnotify/fsnotify"
+	"github.com/k1LoW/runn/tmpmod/github.com/goccy/go-yaml"
+)
+
+// watchDebounce - Duration to wait for subsequent file changes before re-running runbooks.
+const watchDebounce = 100 * time.Millisecond
+
+// Watcher watches runbooks and the local files they depend on.
+type Watcher struct {
+	pathp string
+	fsw   *fsnotify.Watcher
+	// books - Absolute paths of the runbooks matching pathp.
+	books []string
+	// deps - Files and directories that each runbook depends on.
+	deps map[string][]watchTarget
+	// global - Files and directories that all runbooks depend on ( e.g. --grpc-proto ).
+	global []watchTarget
+	// bases - Directories to detect runbooks newly matching pathp.
+	bases   []watchTarget
+	watched map[string]struct{}
+}
+
+type watchTarget struct {
+	path string
+	// dir - If true, all files under the directory are targets.
+	dir bool
+}
+
+func (t watchTarget) match(p string) bool {
+	if p == t.path {
+		return true
+	}
+	return t.dir && strings.HasPrefix(p, t.path+string(filepath.Separator))
+}
+
+// NewWatcher returns a new Watcher for the runbooks matching pathp.
+// paths are the local files and directories that all runbooks depend on ( e.g. the paths specified by --grpc-proto ).
+// The paths can be specified in the "key:path" format.
+func NewWatcher(pathp string, paths ...string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		pathp:   pathp,
+		fsw:     fsw,
+		deps:    map[string][]watchTarget{},
+		watched: map[string]struct{}{},
+	}
+	global, err := globalWatchTargets(paths)
+	if err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	w.global = global
+	for _, pp := range splitList(pathp) {
+		if hasRemotePrefix(pp) {
+			continue
+		}
+		base, _ := doublestar.SplitPattern(filepath.ToSlash(pp))
+		base = filepath.FromSlash(base)
+		if fi, err := os.Stat(base); err == nil && !fi.IsDir() {
+			base = filepath.Dir(base)
+		}
+		abs, err := filepath.Abs(base)
+		if err != nil {
+			_ = fsw.Close()
+			return nil, err
+		}
+		w.bases = append(w.bases, watchTarget{path: abs, dir: true})
+	}
+	if err := w.addTargets(slices.Concat(w.global, w.bases)); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	if _, err := w.refresh(); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Runbooks returns the paths of the watched runbooks.
+func (w *Watcher) Runbooks() []string {
+	return slices.Clone(w.books)
+}
+
+// Wait blocks until files that runbooks depend on change, and returns the paths of the affected runbooks.
+func (w *Watcher) Wait(ctx context.Context) ([]string, error) {
+	var (
+		changed []string
+		timer   <-chan time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil, errors.New("watcher is closed")
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					if err := w.addTargets([]watchTarget{{path: ev.Name, dir: true}}); err != nil {
+						return nil, err
+					}
+				}
+			}
+			changed = append(changed, ev.Name)
+			timer = time.After(watchDebounce)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil, errors.New("watcher is closed")
+			}
+			return nil, err
+		case <-timer:
+			affected, err := w.affected(changed)
+			if err != nil {
+				return nil, err
+			}
+			changed = nil
+			timer = nil
+			if len(affected) > 0 {
+				return affected, nil
+			}
+		}
+	}
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) affected(changed []string) ([]string, error) {
+	added, err := w.refresh()
+	if err != nil {
+		return nil, err
+	}
+	affected := added
+	for _, b := range w.books {
+		if slices.Contains(affected, b) {
+			continue
+		}
+		targets := slices.Concat(w.global, w.deps[b])
+		if slices.ContainsFunc(changed, func(p string) bool {
+			return slices.ContainsFunc(targets, func(t watchTarget) bool {
+				return t.match(p)
+			})
+		}) {
+			affected = append(affected, b)
+		}
+	}
+	// Dependencies may have changed ( e.g. `include:` added to the runbook ).
+	for _, b := range affected {
+		if err := w.update(b); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(affected)
+	return affected, nil
+}
+
+// refresh updates the runbooks matching pathp and returns the newly matched runbooks.
+func (w *Watcher) refresh() ([]string, error) {
+	paths, err := fetchPaths(w.pathp)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		books []string
+		added []string
+	)
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, abs)
+		if _, ok := w.deps[abs]; ok {
+			continue
+		}
+		added = append(added, abs)
+		if err := w.update(abs); err != nil {
+			return nil, err
+		}
+	}
+	for b := range w.deps {
+		if !slices.Contains(books, b) {
+			delete(w.deps, b)
+		}
+	}
+	w.books = books
+	return added, nil
+}
+
+func (w *Watcher) update(book string) error {
+	targets := runbookWatchTargets(book, map[string]struct{}{})
+	w.deps[book] = targets
+	return w.addTargets(targets)
+}
+
+func (w *Watcher) addTargets(targets []watchTarget) error {
+	for _, t := range targets {
+		if !t.dir {
+			if err := w.add(filepath.Dir(t.path)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := filepath.WalkDir(t.path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			// Skip hidden directories such as .git
+			if p != t.path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return w.add(p)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) add(dir string) error {
+	if _, ok := w.watched[dir]; ok {
+		return nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		// The directory may be created later.
+		return nil
+	}
+	if err := w.fsw.Add(dir); err != nil {
+		return err
+	}
+	w.watched[dir] = struct{}{}
+	return nil
+}
+
+// runbookWatchTargets returns the runbook and the local files it depends on.
+// The runbook that cannot be parsed depends only on itself, and the error is reported when it is loaded.
+func runbookWatchTargets(p string, seen map[string]struct{}) []watchTarget {
+	if _, ok := seen[p]; ok {
+		return nil
+	}
+	seen[p] = struct{}{}
+	targets := []watchTarget{{path: p}}
+	f, err := os.Open(p)
+	if err != nil {
+		return targets
+	}
+	bk, err := parseBook(f)
+	_ = f.Close()
+	if err != nil {
+		return targets
+	}
+	root := filepath.Dir(p)
+	for _, v := range bk.vars {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		for _, e := range evaluators {
+			if strings.HasPrefix(s, e.scheme) {
+				targets = append(targets, fileWatchTarget(strings.TrimPrefix(s, e.scheme), root))
+			}
+		}
+	}
+	for _, v := range bk.runners {
+		targets = append(targets, runnerWatchTargets(v, root)...)
+	}
+	for _, ip := range includePaths(bk.rawSteps) {
+		if hasRemotePrefix(ip) || strings.Contains(ip, "{{") {
+			continue
+		}
+		targets = append(targets, runbookWatchTargets(filepath.Join(root, ip), seen)...)
+	}
+	return targets
+}
+
+func runnerWatchTargets(v any, root string) []watchTarget {
+	if _, ok := v.(map[string]any); !ok {
+		return nil
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var targets []watchTarget
+	hc := &httpRunnerConfig{}
+	if err := yaml.Unmarshal(b, hc); err == nil {
+		for _, l := range []string{hc.OpenAPI3DocLocation, hc.GraphQLSchemaLocation} {
+			if l == "" || strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://") {
+				continue
+			}
+			targets = append(targets, fileWatchTarget(l, root))
+		}
+	}
+	gc := &grpcRunnerConfig{}
+	if err := yaml.Unmarshal(b, gc); err == nil {
+		targets = append(targets, protoWatchTargets(gc.ImportPaths, gc.Protos, gc.BufDirs, gc.BufLocks, gc.BufConfigs, root)...)
+		for _, p := range gc.Protosets {
+			targets = append(targets, fileWatchTarget(p, root))
+		}
+	}
+	mc := &mockRunnerConfig{}
+	if err := yaml.Unmarshal(b, mc); err == nil && mc.Mock != nil && mc.Mock.GRPC != nil {
+		g := mc.Mock.GRPC
+		targets = append(targets, protoWatchTargets(g.ImportPaths, g.Protos, g.BufDirs, g.BufLocks, g.BufConfigs, root)...)
+	}
+	return targets
+}
+
+func protoWatchTargets(importPaths, protos, bufDirs, bufLocks, bufConfigs []string, root string) []watchTarget {
+	var targets []watchTarget
+	for _, p := range slices.Concat(importPaths, bufDirs) {
+		targets = append(targets, watchTarget{path: fp(p, root), dir: true})
+	}
+	for _, p := range slices.Concat(protos, bufLocks, bufConfigs) {
+		targets = append(targets, fileWatchTarget(p, root))
+	}
+	return targets
+}
+
+func globalWatchTargets(paths []string) ([]watchTarget, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var targets []watchTarget
+	for _, kp := range paths {
+		_, p := splitKeyAndPath(kp)
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+			continue
+		}
+		if fi, err := os.Stat(fp(p, wd)); err == nil && fi.IsDir() {
+			targets = append(targets, watchTarget{path: fp(p, wd), dir: true})
+			continue
+		}
+		targets = append(targets, fileWatchTarget(p, wd))
+	}
+	return targets, nil
+}
+
+// fileWatchTarget returns the target of the path which may contain wildcards.
+func fileWatchTarget(p, root string) watchTarget {
+	p = fp(p, root)
+	if !strings.Contains(p, "*") {
+		return watchTarget{path: p}
+	}
+	base, _ := doublestar.SplitPattern(filepath.ToSlash(p))
+	return watchTarget{path: filepath.FromSlash(base), dir: true}
+}
+
+// includePaths returns the paths of `include:` in the steps including steps of `parallel:`.
+func includePaths(v any) []string {
+	var paths []string
+	switch vv := v.(type) {
+	case []map[string]any:
+		for _, s := range vv {
+			paths = append(paths, includePaths(s)...)
+		}
+	case []any:
+		for _, s := range vv {
+			paths = append(paths, includePaths(s)...)
+		}
+	case map[string]any:
+		for k, s := range vv {
+			if k == includeRunnerKey {
+				switch c := s.(type) {
+				case string:
+					paths = append(paths, c)
+					continue
+				case map[string]any:
+					if p, ok := c["path"].(string); ok {
+						paths = append(paths, p)
+					}
+					continue
+				}
+			}
+			paths = append(paths, includePaths(s)...)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,143 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatcher(t *testing.T) {
+	if err := setScopes(ScopeAllowReadParent); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yml": `desc: a
+steps:
+  -
+    include: included/b.yml
+`,
+		"c.yml": `desc: c
+vars:
+  users: json://vars.json
+steps:
+  -
+    parallel:
+      steps:
+        -
+          include:
+            path: included/e.yml
+`,
+		"included/b.yml": `desc: b
+steps:
+  -
+    test: true
+`,
+		"included/e.yml": `desc: e
+steps:
+  -
+    test: true
+`,
+		"vars.json":  `[]`,
+		"README.txt": `unrelated`,
+	}
+	for p, c := range files {
+		writeTestFile(t, filepath.Join(dir, p), c)
+	}
+	w, err := NewWatcher(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = w.Close()
+	})
+	if diff := cmp.Diff(w.Runbooks(), []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "c.yml")}); diff != "" {
+		t.Error(diff)
+	}
+
+	tests := []struct {
+		name    string
+		change  string
+		want    []string
+		timeout bool
+	}{
+		{"runbook", "a.yml", []string{"a.yml"}, false},
+		{"included runbook", "included/b.yml", []string{"a.yml"}, false},
+		{"runbook included in parallel", "included/e.yml", []string{"c.yml"}, false},
+		{"vars file", "vars.json", []string{"c.yml"}, false},
+		{"new runbook", "d.yml", []string{"d.yml"}, false},
+		{"unrelated file", "README.txt", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c, ok := files[tt.change]
+			if !ok {
+				c = "desc: new\nsteps:\n  -\n    test: true\n"
+			}
+			c += "\n"
+			writeTestFile(t, filepath.Join(dir, tt.change), c)
+			got, err := w.Wait(ctx)
+			if tt.timeout {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("got %v %v\nwant timeout", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, p := range tt.want {
+				want = append(want, filepath.Join(dir, p))
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, p, c string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(c), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlobalWatchTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "protos", "a.proto"), `syntax = "proto3";`)
+	writeTestFile(t, filepath.Join(dir, "openapi.yml"), `openapi: 3.0.0`)
+	got, err := globalWatchTargets([]string{
+		filepath.Join(dir, "protos"),
+		"req:" + filepath.Join(dir, "openapi.yml"),
+		filepath.Join(dir, "protos", "*.proto"),
+		"https://example.com/openapi.yml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []watchTarget{
+		{path: filepath.Join(dir, "protos"), dir: true},
+		{path: filepath.Join(dir, "openapi.yml")},
+		{path: filepath.Join(dir, "protos"), dir: true},
+	}
+	if diff := cmp.Diff(got, want, cmp.AllowUnexported(watchTarget{})); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,250 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+)
+
+type WebSocketOp string
+
+const (
+	WebSocketOpSend    WebSocketOp = "send"
+	WebSocketOpReceive WebSocketOp = "receive"
+	WebSocketOpClose   WebSocketOp = "close"
+)
+
+const (
+	websocketStoreMessageKey  = "message"
+	websocketStoreMessagesKey = "messages"
+	websocketStoreClosedKey   = "closed"
+	websocketStoreResponseKey = "res"
+)
+
+const websocketDefaultTimeout = 30 * time.Second
+
+type websocketRunner struct {
+	name       string
+	url        *url.URL
+	headers    http.Header
+	timeout    time.Duration
+	skipVerify bool
+	conn       *websocketConn
+	hostRules  hostRules
+	mu         sync.Mutex
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+// websocketConn - net.Conn that reads data buffered during the handshake first.
+type websocketConn struct {
+	net.Conn
+	r io.Reader
+}
+
+type websocketMessage struct {
+	op     WebSocketOp
+	params any
+}
+
+type websocketRequest struct {
+	messages []*websocketMessage
+	timeout  time.Duration
+}
+
+func newWebSocketRunner(name, u string) (*websocketRunner, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if pu.Scheme != "ws" && pu.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid WebSocket URL: %s", u)
+	}
+	return &websocketRunner{
+		name:    name,
+		url:     pu,
+		headers: http.Header{},
+		timeout: websocketDefaultTimeout,
+	}, nil
+}
+
+func (c *websocketConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (rnr *websocketRunner) Renew() error {
+	return rnr.Close()
+}
+
+func (rnr *websocketRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.conn == nil {
+		return nil
+	}
+	conn := rnr.conn
+	rnr.conn = nil
+	return conn.Close()
+}
+
+func (rnr *websocketRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	req, err := parseWebSocketRequest(s.websocketRequest, o.expandBeforeRecord)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *websocketRunner) run(ctx context.Context, r *websocketRequest, s *step) error {
+	o := s.parent
+	if err := rnr.connect(ctx, o); err != nil {
+		return err
+	}
+	timeout := rnr.timeout
+	if r.timeout > 0 {
+		timeout = r.timeout
+	}
+	u := rnr.url.String()
+	o.capturers.captureWebSocketStart(rnr.name, u)
+	defer o.capturers.captureWebSocketEnd(rnr.name, u)
+
+	d := map[string]any{
+		websocketStoreMessageKey: nil,
+		websocketStoreClosedKey:  false,
+	}
+	messages := []any{}
+L:
+	for _, m := range r.messages {
+		switch m.op {
+		case WebSocketOpSend:
+			// Lazy expand due to the possibility of computing variables between multiple messages.
+			e, err := o.expandBeforeRecord(m.params)
+			if err != nil {
+				return err
+			}
+			var payload []byte
+			switch v := e.(type) {
+			case string:
+				payload = []byte(v)
+			default:
+				payload, err = json.Marshal(v)
+				if err != nil {
+					return err
+				}
+			}
+			o.capturers.captureWebSocketSendMessage(e)
+			if err := rnr.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+				return err
+			}
+			if err := wsutil.WriteClientMessage(rnr.conn, ws.OpText, payload); err != nil {
+				return err
+			}
+		case WebSocketOpReceive:
+			if err := rnr.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				return err
+			}
+			b, op, err := wsutil.ReadServerData(rnr.conn)
+			if err != nil {
+				var cerr wsutil.ClosedError
+				if errors.As(err, &cerr) {
+					// Closed by server
+					o.capturers.captureWebSocketClose()
+					if err := rnr.Close(); err != nil {
+						return err
+					}
+					d[websocketStoreClosedKey] = true
+					break L
+				}
+				return fmt.Errorf("failed to receive WebSocket message: %w", err)
+			}
+			msg := decodeWebSocketMessage(b, op)
+			o.capturers.captureWebSocketReceiveMessage(msg)
+			d[websocketStoreMessageKey] = msg
+			messages = append(messages, msg)
+		case WebSocketOpClose:
+			body := ws.NewCloseFrameBody(ws.StatusNormalClosure, "")
+			if err := ws.WriteFrame(rnr.conn, ws.MaskFrameInPlace(ws.NewCloseFrame(body))); err != nil {
+				return err
+			}
+			o.capturers.captureWebSocketClose()
+			if err := rnr.Close(); err != nil {
+				return err
+			}
+			d[websocketStoreClosedKey] = true
+			break L
+		default:
+			return fmt.Errorf("invalid op: %v", m.op)
+		}
+	}
+	d[websocketStoreMessagesKey] = messages
+
+	o.record(map[string]any{
+		websocketStoreResponseKey: d,
+	})
+	return nil
+}
+
+func (rnr *websocketRunner) connect(ctx context.Context, o *operator) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.conn != nil {
+		return nil
+	}
+	d := ws.Dialer{
+		Header:  ws.HandshakeHeaderHTTP(rnr.headers),
+		Timeout: rnr.timeout,
+	}
+	if rnr.skipVerify {
+		//#nosec G402
+		d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if len(rnr.hostRules) > 0 {
+		d.NetDial = rnr.hostRules.dialContextFunc()
+	}
+	conn, br, _, err := d.Dial(ctx, rnr.url.String())
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", rnr.url.String(), err)
+	}
+	c := &websocketConn{Conn: conn, r: conn}
+	if br != nil {
+		c.r = io.MultiReader(br, conn)
+	}
+	rnr.conn = c
+	if err := donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Close()
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func decodeWebSocketMessage(b []byte, op ws.OpCode) any {
+	if op == ws.OpBinary {
+		return b
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err == nil {
+		return v
+	}
+	return string(b)
+}
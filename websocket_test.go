@@ -0,0 +1,140 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+)
+
+func TestNewWebSocketRunner(t *testing.T) {
+	tests := []struct {
+		u       string
+		wantErr bool
+	}{
+		{"ws://localhost:8080/ws", false},
+		{"wss://example.com/ws", false},
+		{"http://example.com/ws", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.u, func(t *testing.T) {
+			_, err := newWebSocketRunner("req", tt.u)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestWebSocketRun(t *testing.T) {
+	ts := newWebSocketEchoServer(t)
+	tests := []struct {
+		name string
+		req  map[string]any
+		want map[string]any
+	}{
+		{
+			"send and receive text",
+			map[string]any{
+				"messages": []any{
+					map[string]any{"send": "hello"},
+					"receive",
+				},
+			},
+			map[string]any{
+				"res": map[string]any{
+					"message":  "hello",
+					"messages": []any{"hello"},
+					"closed":   false,
+				},
+			},
+		},
+		{
+			"send and receive json",
+			map[string]any{
+				"messages": []any{
+					map[string]any{"send": map[string]any{"greeting": "alice"}},
+					"receive",
+					map[string]any{"send": map[string]any{"greeting": "bob"}},
+					"receive",
+					"close",
+				},
+			},
+			map[string]any{
+				"res": map[string]any{
+					"message": map[string]any{"greeting": "bob"},
+					"messages": []any{
+						map[string]any{"greeting": "alice"},
+						map[string]any{"greeting": "bob"},
+					},
+					"closed": true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newWebSocketRunner("ws", ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			s := newStep(0, "stepKey", o, nil)
+			req, err := parseWebSocketRequest(tt.req, o.expandBeforeRecord)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.run(ctx, req, s); err != nil {
+				t.Fatal(err)
+			}
+			got := o.store.steps[0]
+			if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func newWebSocketEchoServer(t *testing.T) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				b, op, err := wsutil.ReadClientData(conn)
+				if err != nil {
+					return
+				}
+				if err := wsutil.WriteServerMessage(conn, op, b); err != nil {
+					return
+				}
+			}
+		}()
+	}))
+	t.Cleanup(ts.Close)
+	return strings.Replace(ts.URL, "http://", "ws://", 1)
+}